@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/go-logr/logr"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// exitCodeFailure is the exit code of all other fatal startup errors
+	exitCodeFailure = 1
+	// exitCodeInvalidFlags is the exit code of missing or invalid flags
+	exitCodeInvalidFlags = 2
+	// exitCodeKubeconfig is the exit code if a kubeconfig cannot be used
+	exitCodeKubeconfig = 3
+	// exitCodeAWSAuth is the exit code if the AWS credentials cannot be loaded, are invalid or lack permissions
+	exitCodeAWSAuth = 4
+	// exitCodeAWSConnectivity is the exit code if the AWS API cannot be reached or fails otherwise
+	exitCodeAWSConnectivity = 5
+)
+
+// exitCodesUsage documents the exit codes in the help text
+var exitCodesUsage = fmt.Sprintf(`
+Exit codes:
+  %d  other fatal startup errors
+  %d  missing or invalid flags
+  %d  unusable kubeconfig
+  %d  AWS credentials not loadable, invalid or lacking permissions
+  %d  AWS API not reachable or failing otherwise
+`, exitCodeFailure, exitCodeInvalidFlags, exitCodeKubeconfig, exitCodeAWSAuth, exitCodeAWSConnectivity)
+
+// awsCredentialErrorCodes are the AWS error codes of missing or invalid credentials
+var awsCredentialErrorCodes = map[string]bool{
+	"NoCredentialProviders":       true,
+	"InvalidClientTokenId":        true,
+	"SignatureDoesNotMatch":       true,
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"UnrecognizedClientException": true,
+}
+
+// exit terminates the process with the exit code, replaced in tests
+var exit = os.Exit
+
+// usage prints the flags and the exit codes
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+	pflag.PrintDefaults()
+	fmt.Fprint(os.Stderr, exitCodesUsage)
+}
+
+// fatal logs the error and exits with the exit code
+func fatal(log logr.Logger, code int, err error, msg string, keysAndValues ...interface{}) {
+	log.Error(err, msg, keysAndValues...)
+	exit(code)
+}
+
+// awsExitCode returns the exit code of a failed AWS operation. Authentication and authorization failures
+// result in exitCodeAWSAuth, network and other AWS API errors in exitCodeAWSConnectivity, and all other errors in the fallback.
+func awsExitCode(err error, fallback int) int {
+	for _, reason := range controller.ErrorReasons(err) {
+		if reason == controller.ReasonUnauthorized {
+			return exitCodeAWSAuth
+		}
+	}
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		if awsCredentialErrorCodes[awsErr.Code()] {
+			return exitCodeAWSAuth
+		}
+		return exitCodeAWSConnectivity
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return exitCodeAWSConnectivity
+	}
+	return fallback
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// exited is raised by the replaced exit function to stop the execution
+type exited struct {
+	code int
+}
+
+// exitCodeOf runs f and returns the code it exits with
+func exitCodeOf(f func()) (code int) {
+	defer func() {
+		r := recover()
+		Expect(r).To(BeAssignableToTypeOf(exited{}))
+		code = r.(exited).code
+	}()
+	f()
+	return -1
+}
+
+var _ = Describe("exit codes", func() {
+	BeforeEach(func() {
+		DeferCleanup(func(exitFunc func(int), usageFunc func()) {
+			exit = exitFunc
+			pflag.Usage = usageFunc
+		}, exit, pflag.Usage)
+		exit = func(code int) { panic(exited{code: code}) }
+		pflag.Usage = func() {}
+	})
+
+	It("should exit with the invalid flags code if a required flag is missing", func() {
+		Expect(exitCodeOf(func() { checkRequiredFlag(logf.Log, "region", "") })).To(Equal(exitCodeInvalidFlags))
+	})
+
+	It("should exit with the kubeconfig code if the kubeconfig cannot be used", func() {
+		kubeconfig := GinkgoT().TempDir() + "/missing"
+		Expect(exitCodeOf(func() { buildConfig(logf.Log, "target-kubeconfig", kubeconfig) })).To(Equal(exitCodeKubeconfig))
+	})
+
+	It("should exit with the given code", func() {
+		Expect(exitCodeOf(func() { fatal(logf.Log, exitCodeAWSConnectivity, errors.New("failed"), "failed") })).To(Equal(exitCodeAWSConnectivity))
+	})
+
+	DescribeTable("should classify the AWS errors",
+		func(err error, fallback, code int) {
+			Expect(awsExitCode(err, fallback)).To(Equal(code))
+		},
+		Entry("unauthorized operation", awserr.New("UnauthorizedOperation", "denied", nil), exitCodeFailure, exitCodeAWSAuth),
+		Entry("wrapped access denied", fmt.Errorf("loading failed: %w", awserr.New("AccessDeniedException", "denied", nil)), exitCodeFailure, exitCodeAWSAuth),
+		Entry("invalid access key", awserr.New("InvalidClientTokenId", "invalid", nil), exitCodeFailure, exitCodeAWSAuth),
+		Entry("no credentials", awserr.New("NoCredentialProviders", "no valid providers in chain", nil), exitCodeFailure, exitCodeAWSAuth),
+		Entry("request error", awserr.New("RequestError", "send request failed", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}), exitCodeFailure, exitCodeAWSConnectivity),
+		Entry("network error", &net.DNSError{Err: "no such host", Name: "ec2.eu-west-1.amazonaws.com"}, exitCodeFailure, exitCodeAWSConnectivity),
+		Entry("other AWS error", awserr.New("InternalError", "internal", nil), exitCodeFailure, exitCodeAWSConnectivity),
+		Entry("non-AWS error", errors.New("missing field accessKeyID"), exitCodeAWSAuth, exitCodeAWSAuth),
+	)
+
+	It("should document the exit codes in the usage", func() {
+		Expect(exitCodesUsage).To(ContainSubstring("2  missing or invalid flags"))
+		Expect(exitCodesUsage).To(ContainSubstring("5  AWS API not reachable"))
+	})
+})
@@ -4,23 +4,47 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/gardener/aws-custom-route-controller/pkg/inventory"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
 	"github.com/gardener/aws-custom-route-controller/pkg/updater"
 	"github.com/gardener/aws-custom-route-controller/pkg/util"
 	"github.com/gardener/aws-custom-route-controller/pkg/util/logger"
 
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/go-logr/logr"
 	"github.com/spf13/pflag"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
@@ -35,118 +59,843 @@ const (
 	componentName = "aws-custom-route-controller"
 	// leaderElectionId is the name of the lease resource
 	leaderElectionId = "aws-custom-route-controller-leader-election"
+	// syncBatchPause is the pause between two batches of a full sync
+	syncBatchPause = 1 * time.Second
+	// leaderElectionRenewDeadline is the default renew deadline of the manager
+	leaderElectionRenewDeadline = 10 * time.Second
+	// recheckPeriod is the delay for repeating an update if node routes have been skipped temporarily
+	recheckPeriod = 1 * time.Minute
 )
 
 var (
 	clusterName             = pflag.String("cluster-name", "", "cluster name used for AWS tags")
+	credentialsSource       = pflag.String("credentials-source", updater.CredentialsSourceKubernetesSecret, fmt.Sprintf("source of the AWS credentials. Must be one of [%s,%s,%s].", updater.CredentialsSourceKubernetesSecret, updater.CredentialsSourceSSM, updater.CredentialsSourceSecretsManager))
+	credentialsResource     = pflag.String("credentials-resource", "", "name of the SSM parameter or Secrets Manager secret containing the AWS credentials (for credentials source ssm or secrets-manager)")
+	assumeRoleARN           = pflag.String("assume-role-arn", "", "ARN of an IAM role to assume with the loaded AWS credentials (empty to use them directly)")
+	assumeRoleSessionName   = pflag.String("assume-role-session-name", "", "IAM role session name shown in CloudTrail for the assumed role (default aws-custom-route-controller-<cluster-name>)")
+	assumeRoleSessionTags   = pflag.StringSlice("assume-role-session-tags", nil, "session tags of the assumed role in the form <key>=<value>, e.g. cluster=<cluster-name> for IAM policy conditions on aws:PrincipalTag (requires sts:TagSession)")
+	controlEventsObject     = pflag.String("control-events-object", "", "object in the namespace on control plane to additionally report events on in the form <kind>/<name>, e.g. Deployment/aws-custom-route-controller")
 	controlKubeconfig       = pflag.String("control-kubeconfig", updater.InClusterConfig, fmt.Sprintf("path of control plane kubeconfig or '%s' for in-cluster config", updater.InClusterConfig))
+	informerResyncPeriod    = pflag.Duration("informer-resync-period", 0, "period for re-listing the nodes from the API server, independent of the AWS sync period (0 for the default of 10h)")
 	healthProbePort         = pflag.Int("health-probe-port", 8081, "port for health probes")
+	healthProbeBindAddress  = pflag.String("health-probe-bind-address", "", "address for health probes in the form host:port, takes precedence over health-probe-port")
 	maxDelay                = pflag.Duration("max-delay-on-failure", 5*time.Minute, "maximum delay if communication with AWS fails")
 	metricsPort             = pflag.Int("metrics-port", 8080, "port for metrics")
+	metricsBindAddress      = pflag.String("metrics-bind-address", "", "address for metrics in the form host:port, takes precedence over metrics-port")
 	namespace               = pflag.String("namespace", "", "namespace of secret containing the AWS credentials on control plane")
-	podNetworkCidr          = pflag.String("pod-network-cidr", "", "CIDR for pod network")
+	podNetworkCidr          = pflag.String("pod-network-cidr", "", "CIDR for pod network (detected from the pod CIDRs of the existing nodes if not set)")
+	fallbackToNodeIP        = pflag.Bool("fallback-to-node-ip", false, "route the internal IP of nodes without pod CIDR as /32 to their instance (requires node-network-cidr)")
+	nodeNetworkCidr         = pflag.String("node-network-cidr", "", "CIDR of the node network, /32 routes inside of it are managed with fallback-to-node-ip")
 	region                  = pflag.String("region", "", "AWS region")
+	useFIPSEndpoints        = pflag.Bool("use-fips-endpoints", false, "use the FIPS variants of the AWS endpoints")
+	awsProxyURL             = pflag.String("aws-proxy-url", "", "proxy for all AWS requests, overriding the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY, which are used otherwise")
 	secretName              = pflag.String("secret-name", "cloudprovider", "name of secret containing the AWS credentials on control plane")
+	accessKeyIDField        = pflag.String("secret-access-key-field", updater.AccessKeyID, "name of the field in the credentials secret holding the AWS access key id")
+	secretAccessKeyField    = pflag.String("secret-secret-key-field", updater.SecretAccessKey, "name of the field in the credentials secret holding the AWS secret access key")
+	sessionTokenField       = pflag.String("secret-session-token-field", updater.SessionToken, "name of the optional field in the credentials secret holding the AWS session token")
 	syncPeriod              = pflag.Duration("sync-period", 1*time.Hour, "period for syncing routes")
-	targetKubeconfig        = pflag.String("target-kubeconfig", "", "path of target kubeconfig")
+	driftDetectionInterval  = pflag.Duration("drift-detection-interval", 0, "interval for checking the route tables for missing managed routes between the syncs (0 to disable)")
+	syncBatchSize           = pflag.Int("sync-batch-size", 0, "maximum number of nodes processed at once during a full sync (0 for unlimited)")
+	targetKubeconfig        = pflag.String("target-kubeconfig", "", fmt.Sprintf("path of target kubeconfig or '%s' if running in the target cluster (in-cluster config if not set)", updater.InClusterConfig))
 	tickPeriod              = pflag.Duration("tick-period", 5*time.Second, "tick period for checking for updates")
 	leaderElection          = pflag.Bool("leader-election", false, "enable leader election")
 	leaderElectionNamespace = pflag.String("leader-election-namespace", "kube-system", "namespace for the lease resource")
+	livenessThreshold       = pflag.Duration("liveness-threshold", 5*time.Minute, "maximum time without heartbeat of the updater loop before the health check fails (0 to disable)")
+	leaseRenewalThreshold   = pflag.Duration("lease-renewal-threshold", 1*time.Minute, "maximum time without renewal of the leader election lease before the health check fails")
 	logLevel                = pflag.String("log-level", logger.InfoLevel, "LogLevel is the level/severity for the logs. Must be one of [info,debug,error].")
-	logFormat               = pflag.String("log-format", logger.FormatJSON, "output format for the logs. Must be one of [text,json].")
+	logFormat               = pflag.String("log-format", logger.FormatJSON, "output format for the logs. Must be one of [text,json,logfmt].")
+	logLevelOverrides       = pflag.StringToString("log-level-overrides", nil, "log levels for individual loggers and their sub-loggers, e.g. updater=debug,controller=info")
+	stoppedInstancePolicy   = pflag.String("stopped-instance-policy", updater.StoppedInstancePolicyKeep, fmt.Sprintf("handling of routes to stopped instances. Must be one of [%s,%s].", updater.StoppedInstancePolicyKeep, updater.StoppedInstancePolicyRemove))
+	instanceLifecyclePolicy = pflag.String("instance-lifecycle-policy", updater.InstanceLifecyclePolicyIgnore, fmt.Sprintf("handling of routes to pending, shutting-down and terminated instances. Must be one of [%s,%s].", updater.InstanceLifecyclePolicyIgnore, updater.InstanceLifecyclePolicyStateAware))
+	reconcileDebounce       = pflag.Duration("reconcile-debounce", 0, "window after a node route change during which further changes are collected, so that a flurry of node updates results in a single route update (0 to update with the next tick)")
+	reconcileCache          = pflag.Bool("reconcile-cache", false, "skip updates if the node routes are the same as applied by the last successful update, e.g. after a node has been recreated with the same pod CIDR (full syncs, retries and detected drift always update)")
+	cleanupWithoutNodes     = pflag.Bool("cleanup-without-nodes", false, "delete routes before any node has been observed, otherwise routes are only created until the first node shows up, e.g. in a fresh cluster")
+	perNodeReconcileTimeout = pflag.Duration("per-node-reconcile-timeout", 0, "maximum duration of reconciling a single node and of each route update, a timed out reconcile or update fails and is retried with backoff (0 for no limit)")
+	readyGraceBeforeDelete  = pflag.Duration("ready-grace-before-delete", 0, "time a node must be not ready before the route of the existing node is deleted if dropped by the stopped instance or instance lifecycle handling (0 deletes immediately). Routes of ready or deleted nodes and of nodes losing an instance conflict are always deleted immediately.")
+	instanceConflictPolicy  = pflag.String("instance-conflict-policy", updater.InstanceConflictPolicyPreferReady, fmt.Sprintf("selection of the node if several nodes resolve to the same instance. Must be one of [%s,%s].", updater.InstanceConflictPolicyPreferReady, updater.InstanceConflictPolicyNewest))
+	multiInstancePolicy     = pflag.String("multi-instance-policy", updater.MultiInstancePolicyError, fmt.Sprintf("handling of instance lookups returning several instances for the instance ID of a node. %s skips the route of the node and keeps its existing route, %s picks a running instance, then the latest launched one. Must be one of [%s,%s].", updater.MultiInstancePolicyError, updater.MultiInstancePolicyPreferRunning, updater.MultiInstancePolicyError, updater.MultiInstancePolicyPreferRunning))
+	selfTagRouteTables      = pflag.String("self-tag-route-tables", updater.SelfTagRouteTablesOff, fmt.Sprintf("writing of the tag %s=true on the cluster route tables. %s writes it on all cluster route tables, %s additionally restricts the controller to the route tables with the tag once any has it. Must be one of [%s,%s,%s].", updater.SelfTagKey, updater.SelfTagRouteTablesWrite, updater.SelfTagRouteTablesRestrict, updater.SelfTagRouteTablesOff, updater.SelfTagRouteTablesWrite, updater.SelfTagRouteTablesRestrict))
+	mode                    = pflag.String("mode", updater.ModeManage, fmt.Sprintf("%s creates and deletes the routes, %s only reports drift between desired and actual routes without modifying AWS resources. Must be one of [%s,%s].", updater.ModeManage, updater.ModeObserve, updater.ModeManage, updater.ModeObserve))
+	nodeConditionType       = pflag.String("node-condition-type", string(corev1.NodeNetworkUnavailable), "type of the node condition maintained after the route of a node has been programmed (empty to disable)")
+	maxDeletions            = pflag.Int("max-deletions-per-reconcile", 0, "maximum number of routes deleted in a single update, all deletions are skipped if exceeded (0 for unlimited)")
+	routeTableConcurrency   = pflag.Int("route-table-concurrency", 1, "maximum number of route tables updated concurrently")
+	awsDescribeConcurrency  = pflag.Int("aws-describe-concurrency", 1, "maximum number of AWS describe requests in flight during an update, e.g. for looking up instances in batches, independent of route-table-concurrency")
+	vpcPeeringConnectionID  = pflag.String("vpc-peering-connection-id", "", fmt.Sprintf("VPC peering connection to route the pod CIDRs of all nodes to instead of their instances (the node annotation %s takes precedence)", updater.VpcPeeringConnectionAnnotation))
+	fixedNextHopInstanceID  = pflag.String("fixed-next-hop-instance-id", "", "instance to route the pod CIDRs of all nodes to instead of their own instances, e.g. a central firewall appliance (excludes fixed-next-hop-eni-id and vpc-peering-connection-id)")
+	fixedNextHopENIID       = pflag.String("fixed-next-hop-eni-id", "", "network interface to route the pod CIDRs of all nodes to instead of their instances, e.g. of a central firewall appliance (excludes fixed-next-hop-instance-id and vpc-peering-connection-id)")
+	verifyAfterWrite        = pflag.Bool("verify-after-write", false, "read back the route table after creating a route to check that the route exists with the expected target")
+	routeScope              = pflag.String("route-scope", updater.RouteScopeTable, fmt.Sprintf("%s programs the route of a node into every cluster route table, %s only into a single route table per VPC. Must be one of [%s,%s].", updater.RouteScopeTable, updater.RouteScopeVPC, updater.RouteScopeTable, updater.RouteScopeVPC))
+	includeMainRouteTable   = pflag.Bool("include-main-route-table", true, "manage routes in the main route table of the VPC if it is tagged for the cluster, otherwise only explicitly associated route tables are used")
+	azScopedRouting         = pflag.Bool("az-scoped-routing", false, "only program the route of a node into the route tables associated with subnets in the zone of the node")
+	perAZRouteMetrics       = pflag.Bool("per-az-route-metrics", false, "export the number of managed routes per availability zone also without az-scoped-routing, which requires the permission ec2:DescribeSubnets")
+	manageSourceDestCheck   = pflag.Bool("manage-source-dest-check", false, "disable the source/destination check of the instances the routes point to")
+	cloudWatchNamespace     = pflag.String("cloudwatch-metrics-namespace", "", "CloudWatch namespace to publish the key controller metrics to (empty to disable)")
+	cloudWatchInterval      = pflag.Duration("cloudwatch-metrics-interval", time.Minute, "interval for publishing the metrics to CloudWatch")
+	startupCleanupDelay     = pflag.Duration("startup-cleanup-delay", 0, "time after startup or leader acquisition during which no routes are deleted")
+	startupRepairPass       = pflag.Bool("startup-repair-pass", false, "make the first update after startup or leader acquisition create-only to restore missing routes quickly, deletions follow with the next update")
+	waitForDaemonSet        = pflag.String("wait-for-daemonset", "", "DaemonSet in the form <namespace>/<name> whose desired pods must all be ready before routes are programmed, e.g. kube-system/calico-node")
+	orphanQuarantinePeriod  = pflag.Duration("orphan-quarantine-period", 0, "period a route must be orphaned before it is deleted (0 to delete immediately)")
+	foreignPodNetworkCidrs  = pflag.StringSlice("foreign-pod-network-cidrs", nil, "pod network CIDRs of other clusters sharing the VPC, routes overlapping them are never modified")
+	exportTerraform         = pflag.Bool("export-terraform", false, "print terraform import commands for all managed routes and exit")
+	checkPermissions        = pflag.Bool("check-permissions", false, "probe the EC2 permissions needed with the given flags using dry run requests, print a report and the minimal IAM policy and exit")
+	removeTaint             = pflag.String("remove-taint", "", "key of a taint to remove from a node after its route has been programmed")
+	workerPoolLabel         = pflag.String("worker-pool-label", "", "key of the node label identifying the worker pool, restricts the controller to the nodes of one pool (requires worker-pool-value)")
+	workerPoolValue         = pflag.String("worker-pool-value", "", "label value of the worker pool managed by this controller")
+	terminatingNodePolicy   = pflag.String("terminating-node-route-policy", controller.TerminatingNodeRoutePolicyKeep, fmt.Sprintf("handling of routes of nodes with a deletion timestamp, e.g. held by a finalizer. Must be one of [%s,%s].", controller.TerminatingNodeRoutePolicyKeep, controller.TerminatingNodeRoutePolicyRemove))
+	nodePatchRetries        = pflag.Int("node-patch-retries", 4, "maximum number of attempts for patching a node condition or taint if the API server fails transiently, retried with exponential backoff (1 to disable)")
+	nodePatchMaxDelay       = pflag.Duration("node-patch-max-delay", 5*time.Second, "maximum delay between two attempts of patching a node condition or taint")
+	nodeConflictRetries     = pflag.Int("node-conflict-retries", 5, "maximum number of attempts for patching a node condition or taint if it fails with a conflict")
+	enableDebugEndpoints    = pflag.Bool("enable-debug-endpoints", false, "serve debug endpoints like /debug/inventory, /debug/routetables and /debug/loglevel on the metrics port")
+	inventoryConfigMap      = pflag.String("inventory-configmap", "", "name of the config map to persist the node route inventory in (empty to disable)")
+	inventoryNamespace      = pflag.String("inventory-namespace", "kube-system", "namespace of the inventory config map")
+	inventoryCompression    = pflag.Int("inventory-compression-threshold", inventory.DefaultCompressionThreshold, "size of the inventory in bytes above which it is stored gzip compressed and base64 encoded in the config map")
+	syncReportConfigMap     = pflag.String("sync-report-configmap", "", "name of the config map to write a report to after each full sync (empty to disable)")
+	syncReportNamespace     = pflag.String("sync-report-namespace", "kube-system", "namespace of the sync report config map")
+	routeStateName          = pflag.String("route-state-name", "", "name of the RouteState custom resource to export the managed routes to in its status after each update (empty to disable)")
+	routeStateNamespace     = pflag.String("route-state-namespace", "kube-system", "namespace of the RouteState custom resource")
+	metricsLatencyType      = pflag.String("metrics-latency-type", metrics.LatencyTypeHistogram, fmt.Sprintf("type of the reconcile and AWS request latency metrics. Must be one of [%s,%s].", metrics.LatencyTypeHistogram, metrics.LatencyTypeSummary))
+	inventoryMetricSeries   = pflag.Int("inventory-max-metric-series", 1000, "maximum number of series of the managed route info metric (0 to disable)")
+	maxUncoveredRatio       = pflag.Float64("max-uncovered-node-cidrs-ratio", 0.5, "maximum ratio of existing node pod CIDRs outside of the pod network CIDR tolerated at startup (1 to only warn)")
+	cidrCustomResource      = pflag.String("cidr-cr", "", "custom resource and field to read the pod CIDRs of the nodes from instead of the node spec, in the form <group>/<version>/<resource>:<field path>, e.g. infrastructure.cluster.x-k8s.io/v1beta2/awsmachines:spec.podCIDRs. The object must be named like the node.")
+	respectAggregateRoutes  = pflag.Bool("respect-aggregate-routes", false, "skip the routes of nodes whose pod CIDR is covered by a summarized route inside of the pod network in the route table, e.g. 10.243.0.0/20 to a transit gateway, and keep such aggregate routes")
+	adoptExistingRoutes     = pflag.Bool("adopt-existing-routes", false, "adopt the existing routes with the correct target at startup, e.g. created manually before the controller was deployed, and log them instead of recreating them")
+	warnMissingDefaultRoute = pflag.Bool("warn-missing-default-route", false, "warn with log and metric about route tables the node routes are programmed into which lack an active 0.0.0.0/0 route, as pods may have no egress")
+	cidrCustomResourceNs    = pflag.String("cidr-cr-namespace", "", "namespace of the custom resources given by cidr-cr (empty for cluster-scoped resources)")
+	secondaryWatch          = pflag.String("secondary-watch", "", "additional resource whose changes trigger the reconcile of the affected nodes, in the form <group>/<version>/<resource>[:<field path>], where the field contains the node names (default the object name), e.g. discovery.k8s.io/v1/endpointslices:endpoints.nodeName")
+	secondaryWatchNamespace = pflag.String("secondary-watch-namespace", "", "namespace of the resources given by secondary-watch (empty for all namespaces)")
+	targets                 = pflag.StringSlice("targets", nil, "additional target clusters managed by this controller instance in the same AWS account and region, each in the form <kubeconfig>:<cluster-name>:<pod-network-cidr>")
 )
 
 func main() {
+	pflag.Usage = usage
+	pflag.Parse()
 
-	logf.SetLogger(logger.MustNewZapLogger(*logLevel, *logFormat))
+	zapLogger, runtimeLogLevel, err := logger.NewZapLoggerWithRuntimeLevel(*logLevel, *logFormat, *logLevelOverrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging configuration: %s\n", err)
+		exit(exitCodeInvalidFlags)
+	}
+	logf.SetLogger(zapLogger)
 
 	var log = logf.Log.WithName(componentName)
 	klog.SetLogger(log)
 	log.Info("version", "version", Version)
 
-	pflag.Parse()
-	checkRequiredFlag(log, "namespace", *namespace)
-	checkRequiredFlag(log, "secret-name", *secretName)
+	proxyURL, err := updater.ParseProxyURL(*awsProxyURL)
+	if err != nil {
+		fatal(log, exitCodeInvalidFlags, err, "invalid aws-proxy-url")
+	}
+	sessionTags, err := updater.ParseSessionTags(*assumeRoleSessionTags)
+	if err != nil {
+		fatal(log, exitCodeInvalidFlags, err, "invalid assume-role-session-tags")
+	}
+	awsClientOptions := updater.AWSClientOptions{UseFIPSEndpoints: *useFIPSEndpoints, ProxyURL: proxyURL}
+	switch *credentialsSource {
+	case updater.CredentialsSourceKubernetesSecret:
+		checkRequiredFlag(log, "namespace", *namespace)
+		checkRequiredFlag(log, "secret-name", *secretName)
+	case updater.CredentialsSourceSSM, updater.CredentialsSourceSecretsManager:
+		checkRequiredFlag(log, "credentials-resource", *credentialsResource)
+	default:
+		log.Info(fmt.Sprintf("invalid '--credentials-source' %q", *credentialsSource))
+		pflag.Usage()
+		exit(exitCodeInvalidFlags)
+	}
 	checkRequiredFlag(log, "region", *region)
 	checkRequiredFlag(log, "cluster-name", *clusterName)
-	checkRequiredFlag(log, "pod-network-cidr", *podNetworkCidr)
-	checkRequiredFlag(log, "target-kubeconfig", *targetKubeconfig)
-
-	targetConfig, err := clientcmd.BuildConfigFromFlags("", *targetKubeconfig)
+	if err := metrics.SetLatencyType(*metricsLatencyType); err != nil {
+		fatal(log, exitCodeInvalidFlags, err, "invalid metrics-latency-type")
+	}
+	if *workerPoolLabel != "" {
+		checkRequiredFlag(log, "worker-pool-value", *workerPoolValue)
+	}
+	additionalTargets, err := controller.ParseTargets(*targets)
 	if err != nil {
-		log.Error(err, "could not use target kubeconfig", "target-kubeconfig", *targetKubeconfig)
-		os.Exit(1)
+		fatal(log, exitCodeInvalidFlags, err, "invalid targets")
 	}
-	options := manager.Options{
-		LeaderElection:             *leaderElection,
-		LeaderElectionResourceLock: resourcelock.LeasesResourceLock,
-		LeaderElectionID:           leaderElectionId,
-		LeaderElectionNamespace:    *leaderElectionNamespace,
-		Metrics: server.Options{
-			BindAddress: fmt.Sprintf(":%d", *metricsPort),
-		},
-		HealthProbeBindAddress: fmt.Sprintf(":%d", *healthProbePort),
+	for _, target := range additionalTargets {
+		if target.ClusterName == *clusterName {
+			fatal(log, exitCodeInvalidFlags, fmt.Errorf("duplicate cluster name %q", target.ClusterName), "cluster names of targets must differ from cluster-name")
+		}
+	}
+
+	targetConfig := buildConfig(log, "target-kubeconfig", *targetKubeconfig)
+	var leaseTracker *controller.LeaseRenewalTracker
+	if *leaderElection {
+		lock, err := newLeaderElectionLock(targetConfig)
+		if err != nil {
+			fatal(log, exitCodeKubeconfig, err, "could not create leader election lock")
+		}
+		leaseTracker = controller.NewLeaseRenewalTracker(lock, *leaseRenewalThreshold)
+	}
+	routeInventory := inventory.New(*clusterName, *inventoryMetricSeries)
+	var debugHandlers map[string]http.Handler
+	if *enableDebugEndpoints {
+		debugHandlers = map[string]http.Handler{
+			"/debug/inventory": routeInventory,
+			"/debug/loglevel":  runtimeLogLevel,
+		}
+	}
+	options, err := newManagerOptions(leaseTracker, debugHandlers)
+	if err != nil {
+		fatal(log, exitCodeInvalidFlags, err, "invalid manager options")
 	}
 	mgr, err := manager.New(targetConfig, options)
 	if err != nil {
-		log.Error(err, "could not create manager")
-		os.Exit(1)
+		fatal(log, exitCodeKubeconfig, err, "could not create manager")
+	}
+	if leaseTracker != nil {
+		leaseTracker.SetElected(mgr.Elected())
+		if err := mgr.AddHealthzCheck("leader election lease", leaseTracker.HealthzChecker); err != nil {
+			fatal(log, exitCodeFailure, err, "could not add lease healthz checker")
+		}
+		leaseKey := client.ObjectKey{Namespace: *leaderElectionNamespace, Name: leaderElectionID()}
+		if err := mgr.Add(controller.NewLeaseAnnotator(mgr.GetClient(), log.WithName("lease"), leaseKey, Version, enabledFeatures(pflag.CommandLine))); err != nil {
+			fatal(log, exitCodeFailure, err, "could not add lease annotator")
+		}
 	}
 
 	reconciler := controller.NewNodeReconciler(mgr.GetClient(), log, mgr.Elected(), mgr.GetEventRecorderFor(componentName))
-	err = builder.
+	if *enableDebugEndpoints {
+		if err := mgr.AddMetricsServerExtraHandler("/debug/routetables", http.HandlerFunc(reconciler.ServeRouteTables)); err != nil {
+			fatal(log, exitCodeFailure, err, "could not add route tables debug endpoint")
+		}
+	}
+	if *fallbackToNodeIP {
+		if *nodeNetworkCidr == "" {
+			fatal(log, exitCodeInvalidFlags, fmt.Errorf("missing node-network-cidr"), "node-network-cidr is required with fallback-to-node-ip")
+		}
+		reconciler.SetFallbackToNodeIP(true)
+	}
+	reconciler.SetReconcileTimeout(*perNodeReconcileTimeout)
+	if err := reconciler.SetTerminatingNodeRoutePolicy(*terminatingNodePolicy); err != nil {
+		fatal(log, exitCodeInvalidFlags, err, "invalid terminating-node-route-policy")
+	}
+	if *workerPoolLabel != "" {
+		reconciler.SetWorkerPool(controller.WorkerPool{Label: *workerPoolLabel, Value: *workerPoolValue})
+		log.Info("restricted to worker pool", "label", *workerPoolLabel, "value", *workerPoolValue)
+	}
+	if *controlEventsObject != "" {
+		ref, err := controller.ParseObjectReference(*controlEventsObject, *namespace)
+		if err != nil {
+			fatal(log, exitCodeInvalidFlags, err, "invalid control events object")
+		}
+		controlConfig := buildConfig(log, "control-kubeconfig", *controlKubeconfig)
+		controlClientset, err := kubernetes.NewForConfig(controlConfig)
+		if err != nil {
+			fatal(log, exitCodeKubeconfig, err, "could not create control plane client")
+		}
+		reconciler.SetControlEventRecorder(controller.NewControlEventRecorder(controlClientset, componentName), ref)
+	}
+	nodeController := builder.
 		ControllerManagedBy(mgr).
-		For(&corev1.Node{}).
-		Complete(reconciler)
+		For(&corev1.Node{}, builder.WithPredicates(nodeRoutePredicate()))
+	if *cidrCustomResource != "" {
+		watched, err := setupPodCIDRProvider(mgr, targetConfig, reconciler)
+		if err != nil {
+			fatal(log, exitCodeFailure, err, "could not set up pod CIDR custom resource", "cidr-cr", *cidrCustomResource)
+		}
+		mapper := controller.NodeRequestMapper{Namespace: *cidrCustomResourceNs}
+		nodeController = nodeController.Watches(watched, handler.EnqueueRequestsFromMapFunc(mapper.Map))
+		log.Info("reading pod CIDRs from custom resource", "cidr-cr", *cidrCustomResource, "namespace", *cidrCustomResourceNs)
+	}
+	if *secondaryWatch != "" {
+		gvr, nodeNameField, err := controller.ParseSecondaryResource(*secondaryWatch)
+		if err != nil {
+			fatal(log, exitCodeInvalidFlags, err, "invalid secondary-watch")
+		}
+		watched, err := watchedObject(mgr, gvr)
+		if err != nil {
+			fatal(log, exitCodeFailure, err, "could not set up secondary watch", "secondary-watch", *secondaryWatch)
+		}
+		mapper := controller.NodeRequestMapper{Namespace: *secondaryWatchNamespace, NodeNameField: nodeNameField}
+		nodeController = nodeController.Watches(watched, handler.EnqueueRequestsFromMapFunc(mapper.Map))
+		log.Info("reconciling nodes on changes of secondary resource", "secondary-watch", *secondaryWatch, "namespace", *secondaryWatchNamespace)
+	}
+	err = nodeController.Complete(reconciler)
 	if err != nil {
-		log.Error(err, "could not create controller")
-		os.Exit(1)
+		fatal(log, exitCodeFailure, err, "could not create controller")
 	}
 	err = mgr.AddReadyzCheck("node reconciler", reconciler.ReadyChecker)
 	if err != nil {
-		log.Error(err, "could not add ready checker")
-		os.Exit(1)
+		fatal(log, exitCodeFailure, err, "could not add ready checker")
 	}
 	err = mgr.AddHealthzCheck("node reconciler", reconciler.HealthzChecker)
 	if err != nil {
-		log.Error(err, "could not add healthz checker")
-		os.Exit(1)
+		fatal(log, exitCodeFailure, err, "could not add healthz checker")
 	}
-
-	credentials, err := updater.LoadCredentials(*controlKubeconfig, *namespace, *secretName)
+	err = mgr.AddHealthzCheck("updater liveness", reconciler.LivenessChecker)
 	if err != nil {
-		log.Error(err, "could not load AWS credentials", "namespace", *namespace, "secretName", *secretName)
-		os.Exit(1)
+		fatal(log, exitCodeFailure, err, "could not add liveness checker")
 	}
-	ec2Routes, err := updater.NewAWSEC2Routes(credentials, *region)
+
+	credentials, err := loadCredentials(awsClientOptions)
 	if err != nil {
-		log.Error(err, "could not create AWS EC2 interface")
-		os.Exit(1)
+		fatal(log, awsExitCode(err, exitCodeAWSAuth), err, "could not load AWS credentials", "credentials-source", *credentialsSource)
+	}
+	if *assumeRoleARN != "" {
+		stsClient, err := updater.NewAWSSTS(credentials, *region, awsClientOptions)
+		if err != nil {
+			fatal(log, exitCodeFailure, err, "could not create AWS STS interface")
+		}
+		sessionName := *assumeRoleSessionName
+		if sessionName == "" {
+			sessionName = updater.DefaultRoleSessionName(componentName, *clusterName)
+		}
+		credentials = updater.AssumeRole(stsClient, *assumeRoleARN, sessionName, sessionTags)
+		log.Info("assuming role", "roleARN", *assumeRoleARN, "sessionName", sessionName, "sessionTags", *assumeRoleSessionTags)
 	}
-	podCIDR, err := util.GetIPv4CIDR(strings.Split(*podNetworkCidr, ","))
+	ec2Routes, err := updater.NewAWSEC2Routes(credentials, *region, awsClientOptions)
 	if err != nil {
-		log.Error(err, "could not parse IPv4 address from pod-network-cidr")
-		os.Exit(1)
+		fatal(log, exitCodeFailure, err, "could not create AWS EC2 interface")
+	}
+	if *cloudWatchNamespace != "" {
+		cloudWatch, err := updater.NewAWSCloudWatch(credentials, *region, awsClientOptions)
+		if err != nil {
+			fatal(log, exitCodeFailure, err, "could not create AWS CloudWatch interface")
+		}
+		publisher := metrics.NewCloudWatchPublisher(log.WithName("cloudwatch"), cloudWatch, *cloudWatchNamespace, *clusterName, *cloudWatchInterval)
+		if err := mgr.Add(publisher); err != nil {
+			fatal(log, exitCodeFailure, err, "could not add CloudWatch metrics publisher")
+		}
+	}
+	var podCIDR string
+	if *podNetworkCidr != "" {
+		podCIDR, err = util.GetIPv4CIDR(strings.Split(*podNetworkCidr, ","))
+		if err != nil {
+			fatal(log, exitCodeInvalidFlags, err, "could not parse IPv4 address from pod-network-cidr")
+		}
+	} else {
+		podCIDR, err = controller.DetectPodNetworkCIDR(context.Background(), mgr.GetAPIReader())
+		if err != nil {
+			fatal(log, exitCodeFailure, err, "could not detect pod network CIDR from nodes, please set pod-network-cidr")
+		}
+		log.Info("detected pod network CIDR from node pod CIDRs, set pod-network-cidr if nodes may be outside of it", "pod-network-cidr", podCIDR)
 	}
 
-	customRoutes, err := updater.NewCustomRoutes(log.WithName("updater"), ec2Routes, *clusterName, podCIDR)
+	customRoutes, err := updater.NewCustomRoutes(log.WithName("updater"), ec2Routes, *clusterName, podCIDR, customRoutesOptions())
 	if err != nil {
-		log.Error(err, "could not create AWS custom routes updater")
-		os.Exit(1)
+		fatal(log, exitCodeInvalidFlags, err, "could not create AWS custom routes updater")
+	}
+
+	if *exportTerraform {
+		routes, err := customRoutes.ListManagedRoutes()
+		if err != nil {
+			fatal(log, awsExitCode(err, exitCodeAWSConnectivity), err, "could not list managed routes")
+		}
+		for _, route := range routes {
+			fmt.Println(route.TerraformImportCommand())
+		}
+		exit(0)
+	}
+
+	if *checkPermissions {
+		checks := customRoutes.CheckPermissions()
+		policy, err := updater.RequiredIAMPolicy(checks)
+		if err != nil {
+			fatal(log, exitCodeFailure, err, "could not create IAM policy")
+		}
+		fmt.Print(updater.PermissionReport(checks))
+		fmt.Printf("\nMinimal IAM policy:\n%s\n", policy)
+		if !updater.PermissionsGranted(checks) {
+			exit(exitCodeAWSAuth)
+		}
+		exit(0)
+	}
+
+	var overlapErr *updater.VPCOverlapError
+	if err := customRoutes.CheckVPCOverlap(); errors.As(err, &overlapErr) {
+		fatal(log, exitCodeInvalidFlags, err, "refusing to start, pod-network-cidr must not overlap with the VPC", "pod-network-cidr", podCIDR)
+	} else if err != nil {
+		// this is the first request to EC2, failing if AWS cannot be accessed
+		fatal(log, awsExitCode(err, exitCodeAWSConnectivity), err, "could not check pod network CIDR for overlap with VPC")
 	}
 
 	ctx := signals.SetupSignalHandler()
-	reconciler.StartUpdater(ctx, customRoutes.Update, *tickPeriod, *syncPeriod, *maxDelay)
+	coverage, err := controller.CheckPodCIDRCoverage(ctx, mgr.GetAPIReader(), podCIDR)
+	if err != nil {
+		fatal(log, exitCodeFailure, err, "could not check pod CIDRs of nodes")
+	}
+	if len(coverage.Uncovered) > 0 {
+		log.Info("WARNING: nodes with pod CIDR outside of pod-network-cidr found", "pod-network-cidr", podCIDR, "nodes", coverage.Nodes, "uncovered", coverage.Uncovered)
+		if coverage.Exceeds(*maxUncoveredRatio) {
+			fatal(log, exitCodeInvalidFlags, fmt.Errorf("too many node pod CIDRs outside of pod network"), "refusing to start, check pod-network-cidr",
+				"pod-network-cidr", podCIDR, "max-uncovered-node-cidrs-ratio", *maxUncoveredRatio)
+		}
+	}
+
+	stores, err := newTargetStores(targetConfig, mgr.GetScheme())
+	if err != nil {
+		fatal(log, exitCodeKubeconfig, err, "could not create config map client")
+	}
+
+	var startupGate controller.StartupGate
+	if *waitForDaemonSet != "" {
+		key, err := controller.ParseNamespacedName(*waitForDaemonSet)
+		if err != nil {
+			fatal(log, exitCodeInvalidFlags, err, "invalid wait-for-daemonset")
+		}
+		startupGate = controller.NewDaemonSetGate(mgr.GetAPIReader(), log.WithName("startup-gate"), key)
+	}
+	if err := markSensitiveFlags(pflag.CommandLine); err != nil {
+		fatal(log, exitCodeFailure, err, "could not mark sensitive flags")
+	}
+	log.Info("effective configuration", effectiveConfig(pflag.CommandLine, podCIDR)...)
+
+	leadership := controller.NewLeadershipLostNotifier()
+	if err := mgr.Add(leadership); err != nil {
+		fatal(log, exitCodeFailure, err, "could not add leadership notifier")
+	}
+
+	config := updaterConfig()
+	config.ClusterName = *clusterName
+	config.Inventory = routeInventory
+	config.DriftDetector = customRoutes.DetectDrift
+	config.StartupGate = startupGate
+	stores.apply(&config)
+	config.LeadershipLost = leadership.Lost()
+	reconciler.StartUpdater(ctx, customRoutes.Update, config)
+	go forceSyncOnSIGHUP(ctx, log, reconciler)
+	for _, target := range additionalTargets {
+		targetLog := log.WithValues("cluster", target.ClusterName)
+		targetMgr, err := setupTarget(ctx, targetLog, mgr, target, ec2Routes)
+		if err != nil {
+			fatal(targetLog, exitCodeFailure, err, "could not set up target", "kubeconfig", target.Kubeconfig)
+		}
+		go func() {
+			if err := targetMgr.Start(ctx); err != nil {
+				fatal(targetLog, exitCodeFailure, err, "could not start manager of target")
+			}
+		}()
+		targetLog.Info("managing routes of additional target", "pod-network-cidr", target.PodNetworkCIDR)
+	}
 	if err := mgr.Start(ctx); err != nil {
-		log.Error(err, "could not start manager")
-		os.Exit(1)
+		fatal(log, exitCodeFailure, err, "could not start manager")
+	}
+}
+
+// healthChecks registers health checks, implemented by the manager of the primary target
+type healthChecks interface {
+	AddHealthzCheck(name string, check healthz.Checker) error
+}
+
+// targetStores are the stores persisting the inventory, the sync report and the route state in the cluster of a target
+type targetStores struct {
+	inventory  *inventory.ConfigMapStore
+	syncReport *controller.SyncReportStore
+	routeState *controller.RouteStateStore
+}
+
+// newTargetStores creates the stores enabled by the flags for the cluster of the given config
+func newTargetStores(config *rest.Config, scheme *runtime.Scheme) (targetStores, error) {
+	stores := targetStores{}
+	if *inventoryConfigMap == "" && *syncReportConfigMap == "" && *routeStateName == "" {
+		return stores, nil
+	}
+	// a direct client to avoid caching all config maps and route states
+	configMapClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return stores, err
+	}
+	if *inventoryConfigMap != "" {
+		stores.inventory = inventory.NewConfigMapStore(configMapClient, *inventoryNamespace, *inventoryConfigMap)
+		stores.inventory.SetCompressionThreshold(*inventoryCompression)
+	}
+	if *syncReportConfigMap != "" {
+		stores.syncReport = controller.NewSyncReportStore(configMapClient, *syncReportNamespace, *syncReportConfigMap)
+	}
+	if *routeStateName != "" {
+		stores.routeState = controller.NewRouteStateStore(configMapClient, *routeStateNamespace, *routeStateName)
+	}
+	return stores, nil
+}
+
+// apply sets the stores in the updater config
+func (s targetStores) apply(config *controller.UpdaterConfig) {
+	config.InventoryStore = s.inventory
+	config.SyncReportStore = s.syncReport
+	config.RouteStateStore = s.routeState
+}
+
+// setupTarget sets up the manager with the node reconciler and the route updater of an additional target.
+// Its health checks are added to the checks of the primary target, which serves the health probes.
+// The target has its own inventory and stores in its cluster, while features bound to the control plane
+// or the primary target (e.g. the debug endpoints, the startup gate or the pod CIDR custom resource) are not available.
+func setupTarget(ctx context.Context, log logr.Logger, checks healthChecks, target controller.Target, ec2Routes updater.EC2Routes) (manager.Manager, error) {
+	targetConfig, err := updater.BuildConfig(target.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not use kubeconfig: %w", err)
+	}
+	options, err := newManagerOptions(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	// metrics and health probes are served by the manager of the primary target
+	options.Metrics.BindAddress = "0"
+	options.HealthProbeBindAddress = "0"
+	mgr, err := manager.New(targetConfig, options)
+	if err != nil {
+		return nil, fmt.Errorf("could not create manager: %w", err)
+	}
+	if *leaderElection {
+		leaseKey := client.ObjectKey{Namespace: *leaderElectionNamespace, Name: leaderElectionID()}
+		if err := mgr.Add(controller.NewLeaseAnnotator(mgr.GetClient(), log.WithName("lease"), leaseKey, Version, enabledFeatures(pflag.CommandLine))); err != nil {
+			return nil, fmt.Errorf("could not add lease annotator: %w", err)
+		}
+	}
+	reconciler := controller.NewNodeReconciler(mgr.GetClient(), log, mgr.Elected(), mgr.GetEventRecorderFor(componentName))
+	reconciler.SetFallbackToNodeIP(*fallbackToNodeIP)
+	reconciler.SetReconcileTimeout(*perNodeReconcileTimeout)
+	if err := reconciler.SetTerminatingNodeRoutePolicy(*terminatingNodePolicy); err != nil {
+		return nil, err
+	}
+	if *workerPoolLabel != "" {
+		reconciler.SetWorkerPool(controller.WorkerPool{Label: *workerPoolLabel, Value: *workerPoolValue})
+	}
+	err = builder.
+		ControllerManagedBy(mgr).
+		Named("node-"+target.ClusterName).
+		For(&corev1.Node{}, builder.WithPredicates(nodeRoutePredicate())).
+		Complete(reconciler)
+	if err != nil {
+		return nil, fmt.Errorf("could not create controller: %w", err)
+	}
+	if err := checks.AddHealthzCheck("node reconciler "+target.ClusterName, reconciler.HealthzChecker); err != nil {
+		return nil, fmt.Errorf("could not add healthz checker: %w", err)
+	}
+	if err := checks.AddHealthzCheck("updater liveness "+target.ClusterName, reconciler.LivenessChecker); err != nil {
+		return nil, fmt.Errorf("could not add liveness checker: %w", err)
+	}
+	customRoutes, err := updater.NewCustomRoutes(log.WithName("updater"), ec2Routes, target.ClusterName, target.PodNetworkCIDR, customRoutesOptions())
+	if err != nil {
+		return nil, fmt.Errorf("could not create AWS custom routes updater: %w", err)
+	}
+	stores, err := newTargetStores(targetConfig, mgr.GetScheme())
+	if err != nil {
+		return nil, fmt.Errorf("could not create config map client: %w", err)
+	}
+	leadership := controller.NewLeadershipLostNotifier()
+	if err := mgr.Add(leadership); err != nil {
+		return nil, fmt.Errorf("could not add leadership notifier: %w", err)
+	}
+	config := updaterConfig()
+	config.ClusterName = target.ClusterName
+	config.Inventory = inventory.New(target.ClusterName, *inventoryMetricSeries)
+	stores.apply(&config)
+	config.DriftDetector = customRoutes.DetectDrift
+	config.LeadershipLost = leadership.Lost()
+	reconciler.StartUpdater(ctx, customRoutes.Update, config)
+	return mgr, nil
+}
+
+// nodeRoutePredicate filters the node events relevant for the routes
+func nodeRoutePredicate() controller.NodeRouteChangedPredicate {
+	return controller.NodeRouteChangedPredicate{
+		RelevantAnnotations: []string{updater.VpcPeeringConnectionAnnotation, updater.NextHopIPAnnotation},
+	}
+}
+
+// customRoutesOptions returns the options of the route updater from the flags, shared by all targets
+func customRoutesOptions() updater.CustomRoutesOptions {
+	return updater.CustomRoutesOptions{
+		Mode:                     *mode,
+		StoppedInstancePolicy:    *stoppedInstancePolicy,
+		InstanceLifecyclePolicy:  *instanceLifecyclePolicy,
+		InstanceConflictPolicy:   *instanceConflictPolicy,
+		MultiInstancePolicy:      *multiInstancePolicy,
+		SelfTagRouteTables:       *selfTagRouteTables,
+		ReadyGraceBeforeDelete:   *readyGraceBeforeDelete,
+		OrphanQuarantinePeriod:   *orphanQuarantinePeriod,
+		MaxDeletionsPerUpdate:    *maxDeletions,
+		ForeignPodNetworkCIDRs:   *foreignPodNetworkCidrs,
+		RouteTableConcurrency:    *routeTableConcurrency,
+		DescribeConcurrency:      *awsDescribeConcurrency,
+		VerifyAfterWrite:         *verifyAfterWrite,
+		AZScopedRouting:          *azScopedRouting,
+		PerAZRouteMetrics:        *perAZRouteMetrics,
+		ManageSourceDestCheck:    *manageSourceDestCheck,
+		NodeNetworkCIDR:          nodeIPNetwork(),
+		TargetResolver:           targetResolver(),
+		FixedNextHopInstanceID:   *fixedNextHopInstanceID,
+		FixedNextHopENIID:        *fixedNextHopENIID,
+		ExcludeVPCMainRouteTable: !*includeMainRouteTable,
+		RouteScope:               *routeScope,
+		WarnMissingDefaultRoute:  *warnMissingDefaultRoute,
+		AdoptExistingRoutes:      *adoptExistingRoutes,
+		RespectAggregateRoutes:   *respectAggregateRoutes,
+	}
+}
+
+// updaterConfig returns the configuration of the updater from the flags, shared by all targets
+func updaterConfig() controller.UpdaterConfig {
+	return controller.UpdaterConfig{
+		TickPeriod:             *tickPeriod,
+		SyncPeriod:             *syncPeriod,
+		MaxDelayOnFailure:      *maxDelay,
+		SyncBatchSize:          *syncBatchSize,
+		SyncBatchPause:         syncBatchPause,
+		RecheckPeriod:          recheckPeriod,
+		NodeConditionType:      corev1.NodeConditionType(*nodeConditionType),
+		RemoveTaint:            *removeTaint,
+		NodeConflictRetries:    *nodeConflictRetries,
+		NodePatchRetries:       *nodePatchRetries,
+		NodePatchMaxDelay:      *nodePatchMaxDelay,
+		StartupCleanupDelay:    *startupCleanupDelay,
+		StartupRepairPass:      *startupRepairPass,
+		LivenessThreshold:      *livenessThreshold,
+		DriftDetectionInterval: *driftDetectionInterval,
+		ReconcileDebounce:      *reconcileDebounce,
+		ReconcileCache:         *reconcileCache,
+		CleanupWithoutNodes:    *cleanupWithoutNodes,
+		ObserveOnly:            *mode == updater.ModeObserve,
+	}
+}
+
+// featureFlags maps the flags of the optional features to the feature names annotated on the leader election lease
+var featureFlags = map[string]string{
+	"adopt-existing-routes":        "adopt-existing-routes",
+	"assume-role-arn":              "assume-role",
+	"az-scoped-routing":            "az-scoped-routing",
+	"cidr-cr":                      "cidr-cr",
+	"cleanup-without-nodes":        "cleanup-without-nodes",
+	"cloudwatch-metrics-namespace": "cloudwatch-metrics",
+	"drift-detection-interval":     "drift-detection",
+	"fallback-to-node-ip":          "fallback-to-node-ip",
+	"fixed-next-hop-eni-id":        "fixed-next-hop",
+	"fixed-next-hop-instance-id":   "fixed-next-hop",
+	"manage-source-dest-check":     "manage-source-dest-check",
+	"mode":                         "observe-mode",
+	"per-az-route-metrics":         "per-az-route-metrics",
+	"reconcile-cache":              "reconcile-cache",
+	"reconcile-debounce":           "reconcile-debounce",
+	"respect-aggregate-routes":     "respect-aggregate-routes",
+	"route-scope":                  "vpc-route-scope",
+	"route-state-name":             "route-state",
+	"secondary-watch":              "secondary-watch",
+	"self-tag-route-tables":        "self-tag-route-tables",
+	"startup-repair-pass":          "startup-repair-pass",
+	"targets":                      "multiple-targets",
+	"use-fips-endpoints":           "fips-endpoints",
+	"verify-after-write":           "verify-after-write",
+	"vpc-peering-connection-id":    "vpc-peering",
+	"warn-missing-default-route":   "warn-missing-default-route",
+}
+
+// enabledFeatures returns the names of the optional features whose flags differ from their defaults
+func enabledFeatures(fs *pflag.FlagSet) []string {
+	features := map[string]bool{}
+	for name, feature := range featureFlags {
+		if flag := fs.Lookup(name); flag != nil && flag.Value.String() != flag.DefValue {
+			features[feature] = true
+		}
+	}
+	var enabled []string
+	for feature := range features {
+		enabled = append(enabled, feature)
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+// targetResolver returns the resolver for the route targets, or nil for the default of the updater
+// (the instances of the nodes or the fixed next hop)
+func targetResolver() updater.TargetResolver {
+	if *vpcPeeringConnectionID != "" {
+		return updater.VpcPeeringConnectionTargetResolver{VpcPeeringConnectionID: *vpcPeeringConnectionID}
+	}
+	return nil
+}
+
+// loadCredentials loads the AWS credentials from the configured source
+func loadCredentials(awsClientOptions updater.AWSClientOptions) (*updater.Credentials, error) {
+	fields := updater.CredentialFields{
+		AccessKeyID:     *accessKeyIDField,
+		SecretAccessKey: *secretAccessKeyField,
+		SessionToken:    *sessionTokenField,
+	}
+	switch *credentialsSource {
+	case updater.CredentialsSourceSSM, updater.CredentialsSourceSecretsManager:
+		sess, err := updater.NewBootstrapSession(*region, awsClientOptions)
+		if err != nil {
+			return nil, err
+		}
+		if *credentialsSource == updater.CredentialsSourceSSM {
+			return updater.LoadCredentialsFromSSM(ssm.New(sess), *credentialsResource, fields)
+		}
+		return updater.LoadCredentialsFromSecretsManager(secretsmanager.New(sess), *credentialsResource, fields)
+	default:
+		return updater.LoadCredentials(*controlKubeconfig, *namespace, *secretName, fields)
+	}
+}
+
+// newManagerOptions creates the manager options from the flags
+func newManagerOptions(leaseTracker *controller.LeaseRenewalTracker, debugHandlers map[string]http.Handler) (manager.Options, error) {
+	metricsAddress, err := bindAddress(*metricsBindAddress, *metricsPort)
+	if err != nil {
+		return manager.Options{}, fmt.Errorf("invalid metrics-bind-address: %w", err)
+	}
+	healthProbeAddress, err := bindAddress(*healthProbeBindAddress, *healthProbePort)
+	if err != nil {
+		return manager.Options{}, fmt.Errorf("invalid health-probe-bind-address: %w", err)
+	}
+	options := manager.Options{
+		LeaderElection:             *leaderElection,
+		LeaderElectionResourceLock: resourcelock.LeasesResourceLock,
+		LeaderElectionID:           leaderElectionID(),
+		LeaderElectionNamespace:    *leaderElectionNamespace,
+		Metrics: server.Options{
+			BindAddress:   metricsAddress,
+			ExtraHandlers: debugHandlers,
+		},
+		HealthProbeBindAddress: healthProbeAddress,
+	}
+	if leaseTracker != nil {
+		options.LeaderElectionResourceLockInterface = leaseTracker
+	}
+	if *informerResyncPeriod > 0 {
+		options.Cache.SyncPeriod = informerResyncPeriod
+	}
+	return options, nil
+}
+
+// bindAddress returns the address if set, otherwise the port on all interfaces
+func bindAddress(address string, port int) (string, error) {
+	if address == "" {
+		return fmt.Sprintf(":%d", port), nil
+	}
+	_, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if p, err := strconv.Atoi(portString); err != nil || p < 0 || p > 65535 {
+		return "", fmt.Errorf("invalid port %q", portString)
+	}
+	return address, nil
+}
+
+// nodeIPNetwork returns the node network for managing the node IP routes if the fallback is enabled
+func nodeIPNetwork() string {
+	if !*fallbackToNodeIP {
+		return ""
+	}
+	return *nodeNetworkCidr
+}
+
+// forceSyncOnSIGHUP forces a full sync bypassing the route table checksums on SIGHUP
+func forceSyncOnSIGHUP(ctx context.Context, log logr.Logger, reconciler *controller.NodeReconciler) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Info("SIGHUP received, forcing sync")
+			reconciler.ForceSync()
+		}
+	}
+}
+
+// setupPodCIDRProvider configures the reconciler to read the pod CIDRs from the custom resource given by cidr-cr
+// and returns the object to watch for changes of the custom resource
+func setupPodCIDRProvider(mgr manager.Manager, config *rest.Config, reconciler *controller.NodeReconciler) (client.Object, error) {
+	gvr, fieldPath, err := controller.ParseCustomResourceField(*cidrCustomResource)
+	if err != nil {
+		return nil, err
+	}
+	watched, err := watchedObject(mgr, gvr)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	reconciler.SetPodCIDRProvider(controller.NewCustomResourcePodCIDRProvider(dynamicClient, gvr, *cidrCustomResourceNs, fieldPath))
+	return watched, nil
+}
+
+// watchedObject returns the unstructured object to watch the resource with
+func watchedObject(mgr manager.Manager, gvr schema.GroupVersionResource) (client.Object, error) {
+	gvk, err := mgr.GetRESTMapper().KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("could not find kind of %s: %w", gvr, err)
+	}
+	watched := &unstructured.Unstructured{}
+	watched.SetGroupVersionKind(gvk)
+	return watched, nil
+}
+
+// leaderElectionID returns the name of the lease resource, which is separate per worker pool.
+// If the worker pool value is not valid in a lease name, e.g. because of upper case letters or underscores, it is hashed.
+func leaderElectionID() string {
+	if *workerPoolLabel == "" {
+		return leaderElectionId
+	}
+	id := leaderElectionId + "-" + *workerPoolValue
+	if len(validation.IsDNS1123Subdomain(id)) == 0 {
+		return id
+	}
+	hash := sha256.Sum256([]byte(*workerPoolValue))
+	return leaderElectionId + "-" + hex.EncodeToString(hash[:])[:16]
+}
+
+// newLeaderElectionLock creates the lease lock like the manager does by default
+func newLeaderElectionLock(config *rest.Config) (resourcelock.Interface, error) {
+	id, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return resourcelock.NewFromKubeconfig(resourcelock.LeasesResourceLock, *leaderElectionNamespace, leaderElectionID(),
+		resourcelock.ResourceLockConfig{Identity: id + "_" + string(uuid.NewUUID())}, config, leaderElectionRenewDeadline)
+}
+
+// buildConfig builds the REST config from the kubeconfig given by the flag, exiting with exitCodeKubeconfig if it cannot be used
+func buildConfig(log logr.Logger, flag, kubeconfig string) *rest.Config {
+	config, err := updater.BuildConfig(kubeconfig)
+	if err != nil {
+		fatal(log, exitCodeKubeconfig, err, "could not use "+flag, flag, kubeconfig)
+	}
+	return config
+}
+
+// sensitiveFlags are the flags identifying the AWS credentials or possibly containing them, e.g. in the proxy URL
+var sensitiveFlags = []string{
+	"secret-name",
+	"secret-access-key-field",
+	"secret-secret-key-field",
+	"secret-session-token-field",
+	"credentials-resource",
+	"assume-role-arn",
+	"aws-proxy-url",
+}
+
+// effectiveConfig returns the flag values as key/value pairs sorted by key, with the resolved pod network CIDR
+// and the name of the leader election lease
+func effectiveConfig(fs *pflag.FlagSet, podCIDR string) []interface{} {
+	config := util.EffectiveConfig(fs)
+	config["pod-network-cidr"] = podCIDR
+	if *leaderElection {
+		config["leader-election-id"] = leaderElectionID()
+	}
+	return util.SortedKeysAndValues(config)
+}
+
+// markSensitiveFlags marks the sensitive flags, so that their values are redacted from the effective configuration
+func markSensitiveFlags(fs *pflag.FlagSet) error {
+	for _, name := range sensitiveFlags {
+		if err := util.MarkFlagSensitive(fs, name); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func checkRequiredFlag(log logr.Logger, name, value string) {
 	if value == "" {
 		log.Info(fmt.Sprintf("'--%s' is required", name))
 		pflag.Usage()
-		os.Exit(1)
+		exit(exitCodeInvalidFlags)
 	}
 }
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+)
+
+// appliedRoutesCache contains the hashes of the node routes of the last successful update by node name
+type appliedRoutesCache struct {
+	// hashes is nil as long as there is no successful update or after a failed one
+	hashes map[string]string
+}
+
+// nodeRouteHash returns the hash over all fields of the node route
+func nodeRouteHash(route updater.NodeRoute) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", route)))
+	return hex.EncodeToString(sum[:])
+}
+
+// matches returns true if the node routes are the same as applied by the last successful update
+func (c *appliedRoutesCache) matches(routes []updater.NodeRoute) bool {
+	if c.hashes == nil || len(c.hashes) != len(routes) {
+		return false
+	}
+	for _, route := range routes {
+		if hash, ok := c.hashes[route.NodeName]; !ok || hash != nodeRouteHash(route) {
+			return false
+		}
+	}
+	return true
+}
+
+// store records the node routes of a successful update
+func (c *appliedRoutesCache) store(routes []updater.NodeRoute) {
+	c.hashes = make(map[string]string, len(routes))
+	for _, route := range routes {
+		c.hashes[route.NodeName] = nodeRouteHash(route)
+	}
+}
+
+// invalidate drops the node routes after a failed update, so that the next update is not skipped
+func (c *appliedRoutesCache) invalidate() {
+	c.hashes = nil
+}
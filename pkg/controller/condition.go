@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// conditionReasonRouteCreated is the reason of the node condition set after the route has been programmed
+	conditionReasonRouteCreated = "RouteCreated"
+	// conditionMessageRouteCreated is the message of the node condition set after the route has been programmed
+	conditionMessageRouteCreated = "aws-custom-route-controller created a route"
+	// conditionReasonNoRouteCreated is the reason of the node condition reverted after the route has been dropped
+	conditionReasonNoRouteCreated = "NoRouteCreated"
+	// conditionMessageNoRouteCreated is the message of the node condition reverted after the route has been dropped
+	conditionMessageNoRouteCreated = "aws-custom-route-controller has no route for the node"
+)
+
+// routeProgrammedStatus returns the condition status signalling a programmed route.
+// For NetworkUnavailable this is false, for any other (custom) condition type it is true.
+func routeProgrammedStatus(conditionType corev1.NodeConditionType) corev1.ConditionStatus {
+	if conditionType == corev1.NodeNetworkUnavailable {
+		return corev1.ConditionFalse
+	}
+	return corev1.ConditionTrue
+}
+
+// routeDroppedStatus returns the condition status signalling a route which is not programmed
+func routeDroppedStatus(conditionType corev1.NodeConditionType) corev1.ConditionStatus {
+	if conditionType == corev1.NodeNetworkUnavailable {
+		return corev1.ConditionTrue
+	}
+	return corev1.ConditionFalse
+}
+
+// updateNodeConditions sets the node condition for all nodes with programmed routes if not already set,
+// and reverts it for the nodes whose routes have been dropped
+func (r *NodeReconciler) updateNodeConditions(ctx context.Context, conditionType corev1.NodeConditionType, patchRetry *nodePatchRetry, routes, dropped []updater.NodeRoute) error {
+	var conditionErrors error
+	for _, route := range routes {
+		if route.NodeName == "" {
+			continue
+		}
+		if err := retryNodePatch(ctx, patchRetry, func() error {
+			return r.setNodeCondition(ctx, conditionType, route.NodeName, true)
+		}); err != nil {
+			conditionErrors = multierr.Append(conditionErrors, fmt.Errorf("setting condition %s on node %s failed: %w", conditionType, route.NodeName, err))
+		}
+	}
+	for _, route := range dropped {
+		if err := retryNodePatch(ctx, patchRetry, func() error {
+			return r.setNodeCondition(ctx, conditionType, route.NodeName, false)
+		}); err != nil {
+			conditionErrors = multierr.Append(conditionErrors, fmt.Errorf("reverting condition %s on node %s failed: %w", conditionType, route.NodeName, err))
+		}
+	}
+	return conditionErrors
+}
+
+// setNodeCondition sets the node condition depending on whether the route of the node is programmed.
+// The condition of a node without route is only reverted if it has been set by the controller.
+func (r *NodeReconciler) setNodeCondition(ctx context.Context, conditionType corev1.NodeConditionType, nodeName string, programmed bool) error {
+	node := &corev1.Node{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	status, reason, message := routeProgrammedStatus(conditionType), conditionReasonRouteCreated, conditionMessageRouteCreated
+	if !programmed {
+		status, reason, message = routeDroppedStatus(conditionType), conditionReasonNoRouteCreated, conditionMessageNoRouteCreated
+	}
+	found := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != conditionType {
+			continue
+		}
+		if condition.Status == status || (!programmed && condition.Reason != conditionReasonRouteCreated) {
+			// up to date or set by others, e.g. by the CNI
+			return nil
+		}
+		found = true
+	}
+	if !found && !programmed {
+		return nil
+	}
+
+	patch := client.StrategicMergeFrom(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	now := metav1.Now()
+	condition := corev1.NodeCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+		LastHeartbeatTime:  now,
+	}
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			node.Status.Conditions[i] = condition
+			found = true
+		}
+	}
+	if !found {
+		node.Status.Conditions = append(node.Status.Conditions, condition)
+	}
+	if err := r.client.Status().Patch(ctx, node, patch); err != nil {
+		return err
+	}
+	r.log.Info("node condition set", "node", nodeName, "type", conditionType, "status", status)
+	return nil
+}
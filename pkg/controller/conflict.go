@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// nodePatchInitialDelay is the delay before the first retry of a node patch failed with a transient error
+	nodePatchInitialDelay = 200 * time.Millisecond
+	// defaultNodePatchMaxDelay is the maximum delay between the retries of a node patch if none is configured
+	defaultNodePatchMaxDelay = 5 * time.Second
+)
+
+// nodePatchRetry configures the retries of patching a node condition or taint
+type nodePatchRetry struct {
+	// conflictRetries is the maximum number of attempts on conflicts (0 uses the client-go default)
+	conflictRetries int
+	// attempts is the maximum number of attempts on transient API server errors
+	attempts int
+	// maxDelay is the maximum delay between two attempts on transient API server errors
+	maxDelay time.Duration
+}
+
+// nodePatchRetry returns the retries of the node patches of the config, independent of the AWS backoff
+func (cfg UpdaterConfig) nodePatchRetry() nodePatchRetry {
+	patchRetry := nodePatchRetry{
+		conflictRetries: cfg.NodeConflictRetries,
+		attempts:        cfg.NodePatchRetries,
+		maxDelay:        cfg.NodePatchMaxDelay,
+	}
+	if patchRetry.maxDelay <= 0 {
+		patchRetry.maxDelay = defaultNodePatchMaxDelay
+	}
+	return patchRetry
+}
+
+// retryOnConflict runs the given function, which must read the node before patching it, again with a short backoff
+// as long as it fails with a conflict. At most retries attempts are made (0 uses the client-go default).
+func retryOnConflict(retries int, fn func() error) error {
+	backoff := retry.DefaultRetry
+	if retries > 0 {
+		backoff.Steps = retries
+	}
+	return retry.RetryOnConflict(backoff, fn)
+}
+
+// retryNodePatch runs the given node patch function with the conflict retries, which are repeated
+// with exponential backoff as long as the API server fails transiently. Once the attempts are exhausted,
+// the transient retries are disabled for the following patches sharing patchRetry, so that an unavailable
+// API server delays an update by a single backoff only instead of one per node.
+func retryNodePatch(ctx context.Context, patchRetry *nodePatchRetry, fn func() error) error {
+	delay := nodePatchInitialDelay
+	for attempt := 1; ; attempt++ {
+		err := retryOnConflict(patchRetry.conflictRetries, fn)
+		if err == nil || !isTransientAPIError(err) {
+			return err
+		}
+		if attempt >= patchRetry.attempts {
+			patchRetry.attempts = 1
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait.Jitter(delay, 0.1)):
+		}
+		delay = min(2*delay, patchRetry.maxDelay)
+	}
+}
+
+// isTransientAPIError returns true for API server errors which are likely to disappear on retry
+func isTransientAPIError(err error) bool {
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsServiceUnavailable(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// controlEventObjectKinds are the supported kinds of the control cluster event object with their API versions
+var controlEventObjectKinds = map[string]string{
+	"ConfigMap":      "v1",
+	"Deployment":     "apps/v1",
+	"Pod":            "v1",
+	"Secret":         "v1",
+	"ServiceAccount": "v1",
+}
+
+// ParseObjectReference parses an object reference in the form `<kind>/<name>` for an object in the given namespace
+func ParseObjectReference(value, namespace string) (*corev1.ObjectReference, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid object reference %q, expected <kind>/<name>", value)
+	}
+	apiVersion, ok := controlEventObjectKinds[parts[0]]
+	if !ok {
+		var kinds []string
+		for kind := range controlEventObjectKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		return nil, fmt.Errorf("unsupported kind %q of object reference, must be one of [%s]", parts[0], strings.Join(kinds, ","))
+	}
+	return &corev1.ObjectReference{
+		Kind:       parts[0],
+		APIVersion: apiVersion,
+		Namespace:  namespace,
+		Name:       parts[1],
+	}, nil
+}
+
+// NewControlEventRecorder creates an event recorder writing the events with the given clientset
+func NewControlEventRecorder(clientset kubernetes.Interface, component string) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+}
+
+// SetControlEventRecorder additionally reports the events on the given object using the recorder
+func (r *NodeReconciler) SetControlEventRecorder(recorder record.EventRecorder, ref *corev1.ObjectReference) {
+	r.controlRecorder = recorder
+	r.controlRef = ref
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("control cluster events", func() {
+	It("should parse object references", func() {
+		ref, err := controller.ParseObjectReference("Deployment/aws-custom-route-controller", "shoot--foo--bar")
+		Expect(err).To(BeNil())
+		Expect(ref).To(Equal(&corev1.ObjectReference{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+			Namespace:  "shoot--foo--bar",
+			Name:       "aws-custom-route-controller",
+		}))
+
+		_, err = controller.ParseObjectReference("aws-custom-route-controller", "shoot--foo--bar")
+		Expect(err).To(HaveOccurred())
+		_, err = controller.ParseObjectReference("Node/foo", "shoot--foo--bar")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should report the route events also on the control cluster object", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		c := fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).Build()
+		targetRecorder := record.NewFakeRecorder(10)
+		controlRecorder := record.NewFakeRecorder(10)
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, targetRecorder)
+		ref, err := controller.ParseObjectReference("Deployment/aws-custom-route-controller", "shoot--foo--bar")
+		Expect(err).To(BeNil())
+		reconciler.SetControlEventRecorder(controlRecorder, ref)
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		fakeUpd := &fakeUpdater{}
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+
+		Eventually(controlRecorder.Events).Should(Receive(ContainSubstring("RoutesUpToDate")))
+		Eventually(targetRecorder.Events).Should(Receive(ContainSubstring("RoutesUpToDate")))
+	})
+
+	It("should write events with the control cluster clientset", func() {
+		clientset := kubefake.NewSimpleClientset()
+		recorder := controller.NewControlEventRecorder(clientset, "aws-custom-route-controller")
+		ref, err := controller.ParseObjectReference("Deployment/aws-custom-route-controller", "shoot--foo--bar")
+		Expect(err).To(BeNil())
+
+		recorder.Event(ref, corev1.EventTypeNormal, "RoutesUpToDate", "routes for all route tables are up-to-date")
+
+		Eventually(func() ([]corev1.Event, error) {
+			list, err := clientset.CoreV1().Events("shoot--foo--bar").List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		}).Should(ContainElement(HaveField("Reason", "RoutesUpToDate")))
+	})
+})
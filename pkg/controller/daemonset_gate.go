@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultStartupGatePeriod is the default period for probing a closed startup gate
+const defaultStartupGatePeriod = 10 * time.Second
+
+// StartupGate returns true once the updater may start programming routes
+type StartupGate func(ctx context.Context) (bool, error)
+
+// ParseNamespacedName parses a name in the form `<namespace>/<name>`
+func ParseNamespacedName(value string) (types.NamespacedName, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("invalid name %q, expected <namespace>/<name>", value)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// NewDaemonSetGate creates a startup gate which opens once all desired pods of the DaemonSet are ready,
+// e.g. to wait for the CNI before programming routes.
+func NewDaemonSetGate(reader client.Reader, log logr.Logger, key types.NamespacedName) StartupGate {
+	return func(ctx context.Context) (bool, error) {
+		ds := &appsv1.DaemonSet{}
+		if err := reader.Get(ctx, key, ds); err != nil {
+			return false, fmt.Errorf("getting daemonset %s failed: %w", key, err)
+		}
+		status := ds.Status
+		if status.ObservedGeneration < ds.Generation || status.DesiredNumberScheduled == 0 ||
+			status.UpdatedNumberScheduled < status.DesiredNumberScheduled || status.NumberReady < status.DesiredNumberScheduled {
+			log.Info("waiting for daemonset to be ready", "daemonset", key, "desired", status.DesiredNumberScheduled,
+				"updated", status.UpdatedNumberScheduled, "ready", status.NumberReady)
+			return false, nil
+		}
+		log.Info("daemonset ready", "daemonset", key, "ready", status.NumberReady)
+		return true, nil
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("DaemonSet startup gate", func() {
+	var (
+		ctx context.Context
+		key = types.NamespacedName{Namespace: "kube-system", Name: "calico-node"}
+		ds  *appsv1.DaemonSet
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		ds = &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name, Generation: 2},
+			Status: appsv1.DaemonSetStatus{
+				ObservedGeneration:     2,
+				DesiredNumberScheduled: 3,
+				UpdatedNumberScheduled: 3,
+				NumberReady:            2,
+			},
+		}
+	})
+
+	It("should parse namespaced names", func() {
+		Expect(controller.ParseNamespacedName("kube-system/calico-node")).To(Equal(key))
+		_, err := controller.ParseNamespacedName("calico-node")
+		Expect(err).To(MatchError(ContainSubstring("expected <namespace>/<name>")))
+	})
+
+	It("should open once all desired pods are ready", func() {
+		c := fake.NewClientBuilder().WithObjects(ds).WithStatusSubresource(&appsv1.DaemonSet{}).Build()
+		gate := controller.NewDaemonSetGate(c, logf.Log.WithName("test"), key)
+		Expect(gate(ctx)).To(BeFalse())
+
+		ds.Status.NumberReady = 3
+		Expect(c.Status().Update(ctx, ds)).To(Succeed())
+		Expect(gate(ctx)).To(BeTrue())
+	})
+
+	It("should stay closed while the daemonset is not observed or updated yet", func() {
+		ds.Status.NumberReady = 3
+		ds.Status.ObservedGeneration = 1
+		c := fake.NewClientBuilder().WithObjects(ds).Build()
+		gate := controller.NewDaemonSetGate(c, logf.Log.WithName("test"), key)
+		Expect(gate(ctx)).To(BeFalse())
+	})
+
+	It("should fail if the daemonset does not exist", func() {
+		gate := controller.NewDaemonSetGate(fake.NewClientBuilder().Build(), logf.Log.WithName("test"), key)
+		_, err := gate(ctx)
+		Expect(err).To(MatchError(ContainSubstring("getting daemonset kube-system/calico-node failed")))
+	})
+
+	It("should delay programming the routes until the daemonset is ready", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		c := fake.NewClientBuilder().WithObjects(ds, makeNode("node0", "i-0000", "10.0.0.0/24")).WithStatusSubresource(&appsv1.DaemonSet{}).Build()
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		fakeUpd := &fakeUpdater{}
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			StartupGate:       controller.NewDaemonSetGate(c, logf.Log.WithName("test"), key),
+			StartupGatePeriod: 20 * time.Millisecond,
+		})
+		Consistently(func() int { return len(fakeUpd.getCalls()) }, 100*time.Millisecond).Should(Equal(0))
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(ds), ds)).To(Succeed())
+		ds.Status.NumberReady = 3
+		Expect(c.Status().Update(ctx, ds)).To(Succeed())
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+	})
+
+	It("should stay healthy and probe the gate rate-limited while it is closed", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		c := fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).Build()
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		var probes atomic.Int32
+		fakeUpd := &fakeUpdater{}
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			StartupGate: func(context.Context) (bool, error) {
+				probes.Add(1)
+				return false, nil
+			},
+			StartupGatePeriod: time.Hour,
+		})
+		Eventually(probes.Load).Should(Equal(int32(1)))
+		Consistently(func() error { return reconciler.HealthzChecker(nil) }, 100*time.Millisecond).Should(Succeed())
+		Expect(probes.Load()).To(Equal(int32(1)))
+		Expect(fakeUpd.getCalls()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// LeadershipLostNotifier is a manager runnable closing a channel as soon as the leadership is lost.
+// As it needs leader election, the manager only starts it after this instance has been elected
+// and cancels it when the leadership is lost or the manager stops.
+type LeadershipLostNotifier struct {
+	lost chan struct{}
+	once sync.Once
+}
+
+var (
+	_ manager.Runnable               = &LeadershipLostNotifier{}
+	_ manager.LeaderElectionRunnable = &LeadershipLostNotifier{}
+)
+
+// NewLeadershipLostNotifier creates a LeadershipLostNotifier
+func NewLeadershipLostNotifier() *LeadershipLostNotifier {
+	return &LeadershipLostNotifier{lost: make(chan struct{})}
+}
+
+// Start waits until the leadership is lost
+func (n *LeadershipLostNotifier) Start(ctx context.Context) error {
+	<-ctx.Done()
+	n.once.Do(func() { close(n.lost) })
+	return nil
+}
+
+// NeedLeaderElection returns true, as the notifier must only run while this instance is the leader
+func (n *LeadershipLostNotifier) NeedLeaderElection() bool {
+	return true
+}
+
+// Lost returns the channel which is closed as soon as the leadership is lost
+func (n *LeadershipLostNotifier) Lost() <-chan struct{} {
+	return n.lost
+}
@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeadershipLostNotifier", func() {
+	It("should close the channel when the leader context is cancelled", func() {
+		notifier := controller.NewLeadershipLostNotifier()
+		Expect(notifier.NeedLeaderElection()).To(BeTrue())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- notifier.Start(ctx) }()
+		Consistently(notifier.Lost()).ShouldNot(BeClosed())
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(notifier.Lost()).To(BeClosed())
+	})
+})
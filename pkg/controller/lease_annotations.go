@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// LeaseVersionAnnotation is the annotation of the leader election lease with the version of the leader
+	LeaseVersionAnnotation = "aws-custom-route-controller.gardener.cloud/version"
+	// LeaseFeaturesAnnotation is the annotation of the leader election lease with the comma-separated features enabled by the leader
+	LeaseFeaturesAnnotation = "aws-custom-route-controller.gardener.cloud/features"
+	// leaseAnnotationRetryPeriod is the delay for repeating a failed annotation of the lease
+	leaseAnnotationRetryPeriod = 5 * time.Second
+)
+
+// LeaseAnnotator is a manager runnable annotating the leader election lease with the version and the enabled features
+// of the controller, e.g. for inventorying the deployed controllers. As it needs leader election, the manager only starts it
+// after this instance has acquired the lease.
+type LeaseAnnotator struct {
+	client      client.Client
+	log         logr.Logger
+	key         client.ObjectKey
+	annotations map[string]string
+}
+
+var (
+	_ manager.Runnable               = &LeaseAnnotator{}
+	_ manager.LeaderElectionRunnable = &LeaseAnnotator{}
+)
+
+// NewLeaseAnnotator creates a LeaseAnnotator for the lease with the key
+func NewLeaseAnnotator(c client.Client, log logr.Logger, key client.ObjectKey, version string, features []string) *LeaseAnnotator {
+	features = append([]string{}, features...)
+	sort.Strings(features)
+	return &LeaseAnnotator{
+		client: c,
+		log:    log,
+		key:    key,
+		annotations: map[string]string{
+			LeaseVersionAnnotation:  version,
+			LeaseFeaturesAnnotation: strings.Join(features, ","),
+		},
+	}
+}
+
+// Start annotates the lease, retrying until it succeeds or the leadership is lost
+func (a *LeaseAnnotator) Start(ctx context.Context) error {
+	for {
+		err := a.annotate(ctx)
+		if err == nil {
+			a.log.Info("leader election lease annotated", "lease", a.key, "annotations", a.annotations)
+			return nil
+		}
+		a.log.Error(err, "annotating leader election lease failed", "lease", a.key)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(leaseAnnotationRetryPeriod):
+		}
+	}
+}
+
+// annotate merges the annotations into the lease without reading it, so that no lease informer is needed
+func (a *LeaseAnnotator) annotate(ctx context.Context) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": a.annotations},
+	})
+	if err != nil {
+		return err
+	}
+	lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: a.key.Namespace, Name: a.key.Name}}
+	return a.client.Patch(ctx, lease, client.RawPatch(types.MergePatchType, patch))
+}
+
+// NeedLeaderElection returns true, as only the leader annotates the lease
+func (a *LeaseAnnotator) NeedLeaderElection() bool {
+	return true
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("LeaseAnnotator", func() {
+	It("should annotate the lease with the version and the features after the election", func() {
+		holder := "host_1234"
+		key := client.ObjectKey{Namespace: "kube-system", Name: "aws-custom-route-controller-leader-election"}
+		c := fake.NewClientBuilder().WithObjects(&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name, Annotations: map[string]string{"foo": "bar"}},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+		}).Build()
+		annotator := controller.NewLeaseAnnotator(c, logf.Log.WithName("test"), key, "v1.2.3", []string{"reconcile-cache", "az-scoped-routing"})
+		Expect(annotator.NeedLeaderElection()).To(BeTrue())
+
+		// the manager starts the runnable once the lease has been acquired
+		Expect(annotator.Start(context.Background())).To(Succeed())
+
+		lease := &coordinationv1.Lease{}
+		Expect(c.Get(context.Background(), key, lease)).To(Succeed())
+		Expect(lease.Annotations).To(Equal(map[string]string{
+			"foo":                              "bar",
+			controller.LeaseVersionAnnotation:  "v1.2.3",
+			controller.LeaseFeaturesAnnotation: "az-scoped-routing,reconcile-cache",
+		}))
+		Expect(lease.Spec.HolderIdentity).To(Equal(&holder))
+	})
+
+	It("should stop retrying when the leadership is lost", func() {
+		c := fake.NewClientBuilder().Build()
+		annotator := controller.NewLeaseAnnotator(c, logf.Log.WithName("test"), client.ObjectKey{Namespace: "kube-system", Name: "missing"}, "v1.2.3", nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- annotator.Start(ctx) }()
+		Consistently(done).ShouldNot(Receive())
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+})
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/atomic"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaseRenewalTracker wraps the resource lock used for leader election to record successful renewals of the lease
+type LeaseRenewalTracker struct {
+	resourcelock.Interface
+
+	threshold   time.Duration
+	elected     <-chan struct{}
+	lastRenewal atomic.Time
+}
+
+var _ resourcelock.Interface = &LeaseRenewalTracker{}
+
+// NewLeaseRenewalTracker creates a LeaseRenewalTracker failing the health check if the lease is not renewed within the threshold
+func NewLeaseRenewalTracker(lock resourcelock.Interface, threshold time.Duration) *LeaseRenewalTracker {
+	return &LeaseRenewalTracker{
+		Interface: lock,
+		threshold: threshold,
+	}
+}
+
+// SetElected sets the channel which is closed as soon as this instance is the leader
+func (t *LeaseRenewalTracker) SetElected(elected <-chan struct{}) {
+	t.elected = elected
+}
+
+// Create creates the leader election record and records the acquisition
+func (t *LeaseRenewalTracker) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if err := t.Interface.Create(ctx, ler); err != nil {
+		return err
+	}
+	t.lastRenewal.Store(time.Now())
+	return nil
+}
+
+// Update updates the leader election record and records the renewal
+func (t *LeaseRenewalTracker) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if err := t.Interface.Update(ctx, ler); err != nil {
+		return err
+	}
+	t.lastRenewal.Store(time.Now())
+	return nil
+}
+
+// HealthzChecker fails if this instance is the leader, but the lease has not been renewed within the threshold
+func (t *LeaseRenewalTracker) HealthzChecker(_ *http.Request) error {
+	if t.elected == nil {
+		return nil
+	}
+	select {
+	case <-t.elected:
+	default:
+		// not the leader
+		return nil
+	}
+	if last := t.lastRenewal.Load(); last.Add(t.threshold).Before(time.Now()) {
+		return fmt.Errorf("leader election lease not renewed since %s", last.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+type fakeLock struct {
+	record resourcelock.LeaderElectionRecord
+}
+
+func (l *fakeLock) Get(_ context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	return &l.record, nil, nil
+}
+
+func (l *fakeLock) Create(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	l.record = ler
+	return nil
+}
+
+func (l *fakeLock) Update(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	l.record = ler
+	return nil
+}
+
+func (l *fakeLock) RecordEvent(_ string) {}
+
+func (l *fakeLock) Identity() string { return "test" }
+
+func (l *fakeLock) Describe() string { return "fake lock" }
+
+var _ = Describe("LeaseRenewalTracker", func() {
+	var (
+		ctx     = context.Background()
+		elected chan struct{}
+		tracker *controller.LeaseRenewalTracker
+	)
+
+	BeforeEach(func() {
+		elected = make(chan struct{})
+		tracker = controller.NewLeaseRenewalTracker(&fakeLock{}, 50*time.Millisecond)
+		tracker.SetElected(elected)
+	})
+
+	It("should be healthy if not the leader", func() {
+		Expect(tracker.HealthzChecker(nil)).To(Succeed())
+	})
+
+	It("should be healthy while the lease is renewed", func() {
+		Expect(tracker.Create(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: "test"})).To(Succeed())
+		close(elected)
+		Expect(tracker.HealthzChecker(nil)).To(Succeed())
+		time.Sleep(30 * time.Millisecond)
+		Expect(tracker.Update(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: "test"})).To(Succeed())
+		time.Sleep(30 * time.Millisecond)
+		Expect(tracker.HealthzChecker(nil)).To(Succeed())
+	})
+
+	It("should fail if the lease is stale", func() {
+		Expect(tracker.Create(ctx, resourcelock.LeaderElectionRecord{HolderIdentity: "test"})).To(Succeed())
+		close(elected)
+		time.Sleep(60 * time.Millisecond)
+		Expect(tracker.HealthzChecker(nil)).NotTo(Succeed())
+	})
+})
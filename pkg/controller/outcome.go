@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+)
+
+const (
+	// ReasonSuccess is the reason of successful reconciles and updates
+	ReasonSuccess = "success"
+	// ReasonInstanceNotFound is the reason if AWS does not know the instance of a route
+	ReasonInstanceNotFound = "instance-not-found"
+	// ReasonThrottled is the reason if AWS API calls have been throttled
+	ReasonThrottled = "throttled"
+	// ReasonUnauthorized is the reason if the AWS credentials lack permissions or are invalid
+	ReasonUnauthorized = "unauthorized"
+	// ReasonCIDRInvalid is the reason if the pod CIDR of a node cannot be parsed
+	ReasonCIDRInvalid = "cidr-invalid"
+	// ReasonInstanceIDInvalid is the reason if the provider ID of a node contains a malformed instance ID
+	ReasonInstanceIDInvalid = "instance-id-invalid"
+	// ReasonOther is the reason of all other errors
+	ReasonOther = "other"
+)
+
+// awsErrorReasons maps AWS error codes to reasons
+var awsErrorReasons = map[string]string{
+	"InvalidInstanceID.NotFound":  ReasonInstanceNotFound,
+	"InvalidInstanceID.Malformed": ReasonInstanceIDInvalid,
+	"RequestLimitExceeded":        ReasonThrottled,
+	"Throttling":                  ReasonThrottled,
+	"ThrottlingException":         ReasonThrottled,
+	"UnauthorizedOperation":       ReasonUnauthorized,
+	"AuthFailure":                 ReasonUnauthorized,
+	"AccessDenied":                ReasonUnauthorized,
+	"AccessDeniedException":       ReasonUnauthorized,
+}
+
+// ErrorReasons classifies the (combined) errors. Each reason is only returned once.
+func ErrorReasons(err error) []string {
+	if err == nil {
+		return []string{ReasonSuccess}
+	}
+	found := map[string]bool{}
+	for _, e := range multierr.Errors(err) {
+		reason := ReasonOther
+		var awsErr awserr.Error
+		if errors.As(e, &awsErr) {
+			if r, ok := awsErrorReasons[awsErr.Code()]; ok {
+				reason = r
+			}
+		}
+		found[reason] = true
+	}
+	reasons := make([]string, 0, len(found))
+	for reason := range found {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// recordOutcome counts the reasons of the error in the outcomes of node reconciles or route updates
+func recordOutcome(outcomes *prometheus.CounterVec, err error) {
+	for _, reason := range ErrorReasons(err) {
+		outcomes.WithLabelValues(reason).Inc()
+	}
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/multierr"
+)
+
+var _ = Describe("ErrorReasons", func() {
+	awsError := func(code string) error {
+		return fmt.Errorf("creating route 10.0.1.0/24 -> i-0001 of node node1 in table rtb-1 failed: %w", awserr.New(code, "message", nil))
+	}
+
+	DescribeTable("should classify errors",
+		func(err error, expected ...string) {
+			Expect(controller.ErrorReasons(err)).To(Equal(expected))
+		},
+		Entry("success", nil, controller.ReasonSuccess),
+		Entry("instance not found", awsError("InvalidInstanceID.NotFound"), controller.ReasonInstanceNotFound),
+		Entry("malformed instance ID", awsError("InvalidInstanceID.Malformed"), controller.ReasonInstanceIDInvalid),
+		Entry("request limit exceeded", awsError("RequestLimitExceeded"), controller.ReasonThrottled),
+		Entry("throttling", awsError("Throttling"), controller.ReasonThrottled),
+		Entry("unauthorized operation", awsError("UnauthorizedOperation"), controller.ReasonUnauthorized),
+		Entry("auth failure", awsError("AuthFailure"), controller.ReasonUnauthorized),
+		Entry("unknown AWS error", awsError("InternalError"), controller.ReasonOther),
+		Entry("no AWS error", fmt.Errorf("unable to find route table"), controller.ReasonOther),
+		Entry("combined errors", multierr.Combine(awsError("RequestLimitExceeded"), awsError("AuthFailure"), awsError("Throttling")),
+			controller.ReasonThrottled, controller.ReasonUnauthorized),
+	)
+})
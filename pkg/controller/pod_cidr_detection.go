@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DetectPodNetworkCIDR determines the pod network CIDR as the smallest network covering the IPv4 pod CIDRs of the existing nodes
+func DetectPodNetworkCIDR(ctx context.Context, reader client.Reader) (string, error) {
+	nodeList := &corev1.NodeList{}
+	if err := reader.List(ctx, nodeList); err != nil {
+		return "", err
+	}
+
+	var networks []*net.IPNet
+	for _, node := range nodeList.Items {
+		podCIDR, _ := util.GetIPv4CIDR(updater.NodePodCIDRs(&node))
+		if podCIDR == "" {
+			continue
+		}
+		_, nodeNetwork, err := net.ParseCIDR(podCIDR)
+		if err != nil {
+			continue
+		}
+		networks = append(networks, nodeNetwork)
+	}
+	if len(networks) == 0 {
+		return "", fmt.Errorf("no nodes with IPv4 pod CIDR found")
+	}
+	supernet := util.SupernetCIDR(networks)
+	if supernet == nil {
+		return "", fmt.Errorf("no common pod network of node pod CIDRs found")
+	}
+	return supernet.String(), nil
+}
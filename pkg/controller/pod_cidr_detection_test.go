@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("DetectPodNetworkCIDR", func() {
+	It("should detect the smallest network covering all node pod CIDRs", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			makeNode("node1", "i-0001", "10.243.1.0/24"),
+			makeNode("node2", "i-0002", "10.243.6.0/24"),
+			makeNode("node3", "i-0003", "fd00:10:243::/64"),
+		).Build()
+
+		cidr, err := controller.DetectPodNetworkCIDR(context.Background(), c)
+		Expect(err).To(BeNil())
+		Expect(cidr).To(Equal("10.243.0.0/21"))
+	})
+
+	It("should use the pod CIDR of a single node", func() {
+		c := fake.NewClientBuilder().WithObjects(makeNode("node1", "i-0001", "10.243.1.0/24")).Build()
+
+		cidr, err := controller.DetectPodNetworkCIDR(context.Background(), c)
+		Expect(err).To(BeNil())
+		Expect(cidr).To(Equal("10.243.1.0/24"))
+	})
+
+	It("should fail without nodes", func() {
+		c := fake.NewClientBuilder().Build()
+
+		_, err := controller.DetectPodNetworkCIDR(context.Background(), c)
+		Expect(err).To(HaveOccurred())
+	})
+})
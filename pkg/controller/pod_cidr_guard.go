@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"net"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodCIDRCoverage describes how many of the node pod CIDRs are covered by the pod network
+type PodCIDRCoverage struct {
+	// Nodes is the number of nodes with an IPv4 pod CIDR
+	Nodes int
+	// Uncovered contains the names of the nodes with a pod CIDR outside of the pod network
+	Uncovered []string
+}
+
+// Exceeds returns true if the ratio of uncovered node pod CIDRs is greater than the given maximum ratio
+func (c *PodCIDRCoverage) Exceeds(maxRatio float64) bool {
+	if c.Nodes == 0 {
+		return false
+	}
+	return float64(len(c.Uncovered))/float64(c.Nodes) > maxRatio
+}
+
+// CheckPodCIDRCoverage compares the pod CIDRs of the existing nodes with the pod network CIDR
+func CheckPodCIDRCoverage(ctx context.Context, reader client.Reader, podNetworkCIDR string) (*PodCIDRCoverage, error) {
+	_, podNetwork, err := net.ParseCIDR(podNetworkCIDR)
+	if err != nil {
+		return nil, err
+	}
+	nodeList := &corev1.NodeList{}
+	if err := reader.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
+
+	coverage := &PodCIDRCoverage{}
+	for _, node := range nodeList.Items {
+		podCIDR, _ := util.GetIPv4CIDR(updater.NodePodCIDRs(&node))
+		if podCIDR == "" {
+			continue
+		}
+		_, nodeNetwork, err := net.ParseCIDR(podCIDR)
+		if err != nil {
+			continue
+		}
+		coverage.Nodes++
+		if !util.ContainsCIDR(podNetwork, nodeNetwork) {
+			coverage.Uncovered = append(coverage.Uncovered, node.Name)
+		}
+	}
+	metrics.NodeCIDRsOutsidePodNetwork.Set(float64(len(coverage.Uncovered)))
+	return coverage, nil
+}
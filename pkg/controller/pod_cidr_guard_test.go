@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("CheckPodCIDRCoverage", func() {
+	It("should accept nodes covered by the pod network", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			makeNode("node1", "i-0001", "10.243.1.0/24"),
+			makeNode("node2", "i-0002", "10.243.2.0/24"),
+		).Build()
+
+		coverage, err := controller.CheckPodCIDRCoverage(context.Background(), c, "10.243.0.0/16")
+		Expect(err).To(BeNil())
+		Expect(coverage.Nodes).To(Equal(2))
+		Expect(coverage.Uncovered).To(BeEmpty())
+		Expect(coverage.Exceeds(0)).To(BeFalse())
+		Expect(testutil.ToFloat64(metrics.NodeCIDRsOutsidePodNetwork)).To(Equal(0.0))
+	})
+
+	It("should report nodes outside of the pod network", func() {
+		c := fake.NewClientBuilder().WithObjects(
+			makeNode("node1", "i-0001", "10.243.1.0/24"),
+			makeNode("node2", "i-0002", "10.244.2.0/24"),
+			makeNode("node3", "i-0003", "10.243.0.0/15"),
+		).Build()
+
+		coverage, err := controller.CheckPodCIDRCoverage(context.Background(), c, "10.243.0.0/16")
+		Expect(err).To(BeNil())
+		Expect(coverage.Nodes).To(Equal(3))
+		Expect(coverage.Uncovered).To(ConsistOf("node2", "node3"))
+		Expect(coverage.Exceeds(0.5)).To(BeTrue())
+		Expect(coverage.Exceeds(1)).To(BeFalse())
+		Expect(testutil.ToFloat64(metrics.NodeCIDRsOutsidePodNetwork)).To(Equal(2.0))
+	})
+})
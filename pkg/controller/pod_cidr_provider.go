@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// PodCIDRProvider determines the pod CIDRs of a node
+type PodCIDRProvider interface {
+	// PodCIDRs returns the pod CIDRs of the node, or none if they are not known (yet)
+	PodCIDRs(ctx context.Context, node *corev1.Node) ([]string, error)
+}
+
+// NodePodCIDRProvider takes the pod CIDRs from the node spec, which is the default
+type NodePodCIDRProvider struct{}
+
+var _ PodCIDRProvider = NodePodCIDRProvider{}
+
+// PodCIDRs returns the pod CIDRs of the node spec
+func (NodePodCIDRProvider) PodCIDRs(_ context.Context, node *corev1.Node) ([]string, error) {
+	return updater.NodePodCIDRs(node), nil
+}
+
+// CustomResourcePodCIDRProvider reads the pod CIDRs of a node from a field of the custom resource named like the node,
+// e.g. if the authoritative pod CIDRs are maintained on infrastructure machine objects
+type CustomResourcePodCIDRProvider struct {
+	client    dynamic.Interface
+	resource  schema.GroupVersionResource
+	namespace string
+	fieldPath []string
+}
+
+var _ PodCIDRProvider = &CustomResourcePodCIDRProvider{}
+
+// ParseCustomResourceField parses the custom resource and the field holding the pod CIDRs
+// in the form <group>/<version>/<resource>:<field path>, e.g. example.com/v1/machines:spec.podCIDRs.
+// The group is omitted for the core API group.
+func ParseCustomResourceField(value string) (schema.GroupVersionResource, []string, error) {
+	resource, field, ok := strings.Cut(value, ":")
+	if !ok || field == "" {
+		return schema.GroupVersionResource{}, nil, fmt.Errorf("missing field path in %q, expected <group>/<version>/<resource>:<field path>", value)
+	}
+	gvr, err := parseGroupVersionResource(resource)
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+	fieldPath, ok := parseFieldPath(field)
+	if !ok {
+		return schema.GroupVersionResource{}, nil, fmt.Errorf("invalid custom resource field %q", value)
+	}
+	return gvr, fieldPath, nil
+}
+
+// parseGroupVersionResource parses a resource in the form <group>/<version>/<resource>, without group for the core API group
+func parseGroupVersionResource(resource string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(resource, "/")
+	var gvr schema.GroupVersionResource
+	switch len(parts) {
+	case 2:
+		gvr = schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}
+	case 3:
+		gvr = schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}
+	default:
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q, expected <group>/<version>/<resource>", resource)
+	}
+	if gvr.Version == "" || gvr.Resource == "" {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q, expected <group>/<version>/<resource>", resource)
+	}
+	return gvr, nil
+}
+
+// parseFieldPath splits a dot-separated field path, which may start with a dot
+func parseFieldPath(field string) ([]string, bool) {
+	fieldPath := strings.Split(strings.TrimPrefix(field, "."), ".")
+	for _, part := range fieldPath {
+		if part == "" {
+			return nil, false
+		}
+	}
+	return fieldPath, true
+}
+
+// NewCustomResourcePodCIDRProvider creates a provider reading the field of the resource in the namespace
+// (empty for cluster-scoped resources)
+func NewCustomResourcePodCIDRProvider(client dynamic.Interface, resource schema.GroupVersionResource, namespace string, fieldPath []string) *CustomResourcePodCIDRProvider {
+	return &CustomResourcePodCIDRProvider{
+		client:    client,
+		resource:  resource,
+		namespace: namespace,
+		fieldPath: fieldPath,
+	}
+}
+
+// PodCIDRs returns the value of the field, which must be a string or a list of strings.
+// If the custom resource or the field does not exist, no pod CIDRs are returned.
+func (p *CustomResourcePodCIDRProvider) PodCIDRs(ctx context.Context, node *corev1.Node) ([]string, error) {
+	obj, err := p.client.Resource(p.resource).Namespace(p.namespace).Get(ctx, node.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting %s %s failed: %w", p.resource.Resource, node.Name, err)
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, p.fieldPath...)
+	if err != nil || !found {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		cidrs := make([]string, 0, len(v))
+		for _, item := range v {
+			cidr, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("field %s of %s %s contains a non-string value %v", strings.Join(p.fieldPath, "."), p.resource.Resource, node.Name, item)
+			}
+			cidrs = append(cidrs, cidr)
+		}
+		return cidrs, nil
+	default:
+		return nil, fmt.Errorf("field %s of %s %s is neither a string nor a list of strings", strings.Join(p.fieldPath, "."), p.resource.Resource, node.Name)
+	}
+}
+
+// SetPodCIDRProvider replaces the pod CIDRs of the node spec by the ones of the provider
+func (r *NodeReconciler) SetPodCIDRProvider(provider PodCIDRProvider) {
+	r.podCIDRProvider = provider
+}
+
+// withProviderPodCIDRs returns the node with the pod CIDRs of the provider, if one is set
+func (r *NodeReconciler) withProviderPodCIDRs(ctx context.Context, node *corev1.Node) (*corev1.Node, error) {
+	if r.podCIDRProvider == nil {
+		return node, nil
+	}
+	cidrs, err := r.podCIDRProvider.PodCIDRs(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	node = node.DeepCopy()
+	node.Spec.PodCIDR = ""
+	node.Spec.PodCIDRs = cidrs
+	if len(cidrs) > 0 {
+		node.Spec.PodCIDR = cidrs[0]
+	}
+	return node, nil
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var machinesResource = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "machines"}
+
+func makeMachine(name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": fields}}
+	obj.SetAPIVersion("example.com/v1")
+	obj.SetKind("Machine")
+	obj.SetNamespace("machines")
+	obj.SetName(name)
+	return obj
+}
+
+func newMachineProvider(objects ...runtime.Object) *controller.CustomResourcePodCIDRProvider {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{machinesResource: "MachineList"}, objects...)
+	return controller.NewCustomResourcePodCIDRProvider(client, machinesResource, "machines", []string{"spec", "podCIDRs"})
+}
+
+var _ = Describe("PodCIDRProvider", func() {
+	DescribeTable("should parse the custom resource field",
+		func(value string, gvr schema.GroupVersionResource, fieldPath []string) {
+			parsedGVR, parsedFieldPath, err := controller.ParseCustomResourceField(value)
+			Expect(err).To(BeNil())
+			Expect(parsedGVR).To(Equal(gvr))
+			Expect(parsedFieldPath).To(Equal(fieldPath))
+		},
+		Entry("with group", "example.com/v1/machines:spec.podCIDRs", machinesResource, []string{"spec", "podCIDRs"}),
+		Entry("with core group", "v1/configmaps:data.cidr", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, []string{"data", "cidr"}),
+		Entry("with leading dot", "example.com/v1/machines:.status.cidr", machinesResource, []string{"status", "cidr"}),
+	)
+
+	DescribeTable("should reject an invalid custom resource field",
+		func(value string) {
+			_, _, err := controller.ParseCustomResourceField(value)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("without field", "example.com/v1/machines"),
+		Entry("with empty field", "example.com/v1/machines:"),
+		Entry("without version", "machines:spec.podCIDRs"),
+		Entry("with too many parts", "example.com/v1/machines/extra:spec.podCIDRs"),
+		Entry("with empty field path element", "example.com/v1/machines:spec..podCIDRs"),
+	)
+
+	It("should return the pod CIDRs of the node spec by default", func() {
+		cidrs, err := controller.NodePodCIDRProvider{}.PodCIDRs(context.Background(), makeNode("node1", "i-0001", "10.243.1.0/24"))
+		Expect(err).To(BeNil())
+		Expect(cidrs).To(Equal([]string{"10.243.1.0/24"}))
+	})
+
+	It("should read a list of pod CIDRs from the custom resource", func() {
+		provider := newMachineProvider(makeMachine("node1", map[string]interface{}{
+			"podCIDRs": []interface{}{"10.243.1.0/24", "fd00:1::/64"},
+		}))
+		cidrs, err := provider.PodCIDRs(context.Background(), makeNode("node1", "i-0001", "10.250.1.0/24"))
+		Expect(err).To(BeNil())
+		Expect(cidrs).To(Equal([]string{"10.243.1.0/24", "fd00:1::/64"}))
+	})
+
+	It("should read a single pod CIDR from the custom resource", func() {
+		provider := newMachineProvider(makeMachine("node1", map[string]interface{}{"podCIDRs": "10.243.1.0/24"}))
+		cidrs, err := provider.PodCIDRs(context.Background(), makeNode("node1", "i-0001", "10.250.1.0/24"))
+		Expect(err).To(BeNil())
+		Expect(cidrs).To(Equal([]string{"10.243.1.0/24"}))
+	})
+
+	It("should return no pod CIDRs if the custom resource or the field does not exist", func() {
+		provider := newMachineProvider(makeMachine("node1", map[string]interface{}{}))
+		cidrs, err := provider.PodCIDRs(context.Background(), makeNode("node1", "i-0001", "10.250.1.0/24"))
+		Expect(err).To(BeNil())
+		Expect(cidrs).To(BeEmpty())
+
+		cidrs, err = provider.PodCIDRs(context.Background(), makeNode("node2", "i-0002", "10.250.2.0/24"))
+		Expect(err).To(BeNil())
+		Expect(cidrs).To(BeEmpty())
+	})
+
+	It("should fail if the field has an unexpected type", func() {
+		provider := newMachineProvider(
+			makeMachine("node1", map[string]interface{}{"podCIDRs": int64(42)}),
+			makeMachine("node2", map[string]interface{}{"podCIDRs": []interface{}{"10.243.2.0/24", int64(42)}}),
+		)
+		_, err := provider.PodCIDRs(context.Background(), makeNode("node1", "i-0001", "10.250.1.0/24"))
+		Expect(err).To(MatchError(ContainSubstring("neither a string nor a list of strings")))
+		_, err = provider.PodCIDRs(context.Background(), makeNode("node2", "i-0002", "10.250.2.0/24"))
+		Expect(err).To(MatchError(ContainSubstring("non-string value")))
+	})
+
+	It("should program the routes with the pod CIDRs of the provider", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		fakeUpd := &fakeUpdater{}
+
+		c := fake.NewClientBuilder().WithObjects(
+			makeNode("node1", "i-0001", "10.250.1.0/24"),
+			makeNode("node2", "i-0002", "10.250.2.0/24"),
+		).Build()
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		reconciler.SetPodCIDRProvider(newMachineProvider(makeMachine("node1", map[string]interface{}{
+			"podCIDRs": []interface{}{"10.243.1.0/24"},
+		})))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		routes := fakeUpd.getCalls()[0].routes
+		Expect(routes).To(HaveLen(1))
+		Expect(routes[0].NodeName).To(Equal("node1"))
+		Expect(routes[0].PodCIDR).To(Equal("10.243.1.0/24"))
+	})
+
+	It("should fail the reconcile if the provider fails", func() {
+		elected := make(chan struct{})
+		close(elected)
+		c := fake.NewClientBuilder().WithObjects(makeNode("node1", "i-0001", "10.250.1.0/24")).Build()
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		// initialise with the node spec first, as it does not tolerate provider errors
+		_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+
+		reconciler.SetPodCIDRProvider(newMachineProvider(makeMachine("node1", map[string]interface{}{"podCIDRs": true})))
+		_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(MatchError(ContainSubstring("neither a string nor a list of strings")))
+	})
+})
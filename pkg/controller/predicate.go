@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"reflect"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// NodeRouteChangedPredicate filters node updates which are not relevant for the routes, like status heartbeats
+type NodeRouteChangedPredicate struct {
+	predicate.Funcs
+	// RelevantAnnotations are the keys of node annotations considered for the routes
+	RelevantAnnotations []string
+}
+
+// Update returns true if the pod CIDRs, the provider ID, the internal IP, the readiness, the labels, relevant annotations
+// or the deletion timestamp of the node have changed
+func (p NodeRouteChangedPredicate) Update(e event.UpdateEvent) bool {
+	oldNode, ok := e.ObjectOld.(*corev1.Node)
+	if !ok {
+		return true
+	}
+	newNode, ok := e.ObjectNew.(*corev1.Node)
+	if !ok {
+		return true
+	}
+	if oldNode.Spec.PodCIDR != newNode.Spec.PodCIDR ||
+		!reflect.DeepEqual(oldNode.Spec.PodCIDRs, newNode.Spec.PodCIDRs) ||
+		oldNode.Spec.ProviderID != newNode.Spec.ProviderID ||
+		updater.IsNodeReady(oldNode) != updater.IsNodeReady(newNode) ||
+		!updater.NodeInternalIPv4(oldNode).Equal(updater.NodeInternalIPv4(newNode)) ||
+		!reflect.DeepEqual(oldNode.Labels, newNode.Labels) ||
+		(oldNode.DeletionTimestamp == nil) != (newNode.DeletionTimestamp == nil) {
+		return true
+	}
+	for _, key := range p.RelevantAnnotations {
+		if oldNode.Annotations[key] != newNode.Annotations[key] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("NodeRouteChangedPredicate", func() {
+	var (
+		pred    = controller.NodeRouteChangedPredicate{RelevantAnnotations: []string{"example.com/relevant"}}
+		oldNode *corev1.Node
+		newNode *corev1.Node
+	)
+
+	BeforeEach(func() {
+		oldNode = makeNode("node1", "i-0001", "10.0.1.0/24")
+		oldNode.ResourceVersion = "1"
+		newNode = oldNode.DeepCopy()
+		newNode.ResourceVersion = "2"
+	})
+
+	update := func() bool {
+		return pred.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: newNode})
+	}
+
+	It("should ignore heartbeat-only updates", func() {
+		oldNode.Status.Conditions = []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastHeartbeatTime: metav1.NewTime(time.Now().Add(-time.Minute))},
+		}
+		newNode.Status.Conditions = []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastHeartbeatTime: metav1.NewTime(time.Now())},
+		}
+		Expect(update()).To(BeFalse())
+	})
+
+	It("should ignore changes of irrelevant annotations", func() {
+		newNode.Annotations = map[string]string{"example.com/other": "foo"}
+		Expect(update()).To(BeFalse())
+	})
+
+	It("should accept pod CIDR changes", func() {
+		newNode.Spec.PodCIDRs = []string{"10.0.2.0/24"}
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept provider ID changes", func() {
+		newNode.Spec.ProviderID = "aws:///eu-west-1a/i-0002"
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept readiness changes", func() {
+		newNode.Status.Conditions = []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+		}
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept internal IP changes", func() {
+		newNode.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.250.0.5"}}
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept label changes", func() {
+		newNode.Labels = map[string]string{"foo": "bar"}
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept changes of relevant annotations", func() {
+		newNode.Annotations = map[string]string{"example.com/relevant": "foo"}
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept deletion timestamp changes", func() {
+		newNode.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		Expect(update()).To(BeTrue())
+	})
+
+	It("should accept create and delete events", func() {
+		Expect(pred.Create(event.CreateEvent{Object: newNode})).To(BeTrue())
+		Expect(pred.Delete(event.DeleteEvent{Object: newNode})).To(BeTrue())
+	})
+})
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+)
+
+// SetReconcileTimeout sets the maximum duration of reconciling a single node and of each route update (0 for no limit).
+// A timed out reconcile fails and the node is requeued with backoff, a timed out update is retried with backoff.
+func (r *NodeReconciler) SetReconcileTimeout(timeout time.Duration) {
+	r.reconcileTimeout = timeout
+}
+
+// withReconcileTimeout returns the context of a single reconcile limited by the reconcile timeout
+func (r *NodeReconciler) withReconcileTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.reconcileTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.reconcileTimeout)
+}
+
+// reconcileError marks the error of a reconcile which has exceeded the reconcile timeout
+func (r *NodeReconciler) reconcileError(ctx context.Context, nodeName string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("reconcile of node %s timed out after %s: %w", nodeName, r.reconcileTimeout, err)
+	}
+	return err
+}
+
+// errUpdateStillRunning is returned while a timed out update has not returned yet
+var errUpdateStillRunning = errors.New("timed out route update still running")
+
+// withUpdateTimeout limits each call of the update function by the reconcile timeout. A timed out update is
+// aborted before its next change. If an AWS call hangs, the update keeps running in the background and the
+// following updates fail until it has returned, so that the updater loop keeps ticking without overlapping updates.
+func (r *NodeReconciler) withUpdateTimeout(ctx context.Context, updateFunc updater.NodeRoutesUpdater) updater.NodeRoutesUpdater {
+	if r.reconcileTimeout <= 0 {
+		return updateFunc
+	}
+	var running chan struct{}
+	return func(routes []updater.NodeRoute, options updater.UpdateOptions) (*updater.UpdateResult, error) {
+		if running != nil {
+			select {
+			case <-running:
+			default:
+				return nil, errUpdateStillRunning
+			}
+		}
+		tctx, cancel := context.WithTimeout(ctx, r.reconcileTimeout)
+		defer cancel()
+		// the abort channel of the loop is closed with ctx as well
+		options.Abort = tctx.Done()
+
+		var (
+			result *updater.UpdateResult
+			err    error
+			done   = make(chan struct{})
+		)
+		running = done
+		go func() {
+			defer close(done)
+			result, err = updateFunc(routes, options)
+		}()
+		select {
+		case <-done:
+		case <-tctx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("route update timed out after %s: %w", r.reconcileTimeout, tctx.Err())
+		}
+		if err != nil && tctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("route update timed out after %s: %w", r.reconcileTimeout, err)
+		}
+		return result, err
+	}
+}
@@ -10,14 +10,21 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/gardener/aws-custom-route-controller/pkg/inventory"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
 	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
 	"github.com/go-logr/logr"
 	"go.uber.org/atomic"
+	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -33,11 +40,40 @@ type NodeReconciler struct {
 	updaterStarted     atomic.Bool
 	elected            <-chan struct{}
 	nodeRoutes         *updater.NamedNodeRoutes
+	workerPool         *WorkerPool
+	otherPoolRoutes    *updater.NamedNodeRoutes
+	podCIDRProvider    PodCIDRProvider
 	lastTick           atomic.Time
 	tickPeriod         time.Duration
+	forceSync          atomic.Bool
+	heartbeat          atomic.Time
+	livenessThreshold  time.Duration
+	// nodesObserved is set as soon as a node has been seen, route deletions are skipped before
+	nodesObserved atomic.Bool
+
+	// reconcileTimeout limits the duration of reconciling a single node and of each route update (0 for no limit)
+	reconcileTimeout time.Duration
+
+	// removeTerminatingNodeRoutes removes the routes of nodes with a deletion timestamp
+	removeTerminatingNodeRoutes bool
 
 	recorder    record.EventRecorder
 	lastEventOk bool
+
+	controlRecorder record.EventRecorder
+	controlRef      *corev1.ObjectReference
+
+	// routeTables traces the route tables of the node routes
+	routeTables routeTableTrace
+
+	// appliedRoutes contains the node routes of the last successful update, only used by the updater loop
+	appliedRoutes appliedRoutesCache
+	// cleanupWithoutNodesLogged is set after logging that the cleanup is skipped without nodes, only used by the updater loop
+	cleanupWithoutNodesLogged bool
+
+	// lastError is the last update error reported in the sync report
+	lastError     string
+	lastErrorTime time.Time
 }
 
 // NewNodeReconciler creates a NodeReconciler instance
@@ -48,28 +84,126 @@ func NewNodeReconciler(
 	recorder record.EventRecorder,
 ) *NodeReconciler {
 	return &NodeReconciler{
-		client:     client,
-		log:        log.WithName("controller").WithName("node"),
-		elected:    elected,
-		nodeRoutes: updater.NewNamedNodeRoutes(),
-		recorder:   recorder,
+		client:          client,
+		log:             log.WithName("controller").WithName("node"),
+		elected:         elected,
+		nodeRoutes:      updater.NewNamedNodeRoutes(),
+		otherPoolRoutes: updater.NewNamedNodeRoutes(),
+		recorder:        recorder,
 	}
 }
 
+// SetFallbackToNodeIP enables programming a /32 route for the internal IP of nodes without pod CIDR
+func (r *NodeReconciler) SetFallbackToNodeIP(enabled bool) {
+	r.nodeRoutes.SetFallbackToNodeIP(enabled)
+	r.otherPoolRoutes.SetFallbackToNodeIP(enabled)
+}
+
+// UpdaterConfig contains the settings of the background updater loop
+type UpdaterConfig struct {
+	// ClusterName is the value of the cluster label of the metrics exported by the updater
+	ClusterName string
+	// TickPeriod is the period for checking for updates
+	TickPeriod time.Duration
+	// SyncPeriod is the period for syncing all routes
+	SyncPeriod time.Duration
+	// MaxDelayOnFailure is the maximum delay for retrying if the update failed
+	MaxDelayOnFailure time.Duration
+	// SyncBatchSize is the maximum number of node routes processed at once during a full sync (0 means unlimited)
+	SyncBatchSize int
+	// SyncBatchPause is the pause between two batches of a full sync
+	SyncBatchPause time.Duration
+	// RecheckPeriod is the delay for repeating an update if some node routes have been skipped temporarily
+	RecheckPeriod time.Duration
+	// NodeConditionType is the type of the node condition maintained after the route of a node is programmed (empty to disable)
+	NodeConditionType corev1.NodeConditionType
+	// RemoveTaint is the key of the taint removed from a node after its route is programmed (empty to disable)
+	RemoveTaint string
+	// NodeConflictRetries is the maximum number of attempts for patching a node condition or taint on conflicts (0 uses the client-go default)
+	NodeConflictRetries int
+	// NodePatchRetries is the maximum number of attempts for patching a node condition or taint on transient API server errors,
+	// retried with exponential backoff (0 or 1 disables retrying). Only the first node exhausting them is retried per update.
+	NodePatchRetries int
+	// NodePatchMaxDelay is the maximum delay between two attempts of a node patch (0 for the default of 5s)
+	NodePatchMaxDelay time.Duration
+	// LivenessThreshold is the maximum age of the updater heartbeat before the liveness check fails (0 disables the check)
+	LivenessThreshold time.Duration
+	// StartupCleanupDelay is the time after startup during which no routes are deleted, to give the node cache time to populate
+	StartupCleanupDelay time.Duration
+	// StartupRepairPass makes the first update after startup create-only, restoring missing routes quickly.
+	// Deletions follow with the next update once the repair pass has finished.
+	StartupRepairPass bool
+	// Inventory is updated with the node route mappings after each successful update (optional)
+	Inventory *inventory.Inventory
+	// InventoryStore persists the inventory (optional)
+	InventoryStore *inventory.ConfigMapStore
+	// DriftDetector checks for missing routes between the updates (optional)
+	DriftDetector updater.DriftDetector
+	// DriftDetectionInterval is the period of the drift detection (0 disables it)
+	DriftDetectionInterval time.Duration
+	// StartupGate delays programming the routes until it opens (optional)
+	StartupGate StartupGate
+	// StartupGatePeriod is the period for probing the closed startup gate (0 for the default of 10s)
+	StartupGatePeriod time.Duration
+	// SyncReportStore persists a report after each full sync (optional)
+	SyncReportStore *SyncReportStore
+	// RouteStateStore exports the managed routes after each successful update (optional)
+	RouteStateStore *RouteStateStore
+	// ObserveOnly reports drift instead of updating node conditions and taints, as the routes are not programmed
+	ObserveOnly bool
+	// ReconcileDebounce delays the update after a node route change, so that all changes within the window result in a single update (0 to disable)
+	ReconcileDebounce time.Duration
+	// CleanupWithoutNodes permits deleting routes before any node has been observed, e.g. in a fresh cluster
+	CleanupWithoutNodes bool
+	// ReconcileCache skips the update if the node routes are the same as applied by the last successful update.
+	// Full syncs, retries, rechecks and detected drift bypass it.
+	ReconcileCache bool
+	// LeadershipLost is closed when the leadership is lost, which aborts a running update and stops the updater (optional)
+	LeadershipLost <-chan struct{}
+}
+
 // StartUpdater starts background go routine to check for changed routes calculated by watching nodes
-func (r *NodeReconciler) StartUpdater(ctx context.Context, updateFunc updater.NodeRoutesUpdater,
-	tickPeriod, syncPeriod, maxDelayOnFailure time.Duration) {
-	r.tickPeriod = tickPeriod
-	ticker := time.NewTicker(tickPeriod)
+func (r *NodeReconciler) StartUpdater(ctx context.Context, updateFunc updater.NodeRoutesUpdater, cfg UpdaterConfig) {
+	r.tickPeriod = cfg.TickPeriod
+	r.livenessThreshold = cfg.LivenessThreshold
+	r.heartbeat.Store(time.Now())
+	ticker := time.NewTicker(cfg.TickPeriod)
 	log := r.log.WithName("ticker")
+	ctx, cancel := context.WithCancel(ctx)
+	updateFunc = r.withUpdateTimeout(ctx, updateFunc)
+	if cfg.LeadershipLost != nil {
+		go func() {
+			select {
+			case <-cfg.LeadershipLost:
+				log.Info("leadership lost, stopping updater")
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
 
 	go func() {
+		defer cancel()
 		var (
-			lastUpdate  time.Time
-			lastFailure time.Time
-			delay       time.Duration
+			lastUpdate     time.Time
+			lastFailure    time.Time
+			lastDriftCheck time.Time
+			delay          time.Duration
+			recheckAt      time.Time
+			// cleanupAfter is the end of the startup cleanup delay
+			cleanupAfter    time.Time
+			cleanupDeferred bool
+			// repairPending is set until the create-only startup repair pass has finished
+			repairPending = cfg.StartupRepairPass
+			gateOpen      = cfg.StartupGate == nil
+			lastGateProbe time.Time
+			gatePeriod    = cfg.StartupGatePeriod
 		)
+		if gatePeriod == 0 {
+			gatePeriod = defaultStartupGatePeriod
+		}
 
+		r.loadInventory(ctx, log, cfg)
 		r.updaterStarted.Store(true)
 
 		for {
@@ -78,34 +212,140 @@ func (r *NodeReconciler) StartUpdater(ctx context.Context, updateFunc updater.No
 				log.Info("updater loop cancelled")
 				return
 			}
+			r.heartbeat.Store(time.Now())
 			if !r.initialiseFinished.Load() {
 				continue
 			}
-			if lastUpdate.Add(syncPeriod).Before(time.Now()) {
+			if !gateOpen {
+				// the updater is alive while waiting for the gate
+				r.lastTick.Store(time.Now())
+				if lastGateProbe.Add(gatePeriod).After(time.Now()) {
+					continue
+				}
+				lastGateProbe = time.Now()
+				ready, err := cfg.StartupGate(ctx)
+				if err != nil {
+					log.Error(err, "checking startup gate failed")
+				}
+				if !ready {
+					continue
+				}
+				gateOpen = true
+			}
+			if cleanupAfter.IsZero() {
+				cleanupAfter = time.Now().Add(cfg.StartupCleanupDelay)
+				if cfg.StartupCleanupDelay > 0 {
+					log.Info("deferring route cleanup after startup", "startupCleanupDelay", cfg.StartupCleanupDelay)
+				}
+			}
+			createOnly := repairPending || time.Now().Before(cleanupAfter) || r.isCleanupWithoutNodes(log, cfg)
+			// bypassCache is set if the update must not be skipped even if the node routes are unchanged
+			bypassCache := createOnly
+			if cleanupDeferred && !createOnly {
+				log.Info("startup cleanup enabled")
+				r.nodeRoutes.SetChanged()
+				cleanupDeferred = false
+				bypassCache = true
+			}
+			sync, force := false, r.forceSync.Swap(false)
+			if force {
+				log.Info("forced sync")
+				r.nodeRoutes.SetChanged()
+				sync = true
+			} else if lastUpdate.Add(cfg.SyncPeriod).Before(time.Now()) {
 				log.Info("sync")
 				r.nodeRoutes.SetChanged()
+				sync = true
 			}
+			bypassCache = bypassCache || sync
 			if delay > 0 && lastFailure.Add(delay).Before(time.Now()) {
 				log.Info("retry")
 				r.nodeRoutes.SetChanged()
+				bypassCache = true
+			}
+			if !recheckAt.IsZero() && recheckAt.Before(time.Now()) {
+				log.Info("recheck")
+				r.nodeRoutes.SetChanged()
+				bypassCache = true
+			}
+			if cfg.DriftDetector != nil && cfg.DriftDetectionInterval > 0 && delay == 0 &&
+				lastDriftCheck.Add(cfg.DriftDetectionInterval).Before(time.Now()) && lastUpdate.Add(cfg.DriftDetectionInterval).Before(time.Now()) {
+				lastDriftCheck = time.Now()
+				if missing, err := cfg.DriftDetector(); err != nil {
+					log.Error(err, "drift detection failed")
+				} else if missing > 0 {
+					log.Info("drift detected, repairing routes", "missingRoutes", missing)
+					r.nodeRoutes.SetChanged()
+					bypassCache = true
+				}
 			}
-			if routes := r.nodeRoutes.GetRoutesIfChanged(); routes != nil {
-				err := updateFunc(routes)
+			if routes := r.changedRoutes(cfg, force); routes != nil && !r.isAppliedRoutes(log, cfg, routes, bypassCache) {
+				var (
+					result *updater.UpdateResult
+					err    error
+				)
+				options := updater.UpdateOptions{CreateOnly: createOnly, Force: force, Abort: ctx.Done(), KeepCIDRs: r.otherPoolRoutes.PodCIDRs()}
+				started := time.Now()
+				cleanupDeferred = cleanupDeferred || createOnly
+				if sync && cfg.SyncBatchSize > 0 && len(routes) > cfg.SyncBatchSize {
+					result, err = r.updateInBatches(ctx, log, updateFunc, routes, cfg.SyncBatchSize, cfg.SyncBatchPause, options)
+				} else {
+					result, err = updateFunc(routes, options)
+				}
+				if ctx.Err() != nil {
+					log.Info("updater loop cancelled during update", "error", err)
+					return
+				}
+				if repairPending {
+					log.Info("startup repair pass finished", "routes", len(routes), "error", err)
+					repairPending = false
+				}
+				recordOutcome(metrics.UpdateOutcomes, err)
+				recheckAt = time.Time{}
+				if result != nil && result.Recheck {
+					recheckAt = time.Now().Add(cfg.RecheckPeriod)
+				}
 				if err != nil {
 					log.Error(err, "updating routes failed")
+					metrics.UpdateErrors.Inc()
+					r.recordLastError(err)
+					r.appliedRoutes.invalidate()
 					lastFailure = time.Now()
 					if delay == 0 {
-						delay = tickPeriod
-					} else {
+						delay = cfg.TickPeriod
+					} else if delay < cfg.MaxDelayOnFailure {
 						delay = 4 * delay / 3
-						if delay > maxDelayOnFailure {
-							delay = maxDelayOnFailure
+						if delay >= cfg.MaxDelayOnFailure {
+							delay = cfg.MaxDelayOnFailure
+							log.Info("WARNING: retry delay reached maximum, updates are failing persistently", "maxDelayOnFailure", cfg.MaxDelayOnFailure)
 						}
 					}
+					if retryAfter := updater.RetryAfter(err); retryAfter > 0 {
+						// honor the delay requested by throttled AWS responses instead of the own backoff
+						delay = min(retryAfter, cfg.MaxDelayOnFailure)
+						log.Info("retry delay requested by AWS", "retryAfter", retryAfter, "delay", delay)
+					}
+					metrics.UpdateRetryDelay.WithLabelValues(cfg.ClusterName).Set(delay.Seconds())
 				} else {
 					delay = 0
+					metrics.UpdateRetryDelay.WithLabelValues(cfg.ClusterName).Set(0)
+					r.appliedRoutes.store(routes)
+					r.updateInventory(ctx, log, cfg, routes, result)
+					r.traceRouteTables(log, result)
+					r.saveRouteState(ctx, log, cfg, routes, result)
+				}
+				if !cfg.ObserveOnly && result != nil {
+					// also after a partial failure, as it only affects the nodes whose routes are not programmed
+					r.updateProgrammedNodes(ctx, log, cfg, programmedNodeRoutes(routes, result), droppedNodeRoutes(routes, result))
+				}
+				if cfg.ObserveOnly && err == nil && result != nil && result.MissingRoutes+result.ObsoleteRoutes > 0 {
+					r.reportDrift(result)
+				} else {
+					r.reportEventIfNeeded(err)
+				}
+				if sync {
+					r.saveSyncReport(ctx, log, cfg, len(routes), started, result, err)
 				}
-				r.reportEventIfNeeded(err)
 				lastUpdate = time.Now()
 			}
 			r.lastTick.Store(time.Now())
@@ -113,6 +353,162 @@ func (r *NodeReconciler) StartUpdater(ctx context.Context, updateFunc updater.No
 	}()
 }
 
+// changedRoutes returns the node routes if they have changed and the debounce window of the changes has passed.
+// Forced syncs are not debounced.
+func (r *NodeReconciler) changedRoutes(cfg UpdaterConfig, force bool) []updater.NodeRoute {
+	if !force && cfg.ReconcileDebounce > 0 && !r.nodeRoutes.Settled(cfg.ReconcileDebounce) {
+		return nil
+	}
+	return r.nodeRoutes.GetRoutesIfChanged()
+}
+
+// isAppliedRoutes returns true if the update of the changed node routes can be skipped with the reconcile cache,
+// as they are the same as applied by the last successful update, e.g. after a node has been recreated with the same pod CIDR.
+func (r *NodeReconciler) isAppliedRoutes(log logr.Logger, cfg UpdaterConfig, routes []updater.NodeRoute, bypass bool) bool {
+	if !cfg.ReconcileCache || bypass || !r.appliedRoutes.matches(routes) {
+		return false
+	}
+	log.V(1).Info("node routes unchanged since last successful update, update skipped", "routes", len(routes))
+	return true
+}
+
+// isCleanupWithoutNodes returns true if no node has been observed yet, so that no routes are deleted,
+// as they may belong to instances of the cluster not yet registered as nodes.
+func (r *NodeReconciler) isCleanupWithoutNodes(log logr.Logger, cfg UpdaterConfig) bool {
+	if cfg.CleanupWithoutNodes || r.nodesObserved.Load() {
+		return false
+	}
+	if !r.cleanupWithoutNodesLogged {
+		log.Info("no nodes observed yet, no route cleanup runs until at least one node is observed")
+		r.cleanupWithoutNodesLogged = true
+	}
+	return true
+}
+
+// ForceSync requests a full sync of all route tables with the next tick, bypassing the route table checksums
+func (r *NodeReconciler) ForceSync() {
+	r.forceSync.Store(true)
+}
+
+// programmedNodeRoutes returns the node routes present in at least one route table after the update.
+// Nodes dropped by the updater, e.g. by the instance conflict or state handling, or whose route could not be created are excluded.
+func programmedNodeRoutes(routes []updater.NodeRoute, result *updater.UpdateResult) []updater.NodeRoute {
+	if result == nil {
+		return nil
+	}
+	var programmed []updater.NodeRoute
+	for _, route := range routes {
+		if len(result.NodeRouteTables[route.NodeName]) > 0 {
+			programmed = append(programmed, route)
+		}
+	}
+	return programmed
+}
+
+// droppedNodeRoutes returns the node routes not present in any route table after the update, excluding the nodes
+// whose existing routes are kept, e.g. after a failed instance lookup
+func droppedNodeRoutes(routes []updater.NodeRoute, result *updater.UpdateResult) []updater.NodeRoute {
+	if result == nil {
+		return nil
+	}
+	var dropped []updater.NodeRoute
+	for _, route := range routes {
+		if route.NodeName != "" && len(result.NodeRouteTables[route.NodeName]) == 0 && !result.KeptNodes[route.NodeName] {
+			dropped = append(dropped, route)
+		}
+	}
+	return dropped
+}
+
+// updateProgrammedNodes updates the node conditions and taints after the routes have been programmed.
+// The condition of the nodes whose routes have been dropped is reverted.
+func (r *NodeReconciler) updateProgrammedNodes(ctx context.Context, log logr.Logger, cfg UpdaterConfig, routes, dropped []updater.NodeRoute) {
+	patchRetry := cfg.nodePatchRetry()
+	if cfg.NodeConditionType != "" {
+		if err := r.updateNodeConditions(ctx, cfg.NodeConditionType, &patchRetry, routes, dropped); err != nil {
+			log.Error(err, "updating node conditions failed")
+		}
+	}
+	if cfg.RemoveTaint != "" {
+		if err := r.removeNodeTaints(ctx, cfg.RemoveTaint, &patchRetry, routes); err != nil {
+			log.Error(err, "removing node taints failed")
+		}
+	}
+}
+
+// loadInventory seeds the inventory from the store
+func (r *NodeReconciler) loadInventory(ctx context.Context, log logr.Logger, cfg UpdaterConfig) {
+	if cfg.Inventory == nil || cfg.InventoryStore == nil {
+		return
+	}
+	entries, err := cfg.InventoryStore.Load(ctx)
+	if err != nil {
+		log.Error(err, "loading inventory failed")
+		return
+	}
+	cfg.Inventory.Update(entries)
+}
+
+// updateInventory updates the inventory with the node routes and their route tables and persists it if changed
+func (r *NodeReconciler) updateInventory(ctx context.Context, log logr.Logger, cfg UpdaterConfig, routes []updater.NodeRoute, result *updater.UpdateResult) {
+	if cfg.Inventory == nil || result == nil {
+		return
+	}
+	entries := make([]inventory.Entry, 0, len(routes))
+	for _, route := range routes {
+		entries = append(entries, inventory.Entry{
+			NodeName:    route.NodeName,
+			PodCIDR:     route.PodCIDR,
+			InstanceID:  route.InstanceID,
+			RouteTables: result.RouteTables[route.PodCIDR],
+		})
+	}
+	if !cfg.Inventory.Update(entries) || cfg.InventoryStore == nil {
+		return
+	}
+	if err := cfg.InventoryStore.Save(ctx, cfg.Inventory.Entries()); err != nil {
+		log.Error(err, "saving inventory failed")
+	}
+}
+
+// updateInBatches creates the missing routes in batches of limited size and finally
+// performs an update with all routes and the given options to clean up obsolete routes.
+// Only the first batch and the final update describe the route tables, the other batches reuse them.
+func (r *NodeReconciler) updateInBatches(ctx context.Context, log logr.Logger, updateFunc updater.NodeRoutesUpdater,
+	routes []updater.NodeRoute, batchSize int, pause time.Duration, options updater.UpdateOptions) (*updater.UpdateResult, error) {
+	sort.Slice(routes, func(i, j int) bool { return routes[i].PodCIDR < routes[j].PodCIDR })
+	batches := (len(routes) + batchSize - 1) / batchSize
+	var (
+		updateErrors    error
+		created, failed int
+	)
+	for i := 0; i < batches; i++ {
+		batch := routes[i*batchSize : min((i+1)*batchSize, len(routes))]
+		batchResult, err := updateFunc(batch, updater.UpdateOptions{CreateOnly: true, Abort: options.Abort, ReuseRouteTables: i > 0})
+		if err != nil {
+			updateErrors = multierr.Append(updateErrors, err)
+		}
+		if batchResult != nil {
+			created += batchResult.Created
+			failed += batchResult.Failed
+		}
+		r.lastTick.Store(time.Now())
+		r.heartbeat.Store(time.Now())
+		log.Info("sync batch processed", "batch", i+1, "batches", batches, "routes", len(batch))
+		select {
+		case <-ctx.Done():
+			return nil, multierr.Append(updateErrors, ctx.Err())
+		case <-time.After(pause):
+		}
+	}
+	result, err := updateFunc(routes, options)
+	if result != nil {
+		result.Created += created
+		result.Failed += failed
+	}
+	return result, multierr.Append(updateErrors, err)
+}
+
 func (r *NodeReconciler) reportEventIfNeeded(err error) {
 	isOk := err == nil
 	if isOk && r.lastEventOk {
@@ -120,43 +516,96 @@ func (r *NodeReconciler) reportEventIfNeeded(err error) {
 		return
 	}
 
-	// An object is needed this event is about.
-	// As the aws-custom-route-controller has not many objects in the shoot cluster, just use its ServiceAccount.
-	ref := &corev1.ObjectReference{
-		Kind:       "ServiceAccount",
-		APIVersion: "v1",
-		Namespace:  metav1.NamespaceSystem,
-		Name:       "aws-custom-route-controller",
-	}
-	if isOk {
-		r.recorder.Event(ref, corev1.EventTypeNormal, "RoutesUpToDate", "routes for all route tables are up-to-date")
-	} else {
-		msg := err.Error()
+	ref := controllerEventRef()
+	eventType, reason, msg := corev1.EventTypeNormal, "RoutesUpToDate", "routes for all route tables are up-to-date"
+	if !isOk {
+		eventType, reason, msg = corev1.EventTypeWarning, "RoutesUpdateFailed", err.Error()
 		if len(msg) > 300 {
 			msg = msg[:300] + "..."
 		}
-		r.recorder.Event(ref, corev1.EventTypeWarning, "RoutesUpdateFailed", msg)
+	}
+	r.recorder.Event(ref, eventType, reason, msg)
+	if r.controlRecorder != nil {
+		r.controlRecorder.Event(r.controlRef, eventType, reason, msg)
 	}
 	r.lastEventOk = isOk
 }
 
+// reportDrift emits a warning event about the differences found in observe mode, and an event on each node
+// describing the route changes which would be applied for it
+func (r *NodeReconciler) reportDrift(result *updater.UpdateResult) {
+	msg := fmt.Sprintf("routes drifted from desired state: %d missing, %d obsolete", result.MissingRoutes, result.ObsoleteRoutes)
+	r.recorder.Event(controllerEventRef(), corev1.EventTypeWarning, "RoutesDrifted", msg)
+	if r.controlRecorder != nil {
+		r.controlRecorder.Event(r.controlRef, corev1.EventTypeWarning, "RoutesDrifted", msg)
+	}
+	r.lastEventOk = false
+	nodeNames := make([]string, 0, len(result.NodeDrift))
+	for nodeName := range result.NodeDrift {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	for _, nodeName := range nodeNames {
+		r.recorder.Event(nodeEventRef(nodeName), corev1.EventTypeWarning, "RouteDrifted", strings.Join(result.NodeDrift[nodeName], "; "))
+	}
+}
+
+// nodeEventRef returns the reference of the node for events. Like for the events of the kubelet, the UID is the
+// node name, as kubectl describe node looks up the events of the node by it.
+func nodeEventRef(nodeName string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:       "Node",
+		APIVersion: "v1",
+		Name:       nodeName,
+		UID:        types.UID(nodeName),
+	}
+}
+
+// controllerEventRef returns the object the controller events are about.
+// As the aws-custom-route-controller has not many objects in the shoot cluster, just use its ServiceAccount.
+func controllerEventRef() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:       "ServiceAccount",
+		APIVersion: "v1",
+		Namespace:  metav1.NamespaceSystem,
+		Name:       "aws-custom-route-controller",
+	}
+}
+
 // Reconcile extracts pod cidrs from nodes
 func (r *NodeReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	defer metrics.ObserveReconcileLatency(time.Now())
+	defer metrics.LastReconcileTimestamp.SetToCurrentTime()
 	if r.initialiseStarted.CompareAndSwap(false, true) {
 		r.initialise(ctx)
 	}
+	ctx, cancel := r.withReconcileTimeout(ctx)
+	defer cancel()
 
 	node := &corev1.Node{}
 	err := r.client.Get(ctx, req.NamespacedName, node)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			r.removeNodeRoute(req.Name)
+			r.otherPoolRoutes.RemoveNodeRoute(req.Name)
+			recordOutcome(metrics.ReconcileOutcomes, nil)
 			return reconcile.Result{}, nil
 		}
-		return reconcile.Result{}, err
+		recordOutcome(metrics.ReconcileOutcomes, err)
+		return reconcile.Result{}, r.reconcileError(ctx, req.Name, err)
+	}
+	r.nodesObserved.Store(true)
+	if r.isForeignNode(node) {
+		recordOutcome(metrics.ReconcileOutcomes, nil)
+		return reconcile.Result{}, nil
+	}
+
+	if node, err = r.withProviderPodCIDRs(ctx, node); err != nil {
+		recordOutcome(metrics.ReconcileOutcomes, err)
+		return reconcile.Result{}, r.reconcileError(ctx, req.Name, err)
 	}
 
-	r.addNodeRoute(node)
+	metrics.ReconcileOutcomes.WithLabelValues(r.addNodeRoute(node)).Inc()
 
 	return reconcile.Result{}, nil
 }
@@ -187,6 +636,17 @@ func (r *NodeReconciler) HealthzChecker(_ *http.Request) error {
 	return nil
 }
 
+// LivenessChecker fails if the updater loop has not bumped its heartbeat within the liveness threshold, e.g. because it is stuck
+func (r *NodeReconciler) LivenessChecker(_ *http.Request) error {
+	if !r.updaterStarted.Load() || r.livenessThreshold == 0 {
+		return nil
+	}
+	if age := time.Since(r.heartbeat.Load()); age > r.livenessThreshold {
+		return fmt.Errorf("updater heartbeat is stale for %s", age.Round(time.Second))
+	}
+	return nil
+}
+
 func (r *NodeReconciler) initialise(ctx context.Context) {
 	r.log.Info("initialise started")
 	nodeList := &corev1.NodeList{}
@@ -194,17 +654,70 @@ func (r *NodeReconciler) initialise(ctx context.Context) {
 		r.log.Error(err, "listing nodes failed")
 		panic(err) // to avoid cleaning routing table
 	}
-	for _, node := range nodeList.Items {
-		r.addNodeRoute(&node)
+	if len(nodeList.Items) > 0 {
+		r.nodesObserved.Store(true)
+	}
+	for i := range nodeList.Items {
+		if r.isForeignNode(&nodeList.Items[i]) {
+			continue
+		}
+		node, err := r.withProviderPodCIDRs(ctx, &nodeList.Items[i])
+		if err != nil {
+			r.log.Error(err, "getting pod CIDRs failed", "node", nodeList.Items[i].Name)
+			panic(err) // to avoid cleaning routing table
+		}
+		r.addNodeRoute(node)
 	}
 	r.initialiseFinished.Store(true)
 	r.log.Info("initialise finished")
 }
 
-func (r *NodeReconciler) addNodeRoute(node *corev1.Node) {
-	if route, changed := r.nodeRoutes.AddNodeRoute(node); changed {
+// isForeignNode returns true if the node has the provider ID of another cloud. Its route is removed, as it is not managed by the controller.
+func (r *NodeReconciler) isForeignNode(node *corev1.Node) bool {
+	if !updater.IsForeignProviderID(node.Spec.ProviderID) {
+		return false
+	}
+	r.log.V(1).Info("node skipped, provider ID of other cloud", "node", node.Name, "providerID", node.Spec.ProviderID)
+	r.removeNodeRoute(node.Name)
+	r.otherPoolRoutes.RemoveNodeRoute(node.Name)
+	return true
+}
+
+// addNodeRoute adds or updates the route of the node and returns the reason of the outcome
+func (r *NodeReconciler) addNodeRoute(node *corev1.Node) string {
+	if r.workerPool != nil {
+		if !r.workerPool.contains(node) {
+			r.addOtherPoolNodeRoute(node)
+			return ReasonSuccess
+		}
+		r.otherPoolRoutes.RemoveNodeRoute(node.Name)
+	}
+	if instanceID := updater.NodeInstanceID(node); instanceID != "" && !updater.IsValidInstanceID(instanceID) {
+		r.log.Info("WARNING: node skipped, malformed instance ID in provider ID", "node", node.Name, "providerID", node.Spec.ProviderID)
+		r.recorder.Eventf(node, corev1.EventTypeWarning, "InvalidInstanceID", "node skipped, malformed instance ID %q in provider ID", instanceID)
+		metrics.InvalidInstanceIDs.Inc()
+		r.removeNodeRoute(node.Name)
+		return ReasonInstanceIDInvalid
+	}
+	if r.isTerminatingNodeRouteRemoved(node) {
+		r.log.V(1).Info("node terminating, route removed", "node", node.Name)
+		r.removeNodeRoute(node.Name)
+		return ReasonSuccess
+	}
+	reason := ReasonSuccess
+	if _, err := util.GetIPv4CIDR(updater.NodePodCIDRs(node)); err != nil {
+		r.log.Info("WARNING: invalid pod CIDR of node", "node", node.Name, "podCIDRs", updater.NodePodCIDRs(node), "error", err.Error())
+		reason = ReasonCIDRInvalid
+	}
+	route, changed := r.nodeRoutes.AddNodeRoute(node)
+	if route == nil {
+		r.log.V(1).Info("node skipped, no IPv4 pod CIDR or AWS instance ID", "node", node.Name, "podCIDRs", updater.NodePodCIDRs(node), "providerID", node.Spec.ProviderID)
+		return reason
+	}
+	if changed {
 		r.log.Info("added node route", "node", node.Name, "podCIDR", route.PodCIDR, "instanceID", route.InstanceID)
 	}
+	return reason
 }
 
 func (r *NodeReconciler) removeNodeRoute(nodeName string) {
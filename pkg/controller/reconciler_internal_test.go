@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("programmed node routes", func() {
+	routes := []updater.NodeRoute{
+		{NodeName: "node0", PodCIDR: "10.0.0.0/24", InstanceID: "i-0000"},
+		{NodeName: "node1", PodCIDR: "10.0.1.0/24", InstanceID: "i-0001"},
+		{NodeName: "node2", PodCIDR: "10.0.2.0/24", InstanceID: "i-0002"},
+		{NodeName: "node3", PodCIDR: "10.0.3.0/24", InstanceID: "i-0003"},
+	}
+	// the route of node1 could not be created and the lookup of node3 failed, keeping its existing route
+	result := &updater.UpdateResult{
+		NodeRouteTables: map[string][]string{
+			"node0": {"rtb-1", "rtb-2"},
+			"node2": {"rtb-1"},
+		},
+		KeptNodes: map[string]bool{"node3": true},
+		Failed:    1,
+	}
+
+	It("should only return the nodes with a route in a route table after a partial failure", func() {
+		Expect(programmedNodeRoutes(routes, result)).To(Equal([]updater.NodeRoute{routes[0], routes[2]}))
+	})
+
+	It("should return the nodes whose routes are dropped, excluding the kept ones", func() {
+		Expect(droppedNodeRoutes(routes, result)).To(Equal([]updater.NodeRoute{routes[1]}))
+	})
+
+	It("should return no nodes without result", func() {
+		Expect(programmedNodeRoutes(routes, nil)).To(BeNil())
+		Expect(droppedNodeRoutes(routes, nil)).To(BeNil())
+	})
+})
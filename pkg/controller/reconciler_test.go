@@ -0,0 +1,1273 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/gardener/aws-custom-route-controller/pkg/inventory"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	ec2fake "github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type updateCall struct {
+	routes  []updater.NodeRoute
+	options updater.UpdateOptions
+}
+
+type fakeUpdater struct {
+	sync.Mutex
+	calls       []updateCall
+	routeTables map[string][]string
+	// unprogrammed are the nodes left without route by the update
+	unprogrammed map[string]bool
+	err          error
+}
+
+func (u *fakeUpdater) setErr(err error) {
+	u.Lock()
+	defer u.Unlock()
+	u.err = err
+}
+
+func (u *fakeUpdater) setUnprogrammed(unprogrammed map[string]bool) {
+	u.Lock()
+	defer u.Unlock()
+	u.unprogrammed = unprogrammed
+}
+
+func (u *fakeUpdater) update(routes []updater.NodeRoute, options updater.UpdateOptions) (*updater.UpdateResult, error) {
+	u.Lock()
+	defer u.Unlock()
+	u.calls = append(u.calls, updateCall{routes: append([]updater.NodeRoute{}, routes...), options: options})
+	nodeRouteTables := map[string][]string{}
+	for _, route := range routes {
+		if route.NodeName != "" && !u.unprogrammed[route.NodeName] {
+			nodeRouteTables[route.NodeName] = []string{"rtb-fake"}
+		}
+	}
+	return &updater.UpdateResult{RouteTables: u.routeTables, NodeRouteTables: nodeRouteTables, Created: len(routes)}, u.err
+}
+
+func (u *fakeUpdater) getCalls() []updateCall {
+	u.Lock()
+	defer u.Unlock()
+	return append([]updateCall{}, u.calls...)
+}
+
+// failingLookupEC2 fails the instance lookups including the instance
+type failingLookupEC2 struct {
+	*ec2fake.EC2
+	instanceID string
+}
+
+func (f *failingLookupEC2) DescribeInstances(request *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	for _, filter := range request.Filters {
+		for _, value := range filter.Values {
+			if aws.StringValue(value) == f.instanceID {
+				return nil, awserr.New("InternalError", "lookup failed", nil)
+			}
+		}
+	}
+	return f.EC2.DescribeInstances(request)
+}
+
+// blockingEC2 blocks the route creation until released, like a hanging AWS call
+type blockingEC2 struct {
+	*ec2fake.EC2
+	release chan struct{}
+}
+
+func (f *blockingEC2) CreateRoute(request *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+	<-f.release
+	return f.EC2.CreateRoute(request)
+}
+
+func makeNode(name, instanceID, podCIDR string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.NodeSpec{
+			PodCIDRs:   []string{podCIDR},
+			ProviderID: "aws:///eu-west-1a/" + instanceID,
+		},
+	}
+}
+
+var _ = Describe("NodeReconciler", func() {
+	var (
+		ctx        context.Context
+		cancel     context.CancelFunc
+		elected    chan struct{}
+		reconciler *controller.NodeReconciler
+		fakeUpd    *fakeUpdater
+		c          client.Client
+	)
+
+	logf.SetLogger(zap.New())
+
+	getCondition := func(nodeName string, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+		node := &corev1.Node{}
+		Expect(c.Get(ctx, client.ObjectKey{Name: nodeName}, node)).To(Succeed())
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == conditionType {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	newReconciler := func(nodes ...client.Object) {
+		c = fake.NewClientBuilder().WithObjects(nodes...).WithStatusSubresource(&corev1.Node{}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		elected = make(chan struct{})
+		close(elected)
+		fakeUpd = &fakeUpdater{}
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should update the last reconcile timestamp on every reconcile", func() {
+		newReconciler(makeNode("node1", "i-0001", "10.0.1.0/24"))
+		metrics.LastReconcileTimestamp.Set(0)
+		before := float64(time.Now().Unix())
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.LastReconcileTimestamp)).To(BeNumerically(">=", before))
+
+		// also for nodes not found, without any route update
+		metrics.LastReconcileTimestamp.Set(0)
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "missing"}})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.LastReconcileTimestamp)).To(BeNumerically(">=", before))
+	})
+
+	It("should process a full sync in batches", func() {
+		var nodes []client.Object
+		for i := 0; i < 5; i++ {
+			nodes = append(nodes, makeNode(fmt.Sprintf("node%d", i), fmt.Sprintf("i-%04d", i), fmt.Sprintf("10.0.%d.0/24", i)))
+		}
+		newReconciler(nodes...)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			SyncBatchSize:     2,
+			SyncBatchPause:    time.Millisecond,
+		})
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(4))
+		calls := fakeUpd.getCalls()
+		for i, size := range []int{2, 2, 1} {
+			Expect(calls[i].routes).To(HaveLen(size))
+			Expect(calls[i].options.CreateOnly).To(BeTrue())
+			Expect(calls[i].options.ReuseRouteTables).To(Equal(i > 0))
+		}
+		Expect(calls[3].routes).To(HaveLen(5))
+		Expect(calls[3].options.CreateOnly).To(BeFalse())
+		Expect(calls[3].options.ReuseRouteTables).To(BeFalse())
+		Consistently(func() int { return len(fakeUpd.getCalls()) }, 100*time.Millisecond).Should(Equal(4))
+	})
+
+	It("should only describe the route tables for the first batch and the final update of a full sync", func() {
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		var nodes []client.Object
+		for i := 0; i < 5; i++ {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(fmt.Sprintf("i-%04d", i))})
+			nodes = append(nodes, makeNode(fmt.Sprintf("node%d", i), fmt.Sprintf("i-%04d", i), fmt.Sprintf("10.0.%d.0/24", i)))
+		}
+		newReconciler(nodes...)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			SyncBatchSize:     2,
+			SyncBatchPause:    time.Millisecond,
+		})
+
+		Eventually(func() int { return cloud.Calls("CreateRoute") }).Should(Equal(5))
+		Eventually(func() int { return cloud.Calls("DescribeRouteTables") }).Should(Equal(2))
+		Consistently(func() int { return cloud.Calls("DescribeRouteTables") }, 100*time.Millisecond).Should(Equal(2))
+		Expect(cloud.Calls("DeleteRoute")).To(BeZero())
+	})
+
+	It("should not use batches if sync batch size is not set", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].routes).To(HaveLen(2))
+	})
+
+	It("should set the NetworkUnavailable condition after programming the routes", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+		})
+
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		condition := getCondition("node0", corev1.NodeNetworkUnavailable)
+		Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("RouteCreated"))
+	})
+
+	It("should only set the condition and remove the taint of nodes with a programmed route", func() {
+		nodes := []client.Object{makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24")}
+		for _, node := range nodes {
+			node.(*corev1.Node).Spec.Taints = []corev1.Taint{{Key: "uninitialized", Effect: corev1.TaintEffectNoSchedule}}
+		}
+		newReconciler(nodes...)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		// the route of node1 has been dropped by the updater, e.g. as it lost an instance conflict
+		fakeUpd.unprogrammed = map[string]bool{"node1": true}
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+			RemoveTaint:       "uninitialized",
+		})
+
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		Expect(fakeUpd.getCalls()[0].routes).To(HaveLen(2))
+		Expect(getCondition("node1", corev1.NodeNetworkUnavailable)).To(BeNil())
+		node := &corev1.Node{}
+		Expect(c.Get(ctx, client.ObjectKey{Name: "node1"}, node)).To(Succeed())
+		Expect(node.Spec.Taints).To(HaveLen(1))
+	})
+
+	It("should set the condition of the programmed nodes if the update fails partially", func() {
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		var nodes []client.Object
+		for i := 0; i < 3; i++ {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(fmt.Sprintf("i-%04d", i)), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}})
+			nodes = append(nodes, makeNode(fmt.Sprintf("node%d", i), fmt.Sprintf("i-%04d", i), fmt.Sprintf("10.0.%d.0/24", i)))
+		}
+		newReconciler(nodes...)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), &failingLookupEC2{EC2: cloud, instanceID: "i-0001"}, "test", "10.0.0.0/16", updater.CustomRoutesOptions{
+			InstanceLifecyclePolicy: updater.InstanceLifecyclePolicyStateAware,
+		})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+		})
+
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		Eventually(func() *corev1.NodeCondition { return getCondition("node2", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		Consistently(func() *corev1.NodeCondition { return getCondition("node1", corev1.NodeNetworkUnavailable) }, 100*time.Millisecond).Should(BeNil())
+	})
+
+	It("should remove the taint of the programmed nodes if the update fails partially", func() {
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		var nodes []client.Object
+		for i := 0; i < 3; i++ {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(fmt.Sprintf("i-%04d", i)), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}})
+			node := makeNode(fmt.Sprintf("node%d", i), fmt.Sprintf("i-%04d", i), fmt.Sprintf("10.0.%d.0/24", i))
+			node.Spec.Taints = []corev1.Taint{{Key: "uninitialized", Effect: corev1.TaintEffectNoSchedule}}
+			nodes = append(nodes, node)
+		}
+		newReconciler(nodes...)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), &failingLookupEC2{EC2: cloud, instanceID: "i-0001"}, "test", "10.0.0.0/16", updater.CustomRoutesOptions{
+			InstanceLifecyclePolicy: updater.InstanceLifecyclePolicyStateAware,
+		})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			RemoveTaint:       "uninitialized",
+		})
+
+		taints := func(nodeName string) []corev1.Taint {
+			node := &corev1.Node{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: nodeName}, node)).To(Succeed())
+			return node.Spec.Taints
+		}
+		Eventually(func() []corev1.Taint { return taints("node0") }).Should(BeEmpty())
+		Eventually(func() []corev1.Taint { return taints("node2") }).Should(BeEmpty())
+		Consistently(func() []corev1.Taint { return taints("node1") }, 100*time.Millisecond).Should(HaveLen(1))
+	})
+
+	It("should revert the condition set by the controller if the route of a node is dropped", func() {
+		nodes := []client.Object{makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24")}
+		// the condition of node1 is set by the CNI
+		nodes[1].(*corev1.Node).Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeNetworkUnavailable, Status: corev1.ConditionFalse, Reason: "CalicoIsUp"}}
+		newReconciler(nodes...)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+		})
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+
+		// the routes of both nodes are dropped, e.g. as their instances have been stopped
+		fakeUpd.setUnprogrammed(map[string]bool{"node0": true, "node1": true})
+		reconciler.ForceSync()
+		Eventually(func() corev1.ConditionStatus { return getCondition("node0", corev1.NodeNetworkUnavailable).Status }).Should(Equal(corev1.ConditionTrue))
+		Expect(getCondition("node0", corev1.NodeNetworkUnavailable).Reason).To(Equal("NoRouteCreated"))
+		Consistently(func() string { return getCondition("node1", corev1.NodeNetworkUnavailable).Reason }, 100*time.Millisecond).Should(Equal("CalicoIsUp"))
+
+		fakeUpd.setUnprogrammed(nil)
+		reconciler.ForceSync()
+		Eventually(func() corev1.ConditionStatus { return getCondition("node0", corev1.NodeNetworkUnavailable).Status }).Should(Equal(corev1.ConditionFalse))
+	})
+
+	It("should only report drift in observe mode", func() {
+		recorder := record.NewFakeRecorder(10)
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).WithStatusSubresource(&corev1.Node{}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, recorder)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		observe := func(routes []updater.NodeRoute, options updater.UpdateOptions) (*updater.UpdateResult, error) {
+			return &updater.UpdateResult{MissingRoutes: len(routes), ObsoleteRoutes: 2, NodeDrift: map[string][]string{
+				"node0": {"route 10.0.0.0/24 -> i-0000 would be created in table rtb-0001", "route 10.0.0.0/24 -> i-0000 would be created in table rtb-0002"},
+			}}, nil
+		}
+		reconciler.StartUpdater(ctx, observe, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+			ObserveOnly:       true,
+		})
+
+		Eventually(recorder.Events).Should(Receive(Equal("Warning RoutesDrifted routes drifted from desired state: 1 missing, 2 obsolete")))
+		Eventually(recorder.Events).Should(Receive(Equal("Warning RouteDrifted route 10.0.0.0/24 -> i-0000 would be created in table rtb-0001; " +
+			"route 10.0.0.0/24 -> i-0000 would be created in table rtb-0002")))
+		Expect(getCondition("node0", corev1.NodeNetworkUnavailable)).To(BeNil())
+	})
+
+	It("should abort the running update and stop the updater when the leadership is lost", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		var (
+			lost    = make(chan struct{})
+			started = make(chan struct{})
+			calls   atomic.Int32
+		)
+		longUpdate := func(_ []updater.NodeRoute, options updater.UpdateOptions) (*updater.UpdateResult, error) {
+			calls.Add(1)
+			close(started)
+			<-options.Abort
+			return &updater.UpdateResult{}, updater.ErrUpdateAborted
+		}
+		reconciler.StartUpdater(ctx, longUpdate, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        20 * time.Millisecond,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+			LeadershipLost:    lost,
+		})
+
+		Eventually(started).Should(BeClosed())
+		close(lost)
+		Consistently(calls.Load, 100*time.Millisecond).Should(Equal(int32(1)))
+		Expect(getCondition("node0", corev1.NodeNetworkUnavailable)).To(BeNil())
+		Eventually(func() error { return reconciler.HealthzChecker(nil) }).Should(MatchError("missing tick"))
+	})
+
+	It("should fail the reconcile of a node if it exceeds the reconcile timeout", func() {
+		node := makeNode("node0", "i-0000", "10.0.0.0/24")
+		c = fake.NewClientBuilder().WithObjects(node).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, cl client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				// a hanging API call only returns when its context is done
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		reconciler.SetReconcileTimeout(50 * time.Millisecond)
+
+		start := time.Now()
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(MatchError(ContainSubstring("reconcile of node node0 timed out after 50ms")))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	It("should fail an update exceeding the reconcile timeout while an AWS call hangs", func() {
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0000")})
+		blocking := &blockingEC2{EC2: cloud, release: make(chan struct{})}
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).WithStatusSubresource(&corev1.Node{}).Build()
+		recorder := record.NewFakeRecorder(100)
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, recorder)
+		reconciler.SetReconcileTimeout(50 * time.Millisecond)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), blocking, "test", "10.0.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: 20 * time.Millisecond,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+		})
+
+		var event string
+		Eventually(recorder.Events, time.Second).Should(Receive(&event))
+		Expect(event).To(ContainSubstring("route update timed out after 50ms"))
+		Eventually(recorder.Events, time.Second).Should(Receive(ContainSubstring("timed out route update still running")))
+		Expect(getCondition("node0", corev1.NodeNetworkUnavailable)).To(BeNil())
+
+		close(blocking.release)
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+	})
+
+	It("should retry patching the node condition and taint on conflicts", func() {
+		node := makeNode("node0", "i-0000", "10.0.0.0/24")
+		node.Spec.Taints = []corev1.Taint{{Key: "uninitialized", Effect: corev1.TaintEffectNoSchedule}}
+		var statusConflicts, taintConflicts atomic.Int32
+		conflict := apierrors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node0", fmt.Errorf("object has been modified"))
+		c = fake.NewClientBuilder().WithObjects(node).WithStatusSubresource(&corev1.Node{}).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if taintConflicts.Add(1) == 1 {
+					return conflict
+				}
+				return cl.Patch(ctx, obj, patch, opts...)
+			},
+			SubResourcePatch: func(ctx context.Context, cl client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if statusConflicts.Add(1) == 1 {
+					return conflict
+				}
+				return cl.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:          10 * time.Millisecond,
+			SyncPeriod:          time.Hour,
+			MaxDelayOnFailure:   time.Second,
+			NodeConditionType:   corev1.NodeNetworkUnavailable,
+			RemoveTaint:         "uninitialized",
+			NodeConflictRetries: 3,
+		})
+
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		Eventually(func() []corev1.Taint {
+			node := &corev1.Node{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: "node0"}, node)).To(Succeed())
+			return node.Spec.Taints
+		}).Should(BeEmpty())
+		Expect(statusConflicts.Load()).To(Equal(int32(2)))
+		Expect(taintConflicts.Load()).To(Equal(int32(2)))
+		Expect(fakeUpd.getCalls()).To(HaveLen(1))
+	})
+
+	It("should retry patching the node condition and taint on transient API errors", func() {
+		node := makeNode("node0", "i-0000", "10.0.0.0/24")
+		node.Spec.Taints = []corev1.Taint{{Key: "uninitialized", Effect: corev1.TaintEffectNoSchedule}}
+		var statusPatches, taintPatches atomic.Int32
+		c = fake.NewClientBuilder().WithObjects(node).WithStatusSubresource(&corev1.Node{}).WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if taintPatches.Add(1) <= 2 {
+					return apierrors.NewServiceUnavailable("etcd leader changed")
+				}
+				return cl.Patch(ctx, obj, patch, opts...)
+			},
+			SubResourcePatch: func(ctx context.Context, cl client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if statusPatches.Add(1) <= 2 {
+					return apierrors.NewTooManyRequests("throttled", 0)
+				}
+				return cl.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+			RemoveTaint:       "uninitialized",
+			NodePatchRetries:  3,
+			NodePatchMaxDelay: 500 * time.Millisecond,
+		})
+
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", corev1.NodeNetworkUnavailable) }).ShouldNot(BeNil())
+		Eventually(func() []corev1.Taint {
+			node := &corev1.Node{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: "node0"}, node)).To(Succeed())
+			return node.Spec.Taints
+		}).Should(BeEmpty())
+		Expect(statusPatches.Load()).To(Equal(int32(3)))
+		Expect(taintPatches.Load()).To(Equal(int32(3)))
+		Expect(fakeUpd.getCalls()).To(HaveLen(1))
+	})
+
+	It("should give up patching the node condition after the transient retries", func() {
+		var statusPatches atomic.Int32
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).WithStatusSubresource(&corev1.Node{}).WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(_ context.Context, _ client.Client, _ string, _ client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+				statusPatches.Add(1)
+				return apierrors.NewInternalError(fmt.Errorf("storage unavailable"))
+			},
+		}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+			NodePatchRetries:  2,
+		})
+
+		Eventually(func() int32 { return statusPatches.Load() }).Should(Equal(int32(2)))
+		Consistently(func() int32 { return statusPatches.Load() }, 500*time.Millisecond).Should(Equal(int32(2)))
+		Expect(fakeUpd.getCalls()).To(HaveLen(1))
+	})
+
+	It("should only retry the first node patch of an update failing transiently", func() {
+		var statusPatches atomic.Int32
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24")).
+			WithStatusSubresource(&corev1.Node{}).WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(_ context.Context, _ client.Client, _ string, _ client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+				statusPatches.Add(1)
+				return apierrors.NewServiceUnavailable("apiserver unavailable")
+			},
+		}).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		for _, name := range []string{"node0", "node1"} {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+			Expect(err).To(BeNil())
+		}
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: corev1.NodeNetworkUnavailable,
+			NodePatchRetries:  3,
+		})
+
+		// three attempts for the first node, a single one for the second
+		Eventually(func() int32 { return statusPatches.Load() }).Should(Equal(int32(4)))
+		Consistently(func() int32 { return statusPatches.Load() }, 500*time.Millisecond).Should(Equal(int32(4)))
+		Expect(fakeUpd.getCalls()).To(HaveLen(1))
+	})
+
+	It("should only manage the nodes of its worker pool and keep the routes of other pools", func() {
+		var nodes []client.Object
+		for i, pool := range []string{"a", "b"} {
+			node := makeNode("node-"+pool, fmt.Sprintf("i-000%d", i), fmt.Sprintf("10.0.%d.0/24", i))
+			node.Labels = map[string]string{"worker.gardener.cloud/pool": pool}
+			nodes = append(nodes, node)
+		}
+		c = fake.NewClientBuilder().WithObjects(nodes...).WithStatusSubresource(&corev1.Node{}).Build()
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0000")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+
+		var reconcilers []*controller.NodeReconciler
+		for _, pool := range []string{"a", "b"} {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName(pool), cloud, "test", "10.0.0.0/16", updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+			r := controller.NewNodeReconciler(c, logf.Log.WithName(pool), elected, record.NewFakeRecorder(100))
+			r.SetWorkerPool(controller.WorkerPool{Label: "worker.gardener.cloud/pool", Value: pool})
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node-a"}})
+			Expect(err).To(BeNil())
+			r.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+				TickPeriod:        10 * time.Millisecond,
+				SyncPeriod:        20 * time.Millisecond,
+				MaxDelayOnFailure: time.Second,
+			})
+			reconcilers = append(reconcilers, r)
+		}
+
+		routes := func() map[string]string {
+			routes := map[string]string{}
+			for _, route := range cloud.RouteTable("rtb-0001").Routes {
+				routes[aws.StringValue(route.DestinationCidrBlock)] = aws.StringValue(route.InstanceId)
+			}
+			return routes
+		}
+		expected := map[string]string{"10.0.0.0/24": "i-0000", "10.0.1.0/24": "i-0001"}
+		Eventually(routes).Should(Equal(expected))
+		Consistently(routes, 100*time.Millisecond).Should(Equal(expected))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(2))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(0))
+
+		Expect(c.Delete(ctx, nodes[1])).To(Succeed())
+		for _, r := range reconcilers {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node-b"}})
+			Expect(err).To(BeNil())
+		}
+		Eventually(routes).Should(Equal(map[string]string{"10.0.0.0/24": "i-0000"}))
+	})
+
+	DescribeTable("should handle terminating nodes according to the policy",
+		func(policy string, expectedNodes []string) {
+			terminating := makeNode("node1", "i-0001", "10.0.1.0/24")
+			terminating.Finalizers = []string{"example.com/finalizer"}
+			terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), terminating)
+			Expect(reconciler.SetTerminatingNodeRoutePolicy(policy)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+			Expect(err).To(BeNil())
+
+			reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+				TickPeriod:        10 * time.Millisecond,
+				SyncPeriod:        time.Hour,
+				MaxDelayOnFailure: time.Second,
+			})
+
+			Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+			var nodes []string
+			for _, route := range fakeUpd.getCalls()[0].routes {
+				nodes = append(nodes, route.NodeName)
+			}
+			Expect(nodes).To(ConsistOf(expectedNodes))
+		},
+		Entry("keep by default", "", []string{"node0", "node1"}),
+		Entry("keep", controller.TerminatingNodeRoutePolicyKeep, []string{"node0", "node1"}),
+		Entry("remove", controller.TerminatingNodeRoutePolicyRemove, []string{"node0"}),
+	)
+
+	It("should reject an invalid terminating node route policy", func() {
+		newReconciler()
+		Expect(reconciler.SetTerminatingNodeRoutePolicy("delete")).To(MatchError(ContainSubstring("invalid terminating node route policy")))
+	})
+
+	It("should set a custom condition type after programming the routes", func() {
+		node := makeNode("node0", "i-0000", "10.0.0.0/24")
+		node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		newReconciler(node)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			NodeConditionType: "RouteProgrammed",
+		})
+
+		Eventually(func() *corev1.NodeCondition { return getCondition("node0", "RouteProgrammed") }).ShouldNot(BeNil())
+		Expect(getCondition("node0", "RouteProgrammed").Status).To(Equal(corev1.ConditionTrue))
+		Expect(getCondition("node0", corev1.NodeReady)).NotTo(BeNil())
+		Expect(getCondition("node0", corev1.NodeNetworkUnavailable)).To(BeNil())
+	})
+
+	It("should remove the taint after programming the routes", func() {
+		node := makeNode("node0", "i-0000", "10.0.0.0/24")
+		node.Spec.Taints = []corev1.Taint{
+			{Key: "node.cloudprovider.kubernetes.io/uninitialized", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "other", Effect: corev1.TaintEffectNoSchedule},
+		}
+		newReconciler(node, makeNode("node1", "i-0001", "10.0.1.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			RemoveTaint:       "node.cloudprovider.kubernetes.io/uninitialized",
+		})
+
+		Eventually(func() []corev1.Taint {
+			node := &corev1.Node{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: "node0"}, node)).To(Succeed())
+			return node.Spec.Taints
+		}).Should(Equal([]corev1.Taint{{Key: "other", Effect: corev1.TaintEffectNoSchedule}}))
+
+		node1 := &corev1.Node{}
+		Expect(c.Get(ctx, client.ObjectKey{Name: "node1"}, node1)).To(Succeed())
+		Expect(node1.Spec.Taints).To(BeEmpty())
+	})
+
+	It("should update and persist the inventory after programming the routes", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		fakeUpd.routeTables = map[string][]string{
+			"10.0.0.0/24": {"rtb-1", "rtb-2"},
+			"10.0.1.0/24": {"rtb-1"},
+		}
+		inv := inventory.New("shoot", 10)
+		store := inventory.NewConfigMapStore(c, metav1.NamespaceSystem, "route-inventory")
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			Inventory:         inv,
+			InventoryStore:    store,
+		})
+
+		Eventually(func() []inventory.Entry { return inv.Entries() }).Should(HaveLen(2))
+		entry, ok := inv.GetByPodCIDR("10.0.0.0/24")
+		Expect(ok).To(BeTrue())
+		Expect(entry.NodeName).To(Equal("node0"))
+		Expect(entry.InstanceID).To(Equal("i-0000"))
+		Expect(entry.RouteTables).To(Equal([]string{"rtb-1", "rtb-2"}))
+
+		Eventually(func() ([]inventory.Entry, error) { return store.Load(ctx) }).Should(Equal(inv.Entries()))
+	})
+
+	It("should write a report after each full sync", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		fakeUpd.setErr(fmt.Errorf("AWS unavailable"))
+
+		readReport := func() (*controller.SyncReport, error) {
+			cm := &corev1.ConfigMap{}
+			if err := c.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "route-sync-report"}, cm); err != nil {
+				return nil, err
+			}
+			report := &controller.SyncReport{}
+			return report, json.Unmarshal([]byte(cm.Data["report.json"]), report)
+		}
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Hour,
+			SyncReportStore:   controller.NewSyncReportStore(c, metav1.NamespaceSystem, "route-sync-report"),
+		})
+		Eventually(readReport).Should(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Success":       BeFalse(),
+			"Nodes":         Equal(2),
+			"RoutesCreated": Equal(2),
+			"LastError":     Equal("AWS unavailable"),
+			"LastErrorTime": Not(BeNil()),
+		})))
+
+		fakeUpd.setErr(nil)
+		reconciler.ForceSync()
+		Eventually(readReport).Should(PointTo(MatchFields(IgnoreExtras, Fields{
+			"Success":   BeTrue(),
+			"Nodes":     Equal(2),
+			"LastError": Equal("AWS unavailable"),
+		})))
+		report, err := readReport()
+		Expect(err).To(BeNil())
+		Expect(report.DurationSeconds).To(BeNumerically(">=", 0))
+		Expect(report.Time.IsZero()).To(BeFalse())
+	})
+
+	It("should force a sync on request", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].options.Force).To(BeFalse())
+
+		reconciler.ForceSync()
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		Expect(fakeUpd.getCalls()[1].options.Force).To(BeTrue())
+	})
+
+	It("should repair routes found missing by the drift detection", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		var deleted, checks atomic.Int32
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			DriftDetector: func() (int, error) {
+				checks.Add(1)
+				return int(deleted.Swap(0)), nil
+			},
+			DriftDetectionInterval: 50 * time.Millisecond,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Eventually(checks.Load).Should(BeNumerically(">=", 1))
+		Consistently(func() int { return len(fakeUpd.getCalls()) }, 100*time.Millisecond).Should(Equal(1))
+
+		// a managed route is deleted out-of-band
+		deleted.Store(1)
+		Eventually(func() int { return len(fakeUpd.getCalls()) }, 100*time.Millisecond).Should(Equal(2))
+		Expect(fakeUpd.getCalls()[1].routes).To(HaveLen(1))
+	})
+
+	It("should report the retry delay and log when it reaches the maximum", func() {
+		var (
+			logMutex sync.Mutex
+			messages []string
+		)
+		log := funcr.New(func(_, args string) {
+			logMutex.Lock()
+			defer logMutex.Unlock()
+			messages = append(messages, args)
+		}, funcr.Options{})
+		getMessages := func() []string {
+			logMutex.Lock()
+			defer logMutex.Unlock()
+			return append([]string{}, messages...)
+		}
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).Build()
+		reconciler = controller.NewNodeReconciler(c, log, elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		fakeUpd.setErr(fmt.Errorf("AWS unavailable"))
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			ClusterName:       "shoot--foo--bar",
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: 20 * time.Millisecond,
+		})
+		retryDelay := metrics.UpdateRetryDelay.WithLabelValues("shoot--foo--bar")
+
+		Eventually(func() float64 { return testutil.ToFloat64(retryDelay) }).Should(Equal(0.02))
+		Eventually(getMessages).Should(ContainElement(ContainSubstring("retry delay reached maximum")))
+		Expect(testutil.ToFloat64(retryDelay)).To(Equal(0.02))
+
+		fakeUpd.setErr(nil)
+		Eventually(func() float64 { return testutil.ToFloat64(retryDelay) }).Should(Equal(0.0))
+	})
+
+	It("should honor the retry delay requested by throttled AWS responses up to the maximum delay", func() {
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		throttled := func(retryAfter time.Duration) error {
+			return fmt.Errorf("creating route failed: %w", &updater.RetryAfterError{
+				RequestFailure: awserr.NewRequestFailure(awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil), http.StatusServiceUnavailable, "req-1"),
+				RetryAfter:     retryAfter,
+			})
+		}
+		fakeUpd.setErr(throttled(300 * time.Millisecond))
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+		Eventually(func() float64 { return testutil.ToFloat64(metrics.UpdateRetryDelay.WithLabelValues("")) }).Should(Equal(0.3))
+		Expect(fakeUpd.getCalls()).To(HaveLen(1))
+
+		fakeUpd.setErr(throttled(time.Minute))
+		Eventually(func() float64 { return testutil.ToFloat64(metrics.UpdateRetryDelay.WithLabelValues("")) }).Should(Equal(1.0))
+		Expect(fakeUpd.getCalls()).To(HaveLen(2))
+	})
+
+	It("should collect rapid node updates within the debounce window into a single update", func() {
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			ReconcileDebounce: 300 * time.Millisecond,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+
+		for i := 1; i <= 5; i++ {
+			node := &corev1.Node{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: "node0"}, node)).To(Succeed())
+			node.Spec.PodCIDRs = []string{fmt.Sprintf("10.0.%d.0/24", i)}
+			Expect(c.Update(ctx, node)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+			Expect(err).To(BeNil())
+			time.Sleep(20 * time.Millisecond)
+		}
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		Consistently(func() int { return len(fakeUpd.getCalls()) }, 400*time.Millisecond).Should(Equal(2))
+		Expect(fakeUpd.getCalls()[1].routes).To(ConsistOf(
+			updater.NodeRoute{NodeName: "node0", InstanceID: "i-0000", PodCIDR: "10.0.5.0/24", Zone: "eu-west-1a"},
+		))
+	})
+
+	It("should skip the update if the node routes are the same as last applied", func() {
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24")).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			ReconcileDebounce: 200 * time.Millisecond,
+			ReconcileCache:    true,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+
+		setPodCIDR := func(podCIDR string) {
+			node := &corev1.Node{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: "node0"}, node)).To(Succeed())
+			node.Spec.PodCIDRs = []string{podCIDR}
+			Expect(c.Update(ctx, node)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+			Expect(err).To(BeNil())
+		}
+		// the pod CIDR is reverted within the debounce window, so the node route is unchanged
+		setPodCIDR("10.0.1.0/24")
+		setPodCIDR("10.0.0.0/24")
+		Consistently(func() int { return len(fakeUpd.getCalls()) }, 400*time.Millisecond).Should(Equal(1))
+
+		setPodCIDR("10.0.2.0/24")
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		Expect(fakeUpd.getCalls()[1].routes).To(ConsistOf(
+			updater.NodeRoute{NodeName: "node0", InstanceID: "i-0000", PodCIDR: "10.0.2.0/24", Zone: "eu-west-1a"},
+		))
+
+		// a full sync bypasses the cache
+		reconciler.ForceSync()
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(3))
+	})
+
+	It("should not delete routes in an empty cluster until a node is observed", func() {
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String("10.0.9.0/24"),
+				InstanceId:           aws.String("i-0009"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0000")})
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		var (
+			logMutex sync.Mutex
+			messages []string
+		)
+		log := funcr.New(func(_, args string) {
+			logMutex.Lock()
+			defer logMutex.Unlock()
+			messages = append(messages, args)
+		}, funcr.Options{})
+		getMessages := func() []string {
+			logMutex.Lock()
+			defer logMutex.Unlock()
+			return append([]string{}, messages...)
+		}
+		c = fake.NewClientBuilder().Build()
+		reconciler = controller.NewNodeReconciler(c, log, elected, record.NewFakeRecorder(100))
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        20 * time.Millisecond,
+			MaxDelayOnFailure: time.Second,
+		})
+		Consistently(func() int { return cloud.Calls("DeleteRoute") }, 200*time.Millisecond).Should(Equal(0))
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+		Expect(getMessages()).To(ContainElement(ContainSubstring("no nodes observed yet, no route cleanup runs until at least one node is observed")))
+
+		Expect(c.Create(ctx, makeNode("node0", "i-0000", "10.0.0.0/24"))).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		Eventually(func() []*ec2.Route { return cloud.RouteTable("rtb-0001").Routes }).Should(ConsistOf(
+			HaveField("DestinationCidrBlock", Equal(aws.String("10.0.0.0/24"))),
+		))
+	})
+
+	It("should skip nodes with malformed instance IDs", func() {
+		malformed := makeNode("node1", "i-0001", "10.0.1.0/24")
+		malformed.Spec.ProviderID = "aws:///eu-west-1a/i-XYZ!"
+		recorder := record.NewFakeRecorder(10)
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24"), malformed).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, recorder)
+		before := testutil.ToFloat64(metrics.InvalidInstanceIDs)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.InvalidInstanceIDs)).To(Equal(before + 1))
+		Expect(recorder.Events).To(Receive(ContainSubstring(`InvalidInstanceID node skipped, malformed instance ID "i-XYZ!"`)))
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].routes).To(ConsistOf(
+			updater.NodeRoute{NodeName: "node0", InstanceID: "i-0000", PodCIDR: "10.0.0.0/24", Zone: "eu-west-1a"},
+		))
+	})
+
+	It("should only process the nodes of AWS in hybrid clusters", func() {
+		gce := makeNode("node-gce", "", "10.0.2.0/24")
+		gce.Spec.ProviderID = "gce://project/europe-west1-b/node-gce"
+		azure := makeNode("node-azure", "", "10.0.3.0/24")
+		azure.Spec.ProviderID = "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/node-azure"
+		c = fake.NewClientBuilder().WithObjects(makeNode("node0", "i-0000", "10.0.0.0/24"), gce, azure, makeNode("node1", "i-0001", "10.0.1.0/24")).Build()
+		reconciler = controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(10))
+		for _, name := range []string{"node0", "node-gce", "node-azure", "node1"} {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+			Expect(err).To(BeNil())
+		}
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].routes).To(ConsistOf(
+			updater.NodeRoute{NodeName: "node0", InstanceID: "i-0000", PodCIDR: "10.0.0.0/24", Zone: "eu-west-1a"},
+			updater.NodeRoute{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.0.1.0/24", Zone: "eu-west-1a"},
+		))
+
+		// the route of a node is removed once it has the provider ID of another cloud
+		node := &corev1.Node{}
+		Expect(c.Get(ctx, client.ObjectKey{Name: "node1"}, node)).To(Succeed())
+		node.Spec.ProviderID = "gce://project/europe-west1-b/node1"
+		Expect(c.Update(ctx, node)).To(Succeed())
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		Expect(fakeUpd.getCalls()[1].routes).To(ConsistOf(
+			updater.NodeRoute{NodeName: "node0", InstanceID: "i-0000", PodCIDR: "10.0.0.0/24", Zone: "eu-west-1a"},
+		))
+	})
+
+	It("should count the reconcile outcomes by reason", func() {
+		invalidCIDR := makeNode("node1", "i-0001", "10.0.1.0/33")
+		malformed := makeNode("node2", "i-0002", "10.0.2.0/24")
+		malformed.Spec.ProviderID = "aws:///eu-west-1a/i-XYZ!"
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), invalidCIDR, malformed)
+		outcome := func(reason string) float64 {
+			return testutil.ToFloat64(metrics.ReconcileOutcomes.WithLabelValues(reason))
+		}
+		before := map[string]float64{}
+		for _, reason := range []string{controller.ReasonSuccess, controller.ReasonCIDRInvalid, controller.ReasonInstanceIDInvalid} {
+			before[reason] = outcome(reason)
+		}
+
+		updateSuccesses := testutil.ToFloat64(metrics.UpdateOutcomes.WithLabelValues(controller.ReasonSuccess))
+
+		for _, name := range []string{"node0", "node1", "node2", "deleted"} {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+			Expect(err).To(BeNil())
+		}
+		Expect(testutil.ToFloat64(metrics.UpdateOutcomes.WithLabelValues(controller.ReasonSuccess))).To(Equal(updateSuccesses))
+		Expect(outcome(controller.ReasonSuccess)).To(Equal(before[controller.ReasonSuccess] + 2))
+		Expect(outcome(controller.ReasonCIDRInvalid)).To(Equal(before[controller.ReasonCIDRInvalid] + 1))
+		Expect(outcome(controller.ReasonInstanceIDInvalid)).To(Equal(before[controller.ReasonInstanceIDInvalid] + 1))
+	})
+
+	It("should count the update outcomes by reason", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+		before := testutil.ToFloat64(metrics.UpdateOutcomes.WithLabelValues(controller.ReasonThrottled))
+		reconcileBefore := testutil.ToFloat64(metrics.ReconcileOutcomes.WithLabelValues(controller.ReasonThrottled))
+		fakeUpd.setErr(awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil))
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+		Eventually(func() float64 {
+			return testutil.ToFloat64(metrics.UpdateOutcomes.WithLabelValues(controller.ReasonThrottled))
+		}).Should(BeNumerically(">", before))
+		Expect(testutil.ToFloat64(metrics.ReconcileOutcomes.WithLabelValues(controller.ReasonThrottled))).To(Equal(reconcileBefore))
+
+		successes := testutil.ToFloat64(metrics.UpdateOutcomes.WithLabelValues(controller.ReasonSuccess))
+		reconcileSuccesses := testutil.ToFloat64(metrics.ReconcileOutcomes.WithLabelValues(controller.ReasonSuccess))
+		fakeUpd.setErr(nil)
+		Eventually(func() float64 {
+			return testutil.ToFloat64(metrics.UpdateOutcomes.WithLabelValues(controller.ReasonSuccess))
+		}).Should(BeNumerically(">", successes))
+		Expect(testutil.ToFloat64(metrics.ReconcileOutcomes.WithLabelValues(controller.ReasonSuccess))).To(Equal(reconcileSuccesses))
+	})
+
+	It("should program routes for Windows nodes", func() {
+		windows1 := makeNode("windows1", "i-0001", "10.0.1.0/24")
+		windows1.Labels = map[string]string{corev1.LabelOSStable: "windows"}
+		windows2 := makeNode("windows2", "i-0002", "10.0.2.0/24")
+		windows2.Labels = map[string]string{corev1.LabelOSStable: "windows"}
+		windows2.Spec.PodCIDRs = nil
+		windows2.Spec.PodCIDR = "10.0.2.0/24"
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), windows1, windows2)
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "windows1"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].routes).To(ConsistOf(
+			updater.NodeRoute{NodeName: "node0", InstanceID: "i-0000", PodCIDR: "10.0.0.0/24", Zone: "eu-west-1a"},
+			updater.NodeRoute{NodeName: "windows1", InstanceID: "i-0001", PodCIDR: "10.0.1.0/24", Zone: "eu-west-1a"},
+			updater.NodeRoute{NodeName: "windows2", InstanceID: "i-0002", PodCIDR: "10.0.2.0/24", Zone: "eu-west-1a"},
+		))
+	})
+
+	It("should defer the route cleanup during the startup cleanup delay", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:          10 * time.Millisecond,
+			SyncPeriod:          time.Hour,
+			MaxDelayOnFailure:   time.Second,
+			StartupCleanupDelay: 200 * time.Millisecond,
+		})
+
+		start := time.Now()
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].options.CreateOnly).To(BeTrue())
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+		Expect(fakeUpd.getCalls()[1].options.CreateOnly).To(BeFalse())
+	})
+
+	It("should only create routes in the startup repair pass and delete them afterwards", func() {
+		newReconciler(makeNode("node0", "i-0000", "10.0.0.0/24"), makeNode("node1", "i-0001", "10.0.1.0/24"))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node0"}})
+		Expect(err).To(BeNil())
+
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			StartupRepairPass: true,
+		})
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		calls := fakeUpd.getCalls()
+		Expect(calls[0].options.CreateOnly).To(BeTrue())
+		Expect(calls[0].routes).To(HaveLen(2))
+		Expect(calls[1].options.CreateOnly).To(BeFalse())
+		Expect(calls[1].routes).To(HaveLen(2))
+		Consistently(func() int { return len(fakeUpd.getCalls()) }, 100*time.Millisecond).Should(Equal(2))
+	})
+
+	It("should fail the liveness check if the heartbeat is stale", func() {
+		newReconciler()
+		Expect(reconciler.LivenessChecker(nil)).To(Succeed())
+
+		updaterCtx, stopUpdater := context.WithCancel(ctx)
+		reconciler.StartUpdater(updaterCtx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			LivenessThreshold: 100 * time.Millisecond,
+		})
+		Consistently(func() error { return reconciler.LivenessChecker(nil) }, 200*time.Millisecond).Should(Succeed())
+
+		stopUpdater()
+		Eventually(func() error { return reconciler.LivenessChecker(nil) }).Should(MatchError(ContainSubstring("heartbeat is stale")))
+	})
+})
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// RouteStateGroupVersionKind is the kind of the custom resource exporting the managed routes
+var RouteStateGroupVersionKind = schema.GroupVersionKind{
+	Group:   "aws-custom-route-controller.gardener.cloud",
+	Version: "v1alpha1",
+	Kind:    "RouteState",
+}
+
+// RouteStateFinalizer is the finalizer of the controller on the route state
+const RouteStateFinalizer = "aws-custom-route-controller.gardener.cloud/route-state"
+
+// RouteStateStatus is the status of the route state
+type RouteStateStatus struct {
+	// UpdateTime is the time of the last successful update changing the routes
+	UpdateTime metav1.Time `json:"updateTime"`
+	// Routes is the number of managed node routes
+	Routes int `json:"routes"`
+	// RouteTables lists the managed routes per route table sorted by ID
+	RouteTables []RouteStateTable `json:"routeTables"`
+}
+
+// RouteStateTable contains the managed routes of a route table
+type RouteStateTable struct {
+	// ID is the ID of the route table
+	ID string `json:"id"`
+	// Routes are the managed routes sorted by destination
+	Routes []RouteStateRoute `json:"routes"`
+}
+
+// RouteStateRoute is a managed route of a node
+type RouteStateRoute struct {
+	// DestinationCidrBlock is the pod CIDR of the node
+	DestinationCidrBlock string `json:"destinationCidrBlock"`
+	// Node is the name of the node
+	Node string `json:"node,omitempty"`
+}
+
+// NewRouteStateStatus groups the routes of the nodes by the route tables containing them after the update
+func NewRouteStateStatus(routes []updater.NodeRoute, result *updater.UpdateResult, now time.Time) RouteStateStatus {
+	status := RouteStateStatus{UpdateTime: metav1.NewTime(now), RouteTables: []RouteStateTable{}}
+	tables := map[string][]RouteStateRoute{}
+	for _, route := range routes {
+		tableIDs := result.RouteTables[route.PodCIDR]
+		if len(tableIDs) == 0 {
+			continue
+		}
+		status.Routes++
+		for _, tableID := range tableIDs {
+			tables[tableID] = append(tables[tableID], RouteStateRoute{DestinationCidrBlock: route.PodCIDR, Node: route.NodeName})
+		}
+	}
+	for tableID, tableRoutes := range tables {
+		sort.Slice(tableRoutes, func(i, j int) bool { return tableRoutes[i].DestinationCidrBlock < tableRoutes[j].DestinationCidrBlock })
+		status.RouteTables = append(status.RouteTables, RouteStateTable{ID: tableID, Routes: tableRoutes})
+	}
+	sort.Slice(status.RouteTables, func(i, j int) bool { return status.RouteTables[i].ID < status.RouteTables[j].ID })
+	return status
+}
+
+// RouteStateStore writes the managed routes to the status of a RouteState custom resource
+type RouteStateStore struct {
+	client    client.Client
+	namespace string
+	name      string
+}
+
+// NewRouteStateStore creates a store for the route state with the given namespace and name
+func NewRouteStateStore(client client.Client, namespace, name string) *RouteStateStore {
+	return &RouteStateStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// newObject returns an empty route state object of the store
+func (s *RouteStateStore) newObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(RouteStateGroupVersionKind)
+	obj.SetNamespace(s.namespace)
+	obj.SetName(s.name)
+	return obj
+}
+
+// Save writes the status to the route state, creating it with the finalizer if needed.
+// If the route state is being deleted, the finalizer is removed instead, and the route state is recreated by the next save.
+// The status is only written if the routes changed, so that the update time is the time of the last change.
+func (s *RouteStateStore) Save(ctx context.Context, status RouteStateStatus) error {
+	obj := s.newObject()
+	err := s.client.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	switch {
+	case apierrors.IsNotFound(err):
+		obj = s.newObject()
+		controllerutil.AddFinalizer(obj, RouteStateFinalizer)
+		if err := s.client.Create(ctx, obj); err != nil {
+			return fmt.Errorf("creating route state failed: %w", err)
+		}
+	case err != nil:
+		return err
+	case obj.GetDeletionTimestamp() != nil:
+		if controllerutil.RemoveFinalizer(obj, RouteStateFinalizer) {
+			return s.client.Update(ctx, obj)
+		}
+		return nil
+	case controllerutil.AddFinalizer(obj, RouteStateFinalizer):
+		if err := s.client.Update(ctx, obj); err != nil {
+			return err
+		}
+	default:
+		if current, ok, _ := unstructured.NestedMap(obj.Object, "status"); ok {
+			var previous RouteStateStatus
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current, &previous); err == nil &&
+				previous.Routes == status.Routes && equality.Semantic.DeepEqual(previous.RouteTables, status.RouteTables) {
+				return nil
+			}
+		}
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(obj.Object, content, "status"); err != nil {
+		return err
+	}
+	return s.client.Status().Update(ctx, obj)
+}
+
+// saveRouteState writes the managed routes after a successful update if a store is configured
+func (r *NodeReconciler) saveRouteState(ctx context.Context, log logr.Logger, cfg UpdaterConfig, routes []updater.NodeRoute, result *updater.UpdateResult) {
+	if cfg.RouteStateStore == nil || result == nil {
+		return
+	}
+	if err := cfg.RouteStateStore.Save(ctx, NewRouteStateStatus(routes, result, time.Now())); err != nil {
+		log.Error(err, "saving route state failed")
+	}
+}
@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	ec2fake "github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("route state", func() {
+	var (
+		ctx   context.Context
+		c     client.Client
+		store *controller.RouteStateStore
+	)
+
+	newRouteState := func() *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(controller.RouteStateGroupVersionKind)
+		return obj
+	}
+	getRouteState := func() (*unstructured.Unstructured, error) {
+		obj := newRouteState()
+		return obj, c.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "routes"}, obj)
+	}
+	routeTables := func() []interface{} {
+		obj, err := getRouteState()
+		if err != nil {
+			return nil
+		}
+		tables, _, _ := unstructured.NestedSlice(obj.Object, "status", "routeTables")
+		return tables
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = fake.NewClientBuilder().WithStatusSubresource(newRouteState(), &corev1.Node{}).Build()
+		store = controller.NewRouteStateStore(c, metav1.NamespaceSystem, "routes")
+	})
+
+	It("should group the managed routes by route table", func() {
+		now := time.Now()
+		status := controller.NewRouteStateStatus([]updater.NodeRoute{
+			{NodeName: "node2", PodCIDR: "10.0.2.0/24"},
+			{NodeName: "node1", PodCIDR: "10.0.1.0/24"},
+			{NodeName: "node3", PodCIDR: "10.0.3.0/24"},
+		}, &updater.UpdateResult{RouteTables: map[string][]string{
+			"10.0.1.0/24": {"rtb-b", "rtb-a"},
+			"10.0.2.0/24": {"rtb-a"},
+		}}, now)
+		Expect(status).To(Equal(controller.RouteStateStatus{
+			UpdateTime: metav1.NewTime(now),
+			Routes:     2,
+			RouteTables: []controller.RouteStateTable{
+				{ID: "rtb-a", Routes: []controller.RouteStateRoute{{DestinationCidrBlock: "10.0.1.0/24", Node: "node1"}, {DestinationCidrBlock: "10.0.2.0/24", Node: "node2"}}},
+				{ID: "rtb-b", Routes: []controller.RouteStateRoute{{DestinationCidrBlock: "10.0.1.0/24", Node: "node1"}}},
+			},
+		}))
+	})
+
+	It("should create the route state with finalizer and update its status", func() {
+		Expect(store.Save(ctx, controller.RouteStateStatus{Routes: 1, RouteTables: []controller.RouteStateTable{
+			{ID: "rtb-a", Routes: []controller.RouteStateRoute{{DestinationCidrBlock: "10.0.1.0/24", Node: "node1"}}},
+		}})).To(Succeed())
+		obj, err := getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetFinalizers()).To(ConsistOf(controller.RouteStateFinalizer))
+		routes, _, _ := unstructured.NestedInt64(obj.Object, "status", "routes")
+		Expect(routes).To(Equal(int64(1)))
+		Expect(routeTables()).To(Equal([]interface{}{map[string]interface{}{
+			"id":     "rtb-a",
+			"routes": []interface{}{map[string]interface{}{"destinationCidrBlock": "10.0.1.0/24", "node": "node1"}},
+		}}))
+
+		Expect(store.Save(ctx, controller.RouteStateStatus{RouteTables: []controller.RouteStateTable{}})).To(Succeed())
+		Expect(routeTables()).To(BeEmpty())
+	})
+
+	It("should not rewrite the status if the routes are unchanged", func() {
+		tables := []controller.RouteStateTable{
+			{ID: "rtb-a", Routes: []controller.RouteStateRoute{{DestinationCidrBlock: "10.0.1.0/24", Node: "node1"}}},
+		}
+		first := time.Now().Add(-time.Hour).Truncate(time.Second)
+		Expect(store.Save(ctx, controller.RouteStateStatus{UpdateTime: metav1.NewTime(first), Routes: 1, RouteTables: tables})).To(Succeed())
+		obj, err := getRouteState()
+		Expect(err).To(BeNil())
+		resourceVersion := obj.GetResourceVersion()
+
+		Expect(store.Save(ctx, controller.RouteStateStatus{UpdateTime: metav1.Now(), Routes: 1, RouteTables: tables})).To(Succeed())
+		obj, err = getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetResourceVersion()).To(Equal(resourceVersion))
+		updateTime, _, _ := unstructured.NestedString(obj.Object, "status", "updateTime")
+		Expect(updateTime).To(Equal(first.UTC().Format(time.RFC3339)))
+
+		Expect(store.Save(ctx, controller.RouteStateStatus{UpdateTime: metav1.Now(), RouteTables: []controller.RouteStateTable{}})).To(Succeed())
+		obj, err = getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetResourceVersion()).NotTo(Equal(resourceVersion))
+		Expect(routeTables()).To(BeEmpty())
+	})
+
+	It("should remove the finalizer of a deleted route state and recreate it with the next save", func() {
+		Expect(store.Save(ctx, controller.RouteStateStatus{Routes: 1})).To(Succeed())
+		obj, err := getRouteState()
+		Expect(err).To(BeNil())
+		Expect(c.Delete(ctx, obj)).To(Succeed())
+		obj, err = getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetDeletionTimestamp()).NotTo(BeNil())
+
+		Expect(store.Save(ctx, controller.RouteStateStatus{Routes: 2})).To(Succeed())
+		_, err = getRouteState()
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(store.Save(ctx, controller.RouteStateStatus{Routes: 3})).To(Succeed())
+		obj, err = getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetFinalizers()).To(ConsistOf(controller.RouteStateFinalizer))
+		routes, _, _ := unstructured.NestedInt64(obj.Object, "status", "routes")
+		Expect(routes).To(Equal(int64(3)))
+	})
+
+	It("should add the finalizer to an existing route state", func() {
+		obj := newRouteState()
+		obj.SetNamespace(metav1.NamespaceSystem)
+		obj.SetName("routes")
+		Expect(c.Create(ctx, obj)).To(Succeed())
+		Expect(store.Save(ctx, controller.RouteStateStatus{Routes: 1})).To(Succeed())
+		obj, err := getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetFinalizers()).To(ConsistOf(controller.RouteStateFinalizer))
+		routes, _, _ := unstructured.NestedInt64(obj.Object, "status", "routes")
+		Expect(routes).To(Equal(int64(1)))
+	})
+
+	It("should export the managed routes after each update", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		Expect(c.Create(ctx, makeNode("node1", "i-0001", "10.0.1.0/24"))).To(Succeed())
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			RouteStateStore:   store,
+		})
+
+		Eventually(routeTables).Should(Equal([]interface{}{map[string]interface{}{
+			"id":     "rtb-0001",
+			"routes": []interface{}{map[string]interface{}{"destinationCidrBlock": "10.0.1.0/24", "node": "node1"}},
+		}}))
+	})
+
+	It("should release and recreate a route state deleted while the controller is running", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+
+		cloud := ec2fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		Expect(c.Create(ctx, makeNode("node1", "i-0001", "10.0.1.0/24"))).To(Succeed())
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+			RouteStateStore:   store,
+		})
+		Eventually(routeTables).Should(HaveLen(1))
+		obj, err := getRouteState()
+		Expect(err).To(BeNil())
+		Expect(obj.GetFinalizers()).To(ConsistOf(controller.RouteStateFinalizer))
+
+		Expect(c.Delete(ctx, obj)).To(Succeed())
+		Consistently(func() (bool, error) {
+			obj, err := getRouteState()
+			return obj.GetDeletionTimestamp() != nil, err
+		}, 50*time.Millisecond).Should(BeTrue())
+
+		// the finalizer is removed with the next update
+		reconciler.ForceSync()
+		Eventually(func() bool {
+			_, err := getRouteState()
+			return apierrors.IsNotFound(err)
+		}).Should(BeTrue())
+
+		// and the route state is recreated with the following one
+		reconciler.ForceSync()
+		Eventually(func() (*unstructured.Unstructured, error) { return getRouteState() }).Should(And(
+			WithTransform(func(obj *unstructured.Unstructured) *metav1.Time { return obj.GetDeletionTimestamp() }, BeNil()),
+			WithTransform(func(obj *unstructured.Unstructured) []string { return obj.GetFinalizers() }, ConsistOf(controller.RouteStateFinalizer)),
+		))
+		Eventually(routeTables).Should(HaveLen(1))
+	})
+})
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/go-logr/logr"
+)
+
+// routeTableTrace contains the route tables of the node routes after the last successful update
+type routeTableTrace struct {
+	lock   sync.RWMutex
+	tables map[string][]string
+}
+
+// traceRouteTables logs the route tables of the nodes whose routes have moved to other route tables since the last update
+func (r *NodeReconciler) traceRouteTables(log logr.Logger, result *updater.UpdateResult) {
+	if result == nil {
+		return
+	}
+	tables := make(map[string][]string, len(result.NodeRouteTables))
+	for nodeName, tableIDs := range result.NodeRouteTables {
+		tableIDs = slices.Clone(tableIDs)
+		sort.Strings(tableIDs)
+		tables[nodeName] = tableIDs
+	}
+	r.routeTables.lock.Lock()
+	defer r.routeTables.lock.Unlock()
+	for nodeName, tableIDs := range tables {
+		if !slices.Equal(r.routeTables.tables[nodeName], tableIDs) {
+			log.Info("node route programmed", "node", nodeName, "routeTables", tableIDs)
+		}
+	}
+	r.routeTables.tables = tables
+}
+
+// RouteTables returns the IDs of the route tables containing the route of the node after the last successful update
+func (r *NodeReconciler) RouteTables(nodeName string) []string {
+	r.routeTables.lock.RLock()
+	defer r.routeTables.lock.RUnlock()
+	return slices.Clone(r.routeTables.tables[nodeName])
+}
+
+// ServeRouteTables returns the route tables of all node routes as JSON, or of a single node with the query parameter node
+func (r *NodeReconciler) ServeRouteTables(w http.ResponseWriter, req *http.Request) {
+	r.routeTables.lock.RLock()
+	tables := r.routeTables.tables
+	if nodeName := req.URL.Query().Get("node"); nodeName != "" {
+		tables = map[string][]string{nodeName: tables[nodeName]}
+	}
+	r.routeTables.lock.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tables); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
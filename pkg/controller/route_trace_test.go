@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	ec2fake "github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("route table trace", func() {
+	It("should report the route tables the node routes have been programmed in", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+
+		cloud := ec2fake.NewEC2()
+		clusterTag := &ec2.Tag{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}
+		for _, zone := range []string{"a", "b"} {
+			cloud.AddSubnet(&ec2.Subnet{SubnetId: aws.String("subnet-" + zone), AvailabilityZone: aws.String("eu-west-1" + zone)})
+			cloud.AddRouteTable(&ec2.RouteTable{
+				RouteTableId: aws.String("rtb-" + zone),
+				Tags:         []*ec2.Tag{clusterTag},
+				Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-" + zone)}},
+			})
+		}
+		cloud.AddRouteTable(&ec2.RouteTable{RouteTableId: aws.String("rtb-shared"), Tags: []*ec2.Tag{clusterTag}})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+
+		nodeA := makeNode("node-a", "i-0001", "10.0.1.0/24")
+		nodeA.Labels = map[string]string{corev1.LabelTopologyZone: "eu-west-1a"}
+		nodeB := makeNode("node-b", "i-0002", "10.0.2.0/24")
+		nodeB.Labels = map[string]string{corev1.LabelTopologyZone: "eu-west-1b"}
+		c := fake.NewClientBuilder().WithObjects(nodeA, nodeB).WithStatusSubresource(&corev1.Node{}).Build()
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/16", updater.CustomRoutesOptions{
+			AZScopedRouting: true,
+		})
+		Expect(err).To(BeNil())
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node-a"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+
+		// tablesWithRoute returns the IDs of the tables of the fake EC2 containing a route for the destination
+		tablesWithRoute := func(destination string) []string {
+			var tableIDs []string
+			for _, tableID := range []string{"rtb-a", "rtb-b", "rtb-shared"} {
+				for _, route := range cloud.RouteTable(tableID).Routes {
+					if aws.StringValue(route.DestinationCidrBlock) == destination {
+						tableIDs = append(tableIDs, tableID)
+					}
+				}
+			}
+			sort.Strings(tableIDs)
+			return tableIDs
+		}
+		Eventually(func() []string { return reconciler.RouteTables("node-a") }).Should(Equal([]string{"rtb-a", "rtb-shared"}))
+		Expect(reconciler.RouteTables("node-a")).To(Equal(tablesWithRoute("10.0.1.0/24")))
+		Expect(reconciler.RouteTables("node-b")).To(Equal(tablesWithRoute("10.0.2.0/24")))
+		Expect(reconciler.RouteTables("node-b")).To(Equal([]string{"rtb-b", "rtb-shared"}))
+
+		recorder := httptest.NewRecorder()
+		reconciler.ServeRouteTables(recorder, httptest.NewRequest(http.MethodGet, "/debug/routetables?node=node-b", nil))
+		var served map[string][]string
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &served)).To(Succeed())
+		Expect(served).To(Equal(map[string][]string{"node-b": {"rtb-b", "rtb-shared"}}))
+	})
+})
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// NodeRequestMapper maps the changes of a secondary resource to the reconcile requests of the affected nodes,
+// e.g. if a CNI reassigns the pod CIDRs of nodes in a separate resource without updating the nodes
+type NodeRequestMapper struct {
+	// Namespace restricts the mapped objects to the namespace (empty for all)
+	Namespace string
+	// NodeNameField is the field path containing the node names, which may traverse lists, e.g. endpoints.nodeName.
+	// If it is empty, the object must be named like the node.
+	NodeNameField []string
+}
+
+// ParseSecondaryResource parses the secondary resource and the optional field holding the node names
+// in the form <group>/<version>/<resource>[:<field path>], e.g. discovery.k8s.io/v1/endpointslices:endpoints.nodeName.
+// The group is omitted for the core API group.
+func ParseSecondaryResource(value string) (schema.GroupVersionResource, []string, error) {
+	resource, field, hasField := strings.Cut(value, ":")
+	gvr, err := parseGroupVersionResource(resource)
+	if err != nil {
+		return schema.GroupVersionResource{}, nil, err
+	}
+	if !hasField {
+		return gvr, nil, nil
+	}
+	fieldPath, ok := parseFieldPath(field)
+	if !ok {
+		return schema.GroupVersionResource{}, nil, fmt.Errorf("invalid node name field in %q", value)
+	}
+	return gvr, fieldPath, nil
+}
+
+// Map returns the reconcile requests of the nodes referenced by the object
+func (m NodeRequestMapper) Map(_ context.Context, obj client.Object) []reconcile.Request {
+	if m.Namespace != "" && obj.GetNamespace() != m.Namespace {
+		return nil
+	}
+	if len(m.NodeNameField) == 0 {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetName()}}}
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	nodeNames := map[string]bool{}
+	for _, nodeName := range nestedStrings(u.Object, m.NodeNameField) {
+		nodeNames[nodeName] = true
+	}
+	requests := make([]reconcile.Request, 0, len(nodeNames))
+	for nodeName := range nodeNames {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: nodeName}})
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].Name < requests[j].Name })
+	return requests
+}
+
+// nestedStrings returns the non-empty strings at the field path of the value, descending into all items of lists
+func nestedStrings(value interface{}, fieldPath []string) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, nestedStrings(item, fieldPath)...)
+		}
+		return values
+	case map[string]interface{}:
+		if len(fieldPath) == 0 {
+			return nil
+		}
+		return nestedStrings(v[fieldPath[0]], fieldPath[1:])
+	case string:
+		if len(fieldPath) == 0 && v != "" {
+			return []string{v}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// makeEndpointSlice returns an unstructured endpoint slice with endpoints on the nodes
+func makeEndpointSlice(namespace, name string, nodeNames ...string) *unstructured.Unstructured {
+	var endpoints []interface{}
+	for _, nodeName := range nodeNames {
+		endpoints = append(endpoints, map[string]interface{}{"nodeName": nodeName})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"endpoints": endpoints}}
+	obj.SetAPIVersion("discovery.k8s.io/v1")
+	obj.SetKind("EndpointSlice")
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+var _ = Describe("secondary watch", func() {
+	DescribeTable("should parse the secondary resource",
+		func(value string, gvr schema.GroupVersionResource, fieldPath []string) {
+			parsedGVR, parsedFieldPath, err := controller.ParseSecondaryResource(value)
+			Expect(err).To(BeNil())
+			Expect(parsedGVR).To(Equal(gvr))
+			Expect(parsedFieldPath).To(Equal(fieldPath))
+		},
+		Entry("without field", "example.com/v1/machines", machinesResource, nil),
+		Entry("with field", "discovery.k8s.io/v1/endpointslices:endpoints.nodeName",
+			schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}, []string{"endpoints", "nodeName"}),
+		Entry("with core group", "v1/configmaps:data.node", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, []string{"data", "node"}),
+	)
+
+	DescribeTable("should reject an invalid secondary resource",
+		func(value string) {
+			_, _, err := controller.ParseSecondaryResource(value)
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("without version", "machines"),
+		Entry("with empty resource", "example.com/v1/"),
+		Entry("with empty field", "example.com/v1/machines:"),
+		Entry("with empty field path element", "example.com/v1/machines:spec..node"),
+	)
+
+	It("should map the object to the node with the same name", func() {
+		mapper := controller.NodeRequestMapper{Namespace: "machines"}
+		Expect(mapper.Map(context.Background(), makeMachine("node1", nil))).To(Equal([]reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: "node1"}},
+		}))
+
+		other := makeMachine("node2", nil)
+		other.SetNamespace("other")
+		Expect(mapper.Map(context.Background(), other)).To(BeEmpty())
+	})
+
+	It("should map the object to the nodes of the node name field", func() {
+		mapper := controller.NodeRequestMapper{NodeNameField: []string{"endpoints", "nodeName"}}
+		Expect(mapper.Map(context.Background(), makeEndpointSlice("default", "cni", "node2", "node1", "node2"))).To(Equal([]reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: "node1"}},
+			{NamespacedName: types.NamespacedName{Name: "node2"}},
+		}))
+		Expect(mapper.Map(context.Background(), makeEndpointSlice("default", "empty"))).To(BeEmpty())
+	})
+
+	It("should reconcile the affected node on a change of the secondary resource", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		fakeUpd := &fakeUpdater{}
+
+		dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{machinesResource: "MachineList"},
+			makeMachine("node1", map[string]interface{}{"podCIDRs": []interface{}{"10.243.1.0/24"}}))
+		c := fake.NewClientBuilder().WithObjects(makeNode("node1", "i-0001", "10.250.1.0/24")).Build()
+		reconciler := controller.NewNodeReconciler(c, logf.Log.WithName("test"), elected, record.NewFakeRecorder(100))
+		reconciler.SetPodCIDRProvider(controller.NewCustomResourcePodCIDRProvider(dynamicClient, machinesResource, "machines", []string{"spec", "podCIDRs"}))
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node1"}})
+		Expect(err).To(BeNil())
+		reconciler.StartUpdater(ctx, fakeUpd.update, controller.UpdaterConfig{
+			TickPeriod:        10 * time.Millisecond,
+			SyncPeriod:        time.Hour,
+			MaxDelayOnFailure: time.Second,
+		})
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(1))
+		Expect(fakeUpd.getCalls()[0].routes[0].PodCIDR).To(Equal("10.243.1.0/24"))
+
+		// the pod CIDR is reassigned without a node update
+		changed := makeMachine("node1", map[string]interface{}{"podCIDRs": []interface{}{"10.243.7.0/24"}})
+		_, err = dynamicClient.Resource(machinesResource).Namespace("machines").Update(ctx, changed, metav1.UpdateOptions{})
+		Expect(err).To(BeNil())
+		mapper := controller.NodeRequestMapper{Namespace: "machines"}
+		for _, req := range mapper.Map(ctx, changed) {
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(BeNil())
+		}
+
+		Eventually(func() int { return len(fakeUpd.getCalls()) }).Should(Equal(2))
+		routes := fakeUpd.getCalls()[1].routes
+		Expect(routes).To(HaveLen(1))
+		Expect(routes[0].PodCIDR).To(Equal("10.243.7.0/24"))
+	})
+})
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// syncReportKey is the key of the report in the config map data
+	syncReportKey = "report.json"
+	// maxSyncReportErrorLength limits the length of the error message in the report to keep the config map small
+	maxSyncReportErrorLength = 1024
+)
+
+// SyncReport summarizes the outcome of a full sync
+type SyncReport struct {
+	// Time is the end of the sync
+	Time metav1.Time `json:"time"`
+	// DurationSeconds is the duration of the sync
+	DurationSeconds float64 `json:"durationSeconds"`
+	// Success is set if the sync succeeded
+	Success bool `json:"success"`
+	// Nodes is the number of nodes with a route
+	Nodes int `json:"nodes"`
+	// RoutesCreated is the number of created routes
+	RoutesCreated int `json:"routesCreated"`
+	// RoutesDeleted is the number of deleted routes
+	RoutesDeleted int `json:"routesDeleted"`
+	// RoutesFailed is the number of routes which could not be created
+	RoutesFailed int `json:"routesFailed"`
+	// RouteDeletionsSkipped is the number of route deletions skipped because they exceed the maximum per update
+	RouteDeletionsSkipped int `json:"routeDeletionsSkipped"`
+	// LastError is the error of the last failed update (truncated)
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorTime is the time of the last failed update
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+}
+
+// SyncReportStore writes the sync report to a config map
+type SyncReportStore struct {
+	client    client.Client
+	namespace string
+	name      string
+}
+
+// NewSyncReportStore creates a store for the config map with the given namespace and name
+func NewSyncReportStore(client client.Client, namespace, name string) *SyncReportStore {
+	return &SyncReportStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Save writes the report to the config map, creating it if needed
+func (s *SyncReportStore) Save(ctx context.Context, report SyncReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: s.name}}
+	_, err = controllerutil.CreateOrPatch(ctx, s.client, cm, func() error {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[syncReportKey] = string(data)
+		return nil
+	})
+	return err
+}
+
+// recordLastError remembers the error of a failed update for the sync report
+func (r *NodeReconciler) recordLastError(err error) {
+	r.lastError = err.Error()
+	if len(r.lastError) > maxSyncReportErrorLength {
+		r.lastError = r.lastError[:maxSyncReportErrorLength] + "..."
+	}
+	r.lastErrorTime = time.Now()
+}
+
+// saveSyncReport writes the report of a full sync if a store is configured
+func (r *NodeReconciler) saveSyncReport(ctx context.Context, log logr.Logger, cfg UpdaterConfig, nodes int,
+	started time.Time, result *updater.UpdateResult, err error) {
+	if cfg.SyncReportStore == nil {
+		return
+	}
+	now := time.Now()
+	report := SyncReport{
+		Time:            metav1.NewTime(now),
+		DurationSeconds: now.Sub(started).Seconds(),
+		Success:         err == nil,
+		Nodes:           nodes,
+		LastError:       r.lastError,
+	}
+	if result != nil {
+		report.RoutesCreated = result.Created
+		report.RoutesDeleted = result.Deleted
+		report.RoutesFailed = result.Failed
+		report.RouteDeletionsSkipped = result.DeletionsSkipped
+	}
+	if !r.lastErrorTime.IsZero() {
+		report.LastErrorTime = &metav1.Time{Time: r.lastErrorTime}
+	}
+	if err := cfg.SyncReportStore.Save(ctx, report); err != nil {
+		log.Error(err, "saving sync report failed")
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// removeNodeTaints removes the taint with the given key from all nodes with programmed routes
+func (r *NodeReconciler) removeNodeTaints(ctx context.Context, taintKey string, patchRetry *nodePatchRetry, routes []updater.NodeRoute) error {
+	var taintErrors error
+	for _, route := range routes {
+		if route.NodeName == "" {
+			continue
+		}
+		if err := retryNodePatch(ctx, patchRetry, func() error {
+			return r.removeNodeTaint(ctx, taintKey, route.NodeName)
+		}); err != nil {
+			taintErrors = multierr.Append(taintErrors, fmt.Errorf("removing taint %s from node %s failed: %w", taintKey, route.NodeName, err))
+		}
+	}
+	return taintErrors
+}
+
+func (r *NodeReconciler) removeNodeTaint(ctx context.Context, taintKey, nodeName string) error {
+	node := &corev1.Node{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	var taints []corev1.Taint
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != taintKey {
+			taints = append(taints, taint)
+		}
+	}
+	if len(taints) == len(node.Spec.Taints) {
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	node.Spec.Taints = taints
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return err
+	}
+	r.log.Info("node taint removed", "node", nodeName, "taint", taintKey)
+	return nil
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+)
+
+// Target is an additional cluster whose node routes are managed by the same controller instance
+type Target struct {
+	// Kubeconfig is the path of the kubeconfig of the target cluster
+	Kubeconfig string
+	// ClusterName is the cluster name used for tagging the route tables of the target cluster
+	ClusterName string
+	// PodNetworkCIDR is the IPv4 pod network of the target cluster
+	PodNetworkCIDR string
+}
+
+// ParseTargets parses targets in the form <kubeconfig>:<cluster-name>:<pod-network-cidr>.
+// The kubeconfig path may contain colons, the cluster names must be unique.
+func ParseTargets(values []string) ([]Target, error) {
+	var targets []Target
+	clusterNames := map[string]bool{}
+	for _, value := range values {
+		rest, podNetworkCIDR, ok := cutLast(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid target %q, expected <kubeconfig>:<cluster-name>:<pod-network-cidr>", value)
+		}
+		kubeconfig, clusterName, ok := cutLast(rest)
+		if !ok || kubeconfig == "" || clusterName == "" {
+			return nil, fmt.Errorf("invalid target %q, expected <kubeconfig>:<cluster-name>:<pod-network-cidr>", value)
+		}
+		cidr, err := util.GetIPv4CIDR([]string{podNetworkCIDR})
+		if err != nil || cidr == "" {
+			return nil, fmt.Errorf("invalid IPv4 pod network CIDR of target %q", value)
+		}
+		if clusterNames[clusterName] {
+			return nil, fmt.Errorf("duplicate cluster name %q of target %q", clusterName, value)
+		}
+		clusterNames[clusterName] = true
+		targets = append(targets, Target{Kubeconfig: kubeconfig, ClusterName: clusterName, PodNetworkCIDR: cidr})
+	}
+	return targets, nil
+}
+
+// cutLast slices the value around the last colon
+func cutLast(value string) (before, after string, found bool) {
+	i := strings.LastIndex(value, ":")
+	if i < 0 {
+		return value, "", false
+	}
+	return value[:i], value[i+1:], true
+}
@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/controller"
+	"github.com/gardener/aws-custom-route-controller/pkg/inventory"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	ec2fake "github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Targets", func() {
+	It("should parse the targets", func() {
+		targets, err := controller.ParseTargets([]string{
+			"/etc/a/kubeconfig:cluster-a:10.1.0.0/16",
+			"C:/b/kubeconfig:cluster-b:10.2.0.0/16",
+		})
+		Expect(err).To(BeNil())
+		Expect(targets).To(Equal([]controller.Target{
+			{Kubeconfig: "/etc/a/kubeconfig", ClusterName: "cluster-a", PodNetworkCIDR: "10.1.0.0/16"},
+			{Kubeconfig: "C:/b/kubeconfig", ClusterName: "cluster-b", PodNetworkCIDR: "10.2.0.0/16"},
+		}))
+	})
+
+	DescribeTable("should reject invalid targets",
+		func(values []string, message string) {
+			_, err := controller.ParseTargets(values)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without separators", []string{"kubeconfig"}, "expected <kubeconfig>:<cluster-name>:<pod-network-cidr>"),
+		Entry("without cluster name", []string{"kubeconfig::10.1.0.0/16"}, "expected <kubeconfig>:<cluster-name>:<pod-network-cidr>"),
+		Entry("without kubeconfig", []string{":cluster-a:10.1.0.0/16"}, "expected <kubeconfig>:<cluster-name>:<pod-network-cidr>"),
+		Entry("with invalid pod network", []string{"kubeconfig:cluster-a:10.1.0.0"}, "invalid IPv4 pod network CIDR"),
+		Entry("with duplicate cluster name", []string{"a:cluster-a:10.1.0.0/16", "b:cluster-a:10.2.0.0/16"}, "duplicate cluster name"),
+	)
+
+	It("should manage the routes of two targets in their own route tables", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		targets, err := controller.ParseTargets([]string{"a/kubeconfig:cluster-a:10.1.0.0/16", "b/kubeconfig:cluster-b:10.2.0.0/16"})
+		Expect(err).To(BeNil())
+
+		cloud := ec2fake.NewEC2()
+		for i, target := range targets {
+			cloud.AddRouteTable(&ec2.RouteTable{
+				RouteTableId: aws.String(fmt.Sprintf("rtb-000%d", i)),
+				Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey(target.ClusterName)), Value: aws.String("1")}},
+			})
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(fmt.Sprintf("i-000%d", i))})
+		}
+		for i, target := range targets {
+			c := fake.NewClientBuilder().WithObjects(makeNode("node", fmt.Sprintf("i-000%d", i), fmt.Sprintf("10.%d.0.0/24", i+1))).
+				WithStatusSubresource(&corev1.Node{}).Build()
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName(target.ClusterName), cloud, target.ClusterName, target.PodNetworkCIDR, updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+			r := controller.NewNodeReconciler(c, logf.Log.WithName(target.ClusterName), elected, record.NewFakeRecorder(100))
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node"}})
+			Expect(err).To(BeNil())
+			r.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+				TickPeriod:        10 * time.Millisecond,
+				SyncPeriod:        20 * time.Millisecond,
+				MaxDelayOnFailure: time.Second,
+			})
+		}
+
+		routes := func(tableID string) func() map[string]string {
+			return func() map[string]string {
+				routes := map[string]string{}
+				for _, route := range cloud.RouteTable(tableID).Routes {
+					routes[aws.StringValue(route.DestinationCidrBlock)] = aws.StringValue(route.InstanceId)
+				}
+				return routes
+			}
+		}
+		Eventually(routes("rtb-0000")).Should(Equal(map[string]string{"10.1.0.0/24": "i-0000"}))
+		Eventually(routes("rtb-0001")).Should(Equal(map[string]string{"10.2.0.0/24": "i-0001"}))
+		Consistently(routes("rtb-0000"), 100*time.Millisecond).Should(Equal(map[string]string{"10.1.0.0/24": "i-0000"}))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(0))
+	})
+
+	It("should keep the inventory and the stores of each target in its own cluster", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		elected := make(chan struct{})
+		close(elected)
+		targets, err := controller.ParseTargets([]string{"a/kubeconfig:cluster-a:10.1.0.0/16", "b/kubeconfig:cluster-b:10.2.0.0/16"})
+		Expect(err).To(BeNil())
+
+		cloud := ec2fake.NewEC2()
+		for i, target := range targets {
+			cloud.AddRouteTable(&ec2.RouteTable{
+				RouteTableId: aws.String(fmt.Sprintf("rtb-000%d", i)),
+				Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey(target.ClusterName)), Value: aws.String("1")}},
+			})
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(fmt.Sprintf("i-000%d", i))})
+		}
+		routeState := &unstructured.Unstructured{}
+		routeState.SetGroupVersionKind(controller.RouteStateGroupVersionKind)
+		clients := make([]client.Client, len(targets))
+		inventories := make([]*inventory.Inventory, len(targets))
+		for i, target := range targets {
+			clients[i] = fake.NewClientBuilder().WithObjects(makeNode("node", fmt.Sprintf("i-000%d", i), fmt.Sprintf("10.%d.0.0/24", i+1))).
+				WithStatusSubresource(&corev1.Node{}, routeState).Build()
+			inventories[i] = inventory.New(target.ClusterName, 10)
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName(target.ClusterName), cloud, target.ClusterName, target.PodNetworkCIDR, updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+			r := controller.NewNodeReconciler(clients[i], logf.Log.WithName(target.ClusterName), elected, record.NewFakeRecorder(100))
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "node"}})
+			Expect(err).To(BeNil())
+			r.StartUpdater(ctx, customRoutes.Update, controller.UpdaterConfig{
+				ClusterName:       target.ClusterName,
+				TickPeriod:        10 * time.Millisecond,
+				SyncPeriod:        time.Hour,
+				MaxDelayOnFailure: time.Second,
+				Inventory:         inventories[i],
+				InventoryStore:    inventory.NewConfigMapStore(clients[i], metav1.NamespaceSystem, "route-inventory"),
+				SyncReportStore:   controller.NewSyncReportStore(clients[i], metav1.NamespaceSystem, "route-sync-report"),
+				RouteStateStore:   controller.NewRouteStateStore(clients[i], metav1.NamespaceSystem, "routes"),
+			})
+		}
+
+		for i, target := range targets {
+			podCIDR := fmt.Sprintf("10.%d.0.0/24", i+1)
+			routeTable := fmt.Sprintf("rtb-000%d", i)
+			expected := []inventory.Entry{{NodeName: "node", PodCIDR: podCIDR, InstanceID: fmt.Sprintf("i-000%d", i), RouteTables: []string{routeTable}}}
+			Eventually(inventories[i].Entries).Should(Equal(expected))
+			Eventually(func() ([]inventory.Entry, error) {
+				return inventory.NewConfigMapStore(clients[i], metav1.NamespaceSystem, "route-inventory").Load(ctx)
+			}).Should(Equal(expected))
+			Eventually(func() float64 {
+				return testutil.ToFloat64(metrics.ManagedRouteInfo.WithLabelValues(target.ClusterName, "node", podCIDR, routeTable))
+			}).Should(Equal(1.0))
+			Eventually(func() error {
+				return clients[i].Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "route-sync-report"}, &corev1.ConfigMap{})
+			}).Should(Succeed())
+			Eventually(func() ([]interface{}, error) {
+				routeState := &unstructured.Unstructured{}
+				routeState.SetGroupVersionKind(controller.RouteStateGroupVersionKind)
+				if err := clients[i].Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "routes"}, routeState); err != nil {
+					return nil, err
+				}
+				tables, _, err := unstructured.NestedSlice(routeState.Object, "status", "routeTables")
+				return tables, err
+			}).Should(ConsistOf(HaveKeyWithValue("id", routeTable)))
+		}
+	})
+})
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// TerminatingNodeRoutePolicyKeep keeps the route of a terminating node until the node is gone
+	TerminatingNodeRoutePolicyKeep = "keep"
+	// TerminatingNodeRoutePolicyRemove removes the route of a node as soon as it has a deletion timestamp
+	TerminatingNodeRoutePolicyRemove = "remove"
+)
+
+// SetTerminatingNodeRoutePolicy sets the handling of routes of nodes with a deletion timestamp, which still exist
+// because of finalizers
+func (r *NodeReconciler) SetTerminatingNodeRoutePolicy(policy string) error {
+	switch policy {
+	case "", TerminatingNodeRoutePolicyKeep:
+		r.removeTerminatingNodeRoutes = false
+	case TerminatingNodeRoutePolicyRemove:
+		r.removeTerminatingNodeRoutes = true
+	default:
+		return fmt.Errorf("invalid terminating node route policy %q", policy)
+	}
+	return nil
+}
+
+// isTerminatingNodeRouteRemoved returns true if the node is terminating and its route must be removed
+func (r *NodeReconciler) isTerminatingNodeRouteRemoved(node *corev1.Node) bool {
+	return r.removeTerminatingNodeRoutes && node.DeletionTimestamp != nil
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WorkerPool restricts the nodes managed by the reconciler to the nodes with the given label value.
+// The routes of nodes in other pools are never deleted, so that one controller per pool can share the route tables.
+type WorkerPool struct {
+	// Label is the key of the node label identifying the worker pool
+	Label string
+	// Value is the label value of the managed worker pool
+	Value string
+}
+
+// contains returns true if the node belongs to the worker pool
+func (p *WorkerPool) contains(node *corev1.Node) bool {
+	return node.Labels[p.Label] == p.Value
+}
+
+// SetWorkerPool restricts the managed nodes to the given worker pool
+func (r *NodeReconciler) SetWorkerPool(pool WorkerPool) {
+	r.workerPool = &pool
+}
+
+// addOtherPoolNodeRoute remembers the route of a node of another worker pool to keep it during cleanup
+func (r *NodeReconciler) addOtherPoolNodeRoute(node *corev1.Node) {
+	r.removeNodeRoute(node.Name)
+	if route, _ := r.otherPoolRoutes.AddNodeRoute(node); route == nil {
+		r.otherPoolRoutes.RemoveNodeRoute(node.Name)
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// Entry maps a node to its pod CIDR and the route tables containing its route
+type Entry struct {
+	NodeName    string   `json:"nodeName"`
+	PodCIDR     string   `json:"podCIDR"`
+	InstanceID  string   `json:"instanceID,omitempty"`
+	RouteTables []string `json:"routeTables,omitempty"`
+}
+
+// Inventory keeps track of the routes programmed for the nodes
+type Inventory struct {
+	sync.RWMutex
+	clusterName      string
+	entries          map[string]Entry
+	maxMetricSeries  int
+	metricsTruncated bool
+}
+
+// New creates an empty inventory of the target cluster. The mappings are exported as metric as long as the number of
+// series does not exceed maxMetricSeries (0 disables the metric).
+func New(clusterName string, maxMetricSeries int) *Inventory {
+	return &Inventory{
+		clusterName:     clusterName,
+		entries:         map[string]Entry{},
+		maxMetricSeries: maxMetricSeries,
+	}
+}
+
+// Update replaces all entries and returns true if the inventory has changed
+func (i *Inventory) Update(entries []Entry) bool {
+	newEntries := map[string]Entry{}
+	for _, entry := range entries {
+		entry.RouteTables = append([]string(nil), entry.RouteTables...)
+		sort.Strings(entry.RouteTables)
+		newEntries[entry.NodeName] = entry
+	}
+
+	i.Lock()
+	defer i.Unlock()
+	if reflect.DeepEqual(i.entries, newEntries) {
+		return false
+	}
+	i.entries = newEntries
+	i.updateMetrics()
+	return true
+}
+
+// Get returns the entry of a node
+func (i *Inventory) Get(nodeName string) (Entry, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	entry, ok := i.entries[nodeName]
+	return entry, ok
+}
+
+// GetByPodCIDR returns the entry of the node with the given pod CIDR
+func (i *Inventory) GetByPodCIDR(podCIDR string) (Entry, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	for _, entry := range i.entries {
+		if entry.PodCIDR == podCIDR {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Entries returns all entries sorted by node name
+func (i *Inventory) Entries() []Entry {
+	i.RLock()
+	defer i.RUnlock()
+	entries := make([]Entry, 0, len(i.entries))
+	for _, entry := range i.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].NodeName < entries[b].NodeName })
+	return entries
+}
+
+// ServeHTTP returns the entries as JSON
+func (i *Inventory) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(i.Entries()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// updateMetrics exports the mappings unless the number of series would exceed the maximum,
+// keeping the series of the other target clusters
+func (i *Inventory) updateMetrics() {
+	metrics.ResetCluster(metrics.ManagedRouteInfo, i.clusterName)
+	if i.maxMetricSeries <= 0 {
+		return
+	}
+	series := 0
+	for _, entry := range i.entries {
+		series += len(entry.RouteTables)
+	}
+	i.metricsTruncated = series > i.maxMetricSeries
+	if i.metricsTruncated {
+		return
+	}
+	for _, entry := range i.entries {
+		for _, table := range entry.RouteTables {
+			metrics.ManagedRouteInfo.WithLabelValues(i.clusterName, entry.NodeName, entry.PodCIDR, table).Set(1)
+		}
+	}
+}
+
+// MetricsTruncated returns true if the mappings are not exported as metric because of too many series
+func (i *Inventory) MetricsTruncated() bool {
+	i.RLock()
+	defer i.RUnlock()
+	return i.metricsTruncated
+}
@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/inventory"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Inventory", func() {
+	var entries []inventory.Entry
+
+	BeforeEach(func() {
+		entries = []inventory.Entry{
+			{NodeName: "node2", PodCIDR: "10.243.2.0/24", InstanceID: "i-0002", RouteTables: []string{"rtb-2", "rtb-1"}},
+			{NodeName: "node1", PodCIDR: "10.243.1.0/24", InstanceID: "i-0001", RouteTables: []string{"rtb-1"}},
+		}
+	})
+
+	It("should look up entries by node name and pod CIDR", func() {
+		inv := inventory.New("shoot", 10)
+		Expect(inv.Update(entries)).To(BeTrue())
+		Expect(inv.Update(entries)).To(BeFalse())
+
+		entry, ok := inv.Get("node2")
+		Expect(ok).To(BeTrue())
+		Expect(entry.PodCIDR).To(Equal("10.243.2.0/24"))
+		Expect(entry.RouteTables).To(Equal([]string{"rtb-1", "rtb-2"}))
+
+		entry, ok = inv.GetByPodCIDR("10.243.1.0/24")
+		Expect(ok).To(BeTrue())
+		Expect(entry.NodeName).To(Equal("node1"))
+
+		_, ok = inv.Get("node3")
+		Expect(ok).To(BeFalse())
+		_, ok = inv.GetByPodCIDR("10.243.3.0/24")
+		Expect(ok).To(BeFalse())
+
+		Expect(inv.Entries()).To(HaveLen(2))
+		Expect(inv.Entries()[0].NodeName).To(Equal("node1"))
+	})
+
+	It("should serve the entries as JSON", func() {
+		inv := inventory.New("shoot", 10)
+		inv.Update(entries)
+
+		rec := httptest.NewRecorder()
+		inv.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/inventory", nil))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		var served []inventory.Entry
+		Expect(json.Unmarshal(rec.Body.Bytes(), &served)).To(Succeed())
+		Expect(served).To(Equal(inv.Entries()))
+	})
+
+	It("should export the mappings as metric with bounded cardinality", func() {
+		inv := inventory.New("shoot", 3)
+		inv.Update(entries)
+		Expect(inv.MetricsTruncated()).To(BeFalse())
+		Expect(testutil.CollectAndCount(metrics.ManagedRouteInfo)).To(Equal(3))
+		Expect(testutil.ToFloat64(metrics.ManagedRouteInfo.WithLabelValues("shoot", "node2", "10.243.2.0/24", "rtb-2"))).To(Equal(1.0))
+
+		inv = inventory.New("shoot", 2)
+		inv.Update(entries)
+		Expect(inv.MetricsTruncated()).To(BeTrue())
+		Expect(testutil.CollectAndCount(metrics.ManagedRouteInfo)).To(Equal(0))
+	})
+
+	It("should keep the metric series of the other target clusters", func() {
+		inv := inventory.New("cluster-a", 10)
+		inv.Update(entries)
+		other := inventory.New("cluster-b", 10)
+		other.Update([]inventory.Entry{{NodeName: "node1", PodCIDR: "10.2.1.0/24", RouteTables: []string{"rtb-3"}}})
+		Expect(testutil.CollectAndCount(metrics.ManagedRouteInfo)).To(Equal(4))
+		Expect(testutil.ToFloat64(metrics.ManagedRouteInfo.WithLabelValues("cluster-b", "node1", "10.2.1.0/24", "rtb-3"))).To(Equal(1.0))
+
+		inv.Update(entries[:1])
+		Expect(testutil.CollectAndCount(metrics.ManagedRouteInfo)).To(Equal(3))
+		Expect(testutil.ToFloat64(metrics.ManagedRouteInfo.WithLabelValues("cluster-b", "node1", "10.2.1.0/24", "rtb-3"))).To(Equal(1.0))
+
+		other.Update(nil)
+		Expect(testutil.CollectAndCount(metrics.ManagedRouteInfo)).To(Equal(2))
+		inv.Update(nil)
+		Expect(testutil.CollectAndCount(metrics.ManagedRouteInfo)).To(BeZero())
+	})
+
+	Context("ConfigMapStore", func() {
+		var (
+			ctx   = context.Background()
+			c     client.Client
+			store *inventory.ConfigMapStore
+		)
+
+		BeforeEach(func() {
+			c = fake.NewClientBuilder().Build()
+			store = inventory.NewConfigMapStore(c, "kube-system", "route-inventory")
+		})
+
+		It("should return no entries if the config map is missing", func() {
+			loaded, err := store.Load(ctx)
+			Expect(err).To(BeNil())
+			Expect(loaded).To(BeEmpty())
+		})
+
+		It("should persist the entries", func() {
+			inv := inventory.New("shoot", 0)
+			inv.Update(entries)
+			Expect(store.Save(ctx, inv.Entries())).To(Succeed())
+
+			loaded, err := store.Load(ctx)
+			Expect(err).To(BeNil())
+			Expect(loaded).To(Equal(inv.Entries()))
+
+			inv.Update(entries[:1])
+			Expect(store.Save(ctx, inv.Entries())).To(Succeed())
+
+			restored := inventory.New("shoot", 0)
+			loaded, err = store.Load(ctx)
+			Expect(err).To(BeNil())
+			restored.Update(loaded)
+			entry, ok := restored.Get("node2")
+			Expect(ok).To(BeTrue())
+			Expect(entry.RouteTables).To(Equal([]string{"rtb-1", "rtb-2"}))
+			_, ok = restored.Get("node1")
+			Expect(ok).To(BeFalse())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: "route-inventory"}, cm)).To(Succeed())
+			Expect(cm.Data).To(HaveKey("inventory.json"))
+		})
+
+		It("should compress large inventories and switch back to plain ones", func() {
+			large := make([]inventory.Entry, 0, 5000)
+			for i := 0; i < 5000; i++ {
+				large = append(large, inventory.Entry{
+					NodeName:    fmt.Sprintf("node-%d", i),
+					PodCIDR:     fmt.Sprintf("10.%d.%d.0/24", i/256, i%256),
+					InstanceID:  fmt.Sprintf("i-%08x", i),
+					RouteTables: []string{"rtb-1", "rtb-2"},
+				})
+			}
+			store.SetCompressionThreshold(64 * 1024)
+			Expect(store.Save(ctx, large)).To(Succeed())
+
+			cm := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: "route-inventory"}, cm)).To(Succeed())
+			Expect(cm.Data).To(HaveKey("inventory.json.gz"))
+			Expect(cm.Data).NotTo(HaveKey("inventory.json"))
+			loaded, err := store.Load(ctx)
+			Expect(err).To(BeNil())
+			Expect(loaded).To(Equal(large))
+
+			Expect(store.Save(ctx, entries)).To(Succeed())
+			cm = &corev1.ConfigMap{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: "route-inventory"}, cm)).To(Succeed())
+			Expect(cm.Data).To(HaveKey("inventory.json"))
+			Expect(cm.Data).NotTo(HaveKey("inventory.json.gz"))
+			loaded, err = store.Load(ctx)
+			Expect(err).To(BeNil())
+			Expect(loaded).To(Equal(entries))
+		})
+
+		It("should fail on invalid content", func() {
+			Expect(c.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "route-inventory"},
+				Data:       map[string]string{"inventory.json": "{"},
+			})).To(Succeed())
+			_, err := store.Load(ctx)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
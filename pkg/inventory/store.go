@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// dataKey is the key of the inventory in the config map data
+	dataKey = "inventory.json"
+	// compressedDataKey is the key of the gzip compressed and base64 encoded inventory in the config map data
+	compressedDataKey = "inventory.json.gz"
+	// DefaultCompressionThreshold is the size of the inventory in bytes above which it is stored compressed
+	DefaultCompressionThreshold = 256 * 1024
+)
+
+// ConfigMapStore persists the inventory entries in a config map
+type ConfigMapStore struct {
+	client               client.Client
+	namespace            string
+	name                 string
+	compressionThreshold int
+}
+
+// NewConfigMapStore creates a store for the config map with the given namespace and name
+func NewConfigMapStore(client client.Client, namespace, name string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client:               client,
+		namespace:            namespace,
+		name:                 name,
+		compressionThreshold: DefaultCompressionThreshold,
+	}
+}
+
+// SetCompressionThreshold sets the size of the inventory in bytes above which it is stored compressed (0 always compresses it)
+func (s *ConfigMapStore) SetCompressionThreshold(threshold int) {
+	s.compressionThreshold = threshold
+}
+
+// payload returns the config map data key and value of the marshalled entries, compressed if exceeding the threshold
+func (s *ConfigMapStore) payload(data []byte) (string, string, error) {
+	if len(data) <= s.compressionThreshold {
+		return dataKey, string(data), nil
+	}
+	compressed, err := util.CompressPayload(data)
+	if err != nil {
+		return "", "", err
+	}
+	return compressedDataKey, compressed, nil
+}
+
+// Save writes the entries to the config map, creating it if needed
+func (s *ConfigMapStore) Save(ctx context.Context, entries []Entry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	key, value, err := s.payload(data)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.name,
+			},
+			Data: map[string]string{key: value},
+		}
+		return s.client.Create(ctx, cm)
+	}
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(cm.DeepCopy())
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	delete(cm.Data, dataKey)
+	delete(cm.Data, compressedDataKey)
+	cm.Data[key] = value
+	return s.client.Patch(ctx, cm, patch)
+}
+
+// Load reads the entries from the config map. A missing config map results in no entries.
+func (s *ConfigMapStore) Load(ctx context.Context) ([]Entry, error) {
+	cm := &corev1.ConfigMap{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: s.name}, cm); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	var data []byte
+	if compressed, ok := cm.Data[compressedDataKey]; ok {
+		var err error
+		if data, err = util.DecompressPayload(compressed); err != nil {
+			return nil, fmt.Errorf("invalid compressed inventory in config map %s/%s: %w", s.namespace, s.name, err)
+		}
+	} else if plain, ok := cm.Data[dataKey]; ok {
+		data = []byte(plain)
+	} else {
+		return nil, nil
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid inventory in config map %s/%s: %w", s.namespace, s.name, err)
+	}
+	return entries, nil
+}
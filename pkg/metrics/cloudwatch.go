@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// MetricDataPutter is the subset of the CloudWatch API used for publishing metrics
+type MetricDataPutter interface {
+	PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+// cloudWatchMetric describes a metric published to CloudWatch
+type cloudWatchMetric struct {
+	name    string
+	counter bool
+}
+
+// cloudWatchMetrics maps the names of the published Prometheus metrics to the CloudWatch metric names.
+// For counters, the increase since the last publication is pushed.
+var cloudWatchMetrics = map[string]cloudWatchMetric{
+	namespace + "_routes_created_total": {name: "RoutesCreated", counter: true},
+	namespace + "_routes_deleted_total": {name: "RoutesDeleted", counter: true},
+	namespace + "_update_errors_total":  {name: "UpdateErrors", counter: true},
+	namespace + "_managed_routes":       {name: "ManagedRoutes"},
+	namespace + "_stale_routes":         {name: "StaleRoutes"},
+}
+
+// CloudWatchPublisher pushes the key controller metrics to CloudWatch periodically
+type CloudWatchPublisher struct {
+	log         logr.Logger
+	client      MetricDataPutter
+	namespace   string
+	clusterName string
+	interval    time.Duration
+	gatherer    prometheus.Gatherer
+	// lastCounters contains the counter values of the last publication
+	lastCounters map[string]float64
+}
+
+// NewCloudWatchPublisher creates a publisher for the given CloudWatch namespace. The metrics are published with
+// the dimension ClusterName.
+func NewCloudWatchPublisher(log logr.Logger, client MetricDataPutter, namespace, clusterName string, interval time.Duration) *CloudWatchPublisher {
+	return &CloudWatchPublisher{
+		log:          log,
+		client:       client,
+		namespace:    namespace,
+		clusterName:  clusterName,
+		interval:     interval,
+		gatherer:     metrics.Registry,
+		lastCounters: map[string]float64{},
+	}
+}
+
+// Start publishes the metrics every interval until the context is done
+func (p *CloudWatchPublisher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.Publish(); err != nil {
+				p.log.Error(err, "publishing metrics to CloudWatch failed")
+			}
+		}
+	}
+}
+
+// clusterSeries returns the series of the metric family for the cluster of the publisher,
+// which is the only series of metrics without cluster label
+func (p *CloudWatchPublisher) clusterSeries(family *dto.MetricFamily) *dto.Metric {
+	for _, series := range family.GetMetric() {
+		cluster := ""
+		for _, label := range series.GetLabel() {
+			if label.GetName() == ClusterLabel {
+				cluster = label.GetValue()
+			}
+		}
+		if cluster == "" || cluster == p.clusterName {
+			return series
+		}
+	}
+	return nil
+}
+
+// Publish pushes the current metric values to CloudWatch
+func (p *CloudWatchPublisher) Publish() error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("gathering metrics failed: %w", err)
+	}
+	var (
+		now     = time.Now()
+		data    []*cloudwatch.MetricDatum
+		current = map[string]float64{}
+	)
+	for _, family := range families {
+		metric, ok := cloudWatchMetrics[family.GetName()]
+		if !ok {
+			continue
+		}
+		series := p.clusterSeries(family)
+		if series == nil {
+			continue
+		}
+		var value float64
+		if metric.counter {
+			total := series.GetCounter().GetValue()
+			current[metric.name] = total
+			value = total - p.lastCounters[metric.name]
+		} else {
+			value = series.GetGauge().GetValue()
+		}
+		data = append(data, &cloudwatch.MetricDatum{
+			MetricName: aws.String(metric.name),
+			Dimensions: []*cloudwatch.Dimension{{Name: aws.String("ClusterName"), Value: aws.String(p.clusterName)}},
+			Timestamp:  aws.Time(now),
+			Unit:       aws.String(cloudwatch.StandardUnitCount),
+			Value:      aws.Float64(value),
+		})
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if _, err := p.client.PutMetricData(&cloudwatch.PutMetricDataInput{
+		Namespace:  aws.String(p.namespace),
+		MetricData: data,
+	}); err != nil {
+		return fmt.Errorf("putting metric data failed: %w", err)
+	}
+	p.lastCounters = current
+	return nil
+}
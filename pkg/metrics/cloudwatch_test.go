@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeCloudWatch struct {
+	inputs []*cloudwatch.PutMetricDataInput
+	err    error
+}
+
+func (f *fakeCloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.inputs = append(f.inputs, input)
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+func datumValues(input *cloudwatch.PutMetricDataInput) map[string]float64 {
+	values := map[string]float64{}
+	for _, datum := range input.MetricData {
+		Expect(datum.Dimensions).To(ConsistOf(&cloudwatch.Dimension{Name: aws.String("ClusterName"), Value: aws.String("shoot--foo--bar")}))
+		values[aws.StringValue(datum.MetricName)] = aws.Float64Value(datum.Value)
+	}
+	return values
+}
+
+var _ = Describe("CloudWatchPublisher", func() {
+	var (
+		client    *fakeCloudWatch
+		publisher *metrics.CloudWatchPublisher
+	)
+
+	BeforeEach(func() {
+		client = &fakeCloudWatch{}
+		publisher = metrics.NewCloudWatchPublisher(logf.Log.WithName("test"), client, "Custom/Routes", "shoot--foo--bar", 0)
+		// publish once to only observe the increases of the counters caused by the test
+		Expect(publisher.Publish()).To(Succeed())
+		client.inputs = nil
+	})
+
+	It("should publish gauges and counter increases", func() {
+		metrics.RoutesCreated.Add(3)
+		metrics.RoutesDeleted.Inc()
+		metrics.ManagedRoutes.WithLabelValues("shoot--foo--bar").Set(7)
+		metrics.ManagedRoutes.WithLabelValues("shoot--foo--other").Set(9)
+		metrics.StaleRoutes.WithLabelValues("shoot--foo--bar").Set(1)
+
+		Expect(publisher.Publish()).To(Succeed())
+		Expect(client.inputs).To(HaveLen(1))
+		Expect(client.inputs[0].Namespace).To(Equal(aws.String("Custom/Routes")))
+		Expect(datumValues(client.inputs[0])).To(Equal(map[string]float64{
+			"RoutesCreated": 3,
+			"RoutesDeleted": 1,
+			"UpdateErrors":  0,
+			"ManagedRoutes": 7,
+			"StaleRoutes":   1,
+		}))
+
+		metrics.RoutesCreated.Inc()
+		metrics.UpdateErrors.Inc()
+		Expect(publisher.Publish()).To(Succeed())
+		Expect(client.inputs).To(HaveLen(2))
+		Expect(datumValues(client.inputs[1])).To(Equal(map[string]float64{
+			"RoutesCreated": 1,
+			"RoutesDeleted": 0,
+			"UpdateErrors":  1,
+			"ManagedRoutes": 7,
+			"StaleRoutes":   1,
+		}))
+	})
+
+	It("should publish the counter increases again after a failed publication", func() {
+		metrics.RoutesCreated.Add(2)
+		client.err = fmt.Errorf("throttled")
+		Expect(publisher.Publish()).To(MatchError(ContainSubstring("throttled")))
+
+		client.err = nil
+		Expect(publisher.Publish()).To(Succeed())
+		Expect(client.inputs).To(HaveLen(1))
+		Expect(datumValues(client.inputs[0])).To(HaveKeyWithValue("RoutesCreated", 2.0))
+	})
+})
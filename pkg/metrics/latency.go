@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// LatencyTypeHistogram exports the latencies as histograms (default)
+	LatencyTypeHistogram = "histogram"
+	// LatencyTypeSummary exports the latencies as summaries with precomputed percentiles
+	LatencyTypeSummary = "summary"
+)
+
+// summaryObjectives are the percentiles of the latency summaries with their allowed errors
+var summaryObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+// latencyMetric is an observer of latencies, exported as histogram or summary
+type latencyMetric struct {
+	name   string
+	help   string
+	labels []string
+	vec    prometheus.ObserverVec
+}
+
+var (
+	latencyLock sync.RWMutex
+	// reconcileLatency is the duration of the node reconciles
+	reconcileLatency = &latencyMetric{
+		name: "reconcile_duration_seconds",
+		help: "Duration of the node reconciles in seconds.",
+	}
+	// awsRequestLatency is the duration of the AWS requests including retries by service and operation
+	awsRequestLatency = &latencyMetric{
+		name:   "aws_request_duration_seconds",
+		help:   "Duration of the AWS requests including retries in seconds by service and operation.",
+		labels: []string{"service", "operation"},
+	}
+	latencyMetrics = []*latencyMetric{reconcileLatency, awsRequestLatency}
+)
+
+func init() {
+	for _, m := range latencyMetrics {
+		m.vec = m.newVec(LatencyTypeHistogram)
+		metrics.Registry.MustRegister(m.vec)
+	}
+}
+
+func (m *latencyMetric) newVec(latencyType string) prometheus.ObserverVec {
+	if latencyType == LatencyTypeSummary {
+		return prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  namespace,
+			Name:       m.name,
+			Help:       m.help,
+			Objectives: summaryObjectives,
+		}, m.labels)
+	}
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      m.name,
+		Help:      m.help,
+		Buckets:   prometheus.DefBuckets,
+	}, m.labels)
+}
+
+// SetLatencyType replaces the registered latency metrics by ones of the given type
+func SetLatencyType(latencyType string) error {
+	switch latencyType {
+	case LatencyTypeHistogram, LatencyTypeSummary:
+	default:
+		return fmt.Errorf("invalid latency type %q", latencyType)
+	}
+	latencyLock.Lock()
+	defer latencyLock.Unlock()
+	for _, m := range latencyMetrics {
+		vec := m.newVec(latencyType)
+		metrics.Registry.Unregister(m.vec)
+		if err := metrics.Registry.Register(vec); err != nil {
+			return err
+		}
+		m.vec = vec
+	}
+	return nil
+}
+
+// observe records the latency since the start time
+func (m *latencyMetric) observe(start time.Time, labelValues ...string) {
+	latencyLock.RLock()
+	defer latencyLock.RUnlock()
+	m.vec.WithLabelValues(labelValues...).Observe(time.Since(start).Seconds())
+}
+
+// ObserveReconcileLatency records the duration of a node reconcile started at the given time
+func ObserveReconcileLatency(start time.Time) {
+	reconcileLatency.observe(start)
+}
+
+// ObserveAWSRequestLatency records the duration of an AWS request started at the given time
+func ObserveAWSRequestLatency(service, operation string, start time.Time) {
+	awsRequestLatency.observe(start, service, operation)
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// registeredTypes returns the types of the registered latency metric families by name
+func registeredTypes() map[string]dto.MetricType {
+	families, err := ctrlmetrics.Registry.Gather()
+	Expect(err).To(BeNil())
+	types := map[string]dto.MetricType{}
+	for _, family := range families {
+		switch family.GetName() {
+		case "aws_custom_route_controller_reconcile_duration_seconds", "aws_custom_route_controller_aws_request_duration_seconds":
+			types[family.GetName()] = family.GetType()
+		}
+	}
+	return types
+}
+
+var _ = Describe("latency metrics", func() {
+	AfterEach(func() {
+		Expect(metrics.SetLatencyType(metrics.LatencyTypeHistogram)).To(Succeed())
+	})
+
+	DescribeTable("should register the chosen metric type",
+		func(latencyType string, expected dto.MetricType) {
+			Expect(metrics.SetLatencyType(latencyType)).To(Succeed())
+			metrics.ObserveReconcileLatency(time.Now().Add(-time.Second))
+			metrics.ObserveAWSRequestLatency("ec2", "DescribeRouteTables", time.Now().Add(-time.Second))
+
+			Expect(registeredTypes()).To(Equal(map[string]dto.MetricType{
+				"aws_custom_route_controller_reconcile_duration_seconds":   expected,
+				"aws_custom_route_controller_aws_request_duration_seconds": expected,
+			}))
+		},
+		Entry("histogram", metrics.LatencyTypeHistogram, dto.MetricType_HISTOGRAM),
+		Entry("summary", metrics.LatencyTypeSummary, dto.MetricType_SUMMARY),
+	)
+
+	It("should reject an invalid latency type", func() {
+		Expect(metrics.SetLatencyType("invalid")).To(MatchError(ContainSubstring("invalid latency type")))
+	})
+})
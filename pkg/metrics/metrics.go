@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "aws_custom_route_controller"
+
+// ClusterLabel is the label of the metrics describing the routes of a single target cluster
+const ClusterLabel = "cluster"
+
+var (
+	// NodeCIDRsOutsidePodNetwork is the number of node pod CIDRs not covered by the configured pod network.
+	NodeCIDRsOutsidePodNetwork = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_cidrs_outside_pod_network",
+		Help:      "Number of node pod CIDRs observed at startup which are not covered by the pod network CIDR.",
+	})
+	// RouteTableAssociationChanges counts the subnets observed to be associated with a different route table.
+	RouteTableAssociationChanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_table_association_changes_total",
+		Help:      "Number of subnets found associated with a different route table than on the previous observation.",
+	})
+	// StaleRoutes is the number of managed routes whose target does not exist anymore.
+	StaleRoutes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stale_routes",
+		Help:      "Number of routes in the pod network with a missing target (blackhole routes) which have not been removed.",
+	}, []string{ClusterLabel})
+	// StaleRoutesMaxAge is the age of the oldest stale route since it was detected first.
+	StaleRoutesMaxAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stale_routes_max_age_seconds",
+		Help:      "Time since the oldest stale route has been detected first.",
+	}, []string{ClusterLabel})
+	// RouteDeletionsAborted counts the updates whose route deletions have been skipped because of too many deletions.
+	RouteDeletionsAborted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_deletions_aborted_total",
+		Help:      "Number of updates which skipped all route deletions because they exceeded the maximum deletions per update.",
+	})
+	// RouteTablesSkipped counts the route tables not diffed because they are unchanged since found in sync.
+	RouteTablesSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_tables_skipped_total",
+		Help:      "Number of route tables skipped by an update because their routes and the desired routes are unchanged since they were found in sync.",
+	})
+	// UpdateRetryDelay is the current delay for retrying failed updates.
+	UpdateRetryDelay = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "update_retry_delay_seconds",
+		Help:      "Current delay for retrying failed route updates, capped by max-delay-on-failure (0 if the last update succeeded).",
+	}, []string{ClusterLabel})
+	// ShadowedRoutes is the number of node routes overlapping with a more specific route in the same table.
+	ShadowedRoutes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "shadowed_routes",
+		Help:      "Number of node routes which do not take effect completely because of a more specific overlapping route in the same route table.",
+	}, []string{ClusterLabel})
+	// RoutesCreated counts the routes created.
+	RoutesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routes_created_total",
+		Help:      "Number of routes created in the route tables.",
+	})
+	// RoutesDeleted counts the routes deleted.
+	RoutesDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routes_deleted_total",
+		Help:      "Number of routes deleted from the route tables.",
+	})
+	// RoutesNoop counts the desired routes which already existed with the correct target.
+	RoutesNoop = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routes_noop_total",
+		Help:      "Number of desired routes skipped by an update because they already existed with the correct target.",
+	})
+	// RoutesAdopted counts the existing routes adopted at startup.
+	RoutesAdopted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routes_adopted_total",
+		Help:      "Number of existing routes with the correct target adopted by the first update instead of being recreated.",
+	})
+	// UpdateErrors counts the failed route updates.
+	UpdateErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "update_errors_total",
+		Help:      "Number of route updates which failed completely or partially.",
+	})
+	// ManagedRoutes is the number of node routes programmed in the route tables.
+	ManagedRoutes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_routes",
+		Help:      "Number of node routes programmed in the route tables after the last update (one per pod CIDR and route table).",
+	}, []string{ClusterLabel})
+	// ManagedRoutesPerAZ is the number of node routes programmed in the route tables of each availability zone.
+	ManagedRoutesPerAZ = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_routes_per_az",
+		Help:      "Number of node routes programmed in the route tables associated with subnets in the availability zone after the last update.",
+	}, []string{ClusterLabel, "zone"})
+	// InstanceConflicts is the number of instances claimed by multiple nodes.
+	InstanceConflicts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "instance_conflicts",
+		Help:      "Number of instances which multiple nodes resolve to in the last update, only the route of one node is programmed for each.",
+	}, []string{ClusterLabel})
+	// DriftedRoutes counts the programmed routes found missing by the drift detection.
+	DriftedRoutes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "drifted_routes_total",
+		Help:      "Number of programmed routes found missing or with another target by the drift detection.",
+	})
+	// InvalidInstanceIDs counts the nodes skipped because of a malformed instance ID.
+	InvalidInstanceIDs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invalid_instance_ids_total",
+		Help:      "Number of times a node has been skipped because its provider ID contains a malformed instance ID.",
+	})
+	// ReconcileOutcomes counts the outcomes of node reconciles by reason.
+	ReconcileOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_outcomes_total",
+		Help:      "Number of outcomes of node reconciles by reason (success, cidr-invalid, instance-id-invalid, other).",
+	}, []string{"reason"})
+	// UpdateOutcomes counts the outcomes of route updates by reason.
+	UpdateOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "update_outcomes_total",
+		Help:      "Number of outcomes of route updates by reason (success, instance-not-found, throttled, unauthorized, instance-id-invalid, other).",
+	}, []string{"reason"})
+	// ObservedDriftRoutes is the number of routes differing from the desired state in observe mode.
+	ObservedDriftRoutes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "observed_drift_routes",
+		Help:      "Number of missing desired routes and of obsolete managed routes found by the last update in observe mode.",
+	}, []string{ClusterLabel, "type"})
+	// DefaultRoutesRefused counts the attempts to create a route with a default destination like 0.0.0.0/0.
+	DefaultRoutesRefused = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "default_routes_refused_total",
+		Help:      "Number of times creating a route with a default destination (0.0.0.0/0 or ::/0) has been refused.",
+	})
+	// RouteDrift is the number of routes differing between desired and actual state by route table.
+	RouteDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "route_drift_count",
+		Help:      "Number of routes to be created or deleted found by the last full update in the route table.",
+	}, []string{ClusterLabel, "route_table"})
+	// RouteTableMissingDefaultRoute is set for the route tables programmed into which lack an active default route.
+	RouteTableMissingDefaultRoute = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "route_table_missing_default_route",
+		Help:      "Set to 1 for route tables containing node routes but no active default route, so that pods may have no egress.",
+	}, []string{ClusterLabel, "route_table"})
+	// MultiInstanceMatches is the number of instance lookups which returned several instances.
+	MultiInstanceMatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "multi_instance_matches_total",
+		Help:      "Number of instance lookups by instance ID which returned several instances.",
+	})
+	// LastReconcileTimestamp is the time of the last node reconcile, regardless of its outcome.
+	LastReconcileTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_reconcile_timestamp_seconds",
+		Help:      "Unix time of the last node reconcile, regardless of its outcome.",
+	})
+	// CredentialsExpiry is the expiry time of the AWS credentials used for a service.
+	CredentialsExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "credentials_expiry_timestamp_seconds",
+		Help:      "Unix time when the AWS credentials used for the service expire (absent for static credentials).",
+	}, []string{"service"})
+	// ManagedRouteInfo maps node names to pod CIDRs and route tables.
+	ManagedRouteInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_route_info",
+		Help:      "Route of a node pod CIDR programmed in a route table (value is always 1).",
+	}, []string{ClusterLabel, "node", "pod_cidr", "route_table"})
+)
+
+// ResetCluster deletes the series of the gauge vector for the cluster, keeping those of the other target clusters
+func ResetCluster(vec *prometheus.GaugeVec, clusterName string) {
+	vec.DeletePartialMatch(prometheus.Labels{ClusterLabel: clusterName})
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		NodeCIDRsOutsidePodNetwork,
+		RouteTableAssociationChanges,
+		StaleRoutes,
+		StaleRoutesMaxAge,
+		RouteDeletionsAborted,
+		RouteTablesSkipped,
+		UpdateRetryDelay,
+		ShadowedRoutes,
+		RoutesCreated,
+		RoutesDeleted,
+		RoutesNoop,
+		RoutesAdopted,
+		UpdateErrors,
+		ManagedRoutes,
+		ManagedRoutesPerAZ,
+		InstanceConflicts,
+		DriftedRoutes,
+		InvalidInstanceIDs,
+		ReconcileOutcomes,
+		UpdateOutcomes,
+		ObservedDriftRoutes,
+		ManagedRouteInfo,
+		CredentialsExpiry,
+		DefaultRoutesRefused,
+		RouteDrift,
+		RouteTableMissingDefaultRoute,
+		LastReconcileTimestamp,
+		MultiInstanceMatches,
+	)
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUpdateAborted is returned if an update has been aborted before all changes were applied
+var ErrUpdateAborted = errors.New("update aborted")
+
+// aborted returns true if the abort channel is closed. A nil channel never aborts.
+func aborted(abort <-chan struct{}) bool {
+	select {
+	case <-abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for the delay and returns false if the abort channel is closed meanwhile
+func sleep(abort <-chan struct{}, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-abort:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
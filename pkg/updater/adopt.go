@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// existingRoutes contains the managed routes found by the first update, which are adopted if desired
+type existingRoutes struct {
+	captured bool
+	// pending are the keys of the existing routes not adopted yet
+	pending map[string]bool
+}
+
+// captureExistingRoutes remembers the managed routes of the tables before the first update changes them
+func (r *CustomRoutes) captureExistingRoutes(tables []*ec2.RouteTable) {
+	if !r.options.AdoptExistingRoutes || r.existing.captured {
+		return
+	}
+	r.existing = existingRoutes{captured: true, pending: map[string]bool{}}
+	for _, table := range tables {
+		for _, route := range r.managedRoutes(table) {
+			r.existing.pending[staleRouteKey(*table.RouteTableId, *route.DestinationCidrBlock)] = true
+		}
+	}
+}
+
+// adoptExistingRoutes logs the desired routes of the table which existed with the correct target before the first update,
+// e.g. if they have been created manually, and returns their number. Each route is considered only once.
+func (r *CustomRoutes) adoptExistingRoutes(table *ec2.RouteTable, desired, toBeCreated []internalNodeRoute) int {
+	if len(r.existing.pending) == 0 || r.isMainTable(table) {
+		return 0
+	}
+	missing := make(map[string]bool, len(toBeCreated))
+	for _, nr := range toBeCreated {
+		missing[nr.destinationCidrBlock] = true
+	}
+	adopted := 0
+	for _, nr := range desired {
+		key := staleRouteKey(*table.RouteTableId, nr.destinationCidrBlock)
+		if !r.existing.pending[key] {
+			continue
+		}
+		// an existing route with another target is replaced and never adopted
+		delete(r.existing.pending, key)
+		if missing[nr.destinationCidrBlock] {
+			continue
+		}
+		r.log.Info("existing route adopted", "table", *table.RouteTableId, "destination", nr.destinationCidrBlock,
+			"target", nr.target.String(), "node", nr.nodeName)
+		adopted++
+	}
+	return adopted
+}
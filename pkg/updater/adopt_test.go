@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("adopt existing routes", func() {
+	var (
+		cloud  *fake.EC2
+		routes []updater.NodeRoute
+	)
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{
+				{DestinationCidrBlock: aws.String("10.243.1.0/24"), InstanceId: aws.String("i-0001"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				{DestinationCidrBlock: aws.String("10.243.2.0/24"), InstanceId: aws.String("i-0009"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+			},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+		routes = []updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"},
+		}
+	})
+
+	It("should adopt a correct pre-existing route without creating it", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			AdoptExistingRoutes: true,
+		})
+		Expect(err).To(BeNil())
+		before := testutil.ToFloat64(metrics.RoutesAdopted)
+
+		// the route of node2 has the wrong target and is replaced instead
+		result, err := customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Adopted).To(Equal(1))
+		Expect(result.Created).To(Equal(1))
+		Expect(result.NodeRouteTables).To(HaveKeyWithValue("node1", []string{"rtb-0001"}))
+		Expect(testutil.ToFloat64(metrics.RoutesAdopted)).To(Equal(before + 1))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(1))
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(ContainElement(And(
+			HaveField("DestinationCidrBlock", Equal(aws.String("10.243.1.0/24"))),
+			HaveField("InstanceId", Equal(aws.String("i-0001"))),
+		)))
+
+		// the routes are only adopted once
+		result, err = customRoutes.Update(routes, updater.UpdateOptions{Force: true})
+		Expect(err).To(BeNil())
+		Expect(result.Adopted).To(Equal(0))
+		Expect(testutil.ToFloat64(metrics.RoutesAdopted)).To(Equal(before + 1))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(1))
+	})
+
+	It("should adopt the routes existing before the first of several create-only updates", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			AdoptExistingRoutes: true,
+		})
+		Expect(err).To(BeNil())
+
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0003")})
+		node3 := updater.NodeRoute{NodeName: "node3", InstanceID: "i-0003", PodCIDR: "10.243.3.0/24"}
+
+		// the first batch does not contain the route of node1 yet
+		result, err := customRoutes.Update([]updater.NodeRoute{node3}, updater.UpdateOptions{CreateOnly: true})
+		Expect(err).To(BeNil())
+		Expect(result.Adopted).To(Equal(0))
+		result, err = customRoutes.Update(routes[:1], updater.UpdateOptions{CreateOnly: true})
+		Expect(err).To(BeNil())
+		Expect(result.Adopted).To(Equal(1))
+		result, err = customRoutes.Update(append(routes, node3), updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Adopted).To(Equal(0))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(2))
+	})
+
+	It("should not adopt routes without the option", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		result, err := customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Adopted).To(Equal(0))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(1))
+	})
+})
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"net"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+)
+
+// nodeCIDRPrefix returns the shortest prefix length of the desired routes inside of the pod network,
+// or the last known one if there are none (0 if never known)
+func (r *CustomRoutes) nodeCIDRPrefix(desired []internalNodeRoute) int {
+	prefix := 0
+	for _, nr := range desired {
+		_, destination, err := net.ParseCIDR(nr.destinationCidrBlock)
+		if err != nil || !util.ContainsCIDR(&r.podNetwork, destination) {
+			continue
+		}
+		if ones, _ := destination.Mask.Size(); prefix == 0 || ones < prefix {
+			prefix = ones
+		}
+	}
+	if prefix == 0 {
+		return r.aggregatePrefix
+	}
+	return prefix
+}
+
+// aggregateRoutes returns the active routes of the table summarizing several pod CIDRs, i.e. with a destination
+// inside of the pod network which is broader than the pod CIDRs of the nodes. Broader routes like the default route
+// are no aggregates of the pod CIDRs.
+func (r *CustomRoutes) aggregateRoutes(table *ec2.RouteTable) map[string]*net.IPNet {
+	aggregates := map[string]*net.IPNet{}
+	for _, route := range table.Routes {
+		if route.DestinationCidrBlock == nil || isStaleRoute(route) || aws.StringValue(route.GatewayId) == localGatewayID {
+			continue
+		}
+		_, destination, err := net.ParseCIDR(*route.DestinationCidrBlock)
+		if err != nil || !util.ContainsCIDR(&r.podNetwork, destination) {
+			continue
+		}
+		if ones, _ := destination.Mask.Size(); ones >= r.aggregatePrefix {
+			continue
+		}
+		aggregates[*route.DestinationCidrBlock] = destination
+	}
+	return aggregates
+}
+
+// coveringAggregate returns the most specific aggregate strictly containing the destination (empty if none)
+func coveringAggregate(aggregates map[string]*net.IPNet, destination string) string {
+	_, destinationNet, err := net.ParseCIDR(destination)
+	if err != nil {
+		return ""
+	}
+	destinationOnes, _ := destinationNet.Mask.Size()
+	covering, coveringOnes := "", -1
+	for cidr, aggregate := range aggregates {
+		ones, _ := aggregate.Mask.Size()
+		if ones < destinationOnes && ones > coveringOnes && util.ContainsCIDR(aggregate, destinationNet) {
+			covering, coveringOnes = cidr, ones
+		}
+	}
+	return covering
+}
+
+// withoutAggregated removes the desired routes covered by an aggregate route of the table, as they are redundant.
+// It returns the remaining and the covered desired routes, and the destinations of all aggregates of the table,
+// which must be kept even if they cover no node route currently.
+func (r *CustomRoutes) withoutAggregated(table *ec2.RouteTable, desired []internalNodeRoute, aggregated map[string]string) (remaining, covered []internalNodeRoute, keep map[string]bool) {
+	if !r.options.RespectAggregateRoutes || r.isMainTable(table) {
+		return desired, nil, nil
+	}
+	aggregates := r.aggregateRoutes(table)
+	if len(aggregates) == 0 {
+		return desired, nil, nil
+	}
+	keep = map[string]bool{}
+	for cidr := range aggregates {
+		keep[cidr] = true
+	}
+	for _, nr := range desired {
+		aggregate := coveringAggregate(aggregates, nr.destinationCidrBlock)
+		if aggregate == "" {
+			remaining = append(remaining, nr)
+			continue
+		}
+		covered = append(covered, nr)
+		key := staleRouteKey(*table.RouteTableId, nr.destinationCidrBlock)
+		if r.aggregated[key] != aggregate {
+			r.log.Info("route covered by aggregate route, skipped", "table", *table.RouteTableId, "destination", nr.destinationCidrBlock,
+				"aggregate", aggregate, "node", nr.nodeName)
+		}
+		aggregated[key] = aggregate
+	}
+	return remaining, covered, keep
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("aggregate routes", func() {
+	var (
+		cloud  *fake.EC2
+		routes []updater.NodeRoute
+	)
+
+	// destinations returns the destinations of the routes of the table
+	destinations := func() []string {
+		var cidrs []string
+		for _, route := range cloud.RouteTable("rtb-0001").Routes {
+			cidrs = append(cidrs, aws.StringValue(route.DestinationCidrBlock))
+		}
+		return cidrs
+	}
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{
+				{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-0001"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				{DestinationCidrBlock: aws.String("10.243.0.0/20"), TransitGatewayId: aws.String("tgw-0001"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				{DestinationCidrBlock: aws.String("10.243.2.0/24"), InstanceId: aws.String("i-0002"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+			},
+		})
+		for _, instanceID := range []string{"i-0001", "i-0002", "i-0003"} {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(instanceID)})
+		}
+		routes = []updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"},
+			{NodeName: "node3", InstanceID: "i-0003", PodCIDR: "10.243.32.0/24"},
+		}
+	})
+
+	It("should skip the routes covered by an aggregate route", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			RespectAggregateRoutes: true,
+		})
+		Expect(err).To(BeNil())
+		result, err := customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+
+		// the redundant route of node2 is deleted, only the route of node3 outside of the aggregate is created
+		Expect(result.Created).To(Equal(1))
+		Expect(result.Deleted).To(Equal(1))
+		Expect(destinations()).To(ConsistOf("0.0.0.0/0", "10.243.0.0/20", "10.243.32.0/24"))
+		Expect(result.NodeRouteTables).To(Equal(map[string][]string{"node1": {"rtb-0001"}, "node2": {"rtb-0001"}, "node3": {"rtb-0001"}}))
+		Expect(result.RouteTables).To(Equal(map[string][]string{"10.243.32.0/24": {"rtb-0001"}}))
+
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{Force: true})
+		Expect(err).To(BeNil())
+		Expect(cloud.Calls("CreateRoute")).To(Equal(1))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(1))
+	})
+
+	It("should keep an aggregate route covering no node", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			RespectAggregateRoutes: true,
+		})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update(routes[2:], updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+
+		// the obsolete route of node2 is deleted, but not the aggregate route
+		Expect(destinations()).To(ConsistOf("0.0.0.0/0", "10.243.0.0/20", "10.243.32.0/24"))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(1))
+	})
+
+	It("should create the node routes once the aggregate route is removed", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			RespectAggregateRoutes: true,
+		})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+
+		_, err = cloud.DeleteRoute(&ec2.DeleteRouteInput{RouteTableId: aws.String("rtb-0001"), DestinationCidrBlock: aws.String("10.243.0.0/20")})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(destinations()).To(ConsistOf("0.0.0.0/0", "10.243.1.0/24", "10.243.2.0/24", "10.243.32.0/24"))
+	})
+
+	It("should replace the aggregate route by the node routes without the option", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(destinations()).To(ConsistOf("0.0.0.0/0", "10.243.1.0/24", "10.243.2.0/24", "10.243.32.0/24"))
+	})
+})
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/go-logr/logr"
+)
+
+// subnetAssociations maps subnet IDs to the IDs of their associated route tables
+type subnetAssociations map[string]string
+
+func getSubnetAssociations(tables []*ec2.RouteTable) subnetAssociations {
+	associations := subnetAssociations{}
+	for _, table := range tables {
+		for _, assoc := range table.Associations {
+			if assoc.SubnetId == nil {
+				continue
+			}
+			associations[*assoc.SubnetId] = aws.StringValue(table.RouteTableId)
+		}
+	}
+	return associations
+}
+
+// isVPCMainRouteTable returns true if the table is the main route table of its VPC
+func isVPCMainRouteTable(table *ec2.RouteTable) bool {
+	for _, assoc := range table.Associations {
+		if aws.BoolValue(assoc.Main) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAssociationChanges warns about subnets which have been associated with another route table since the last observation
+func (r *CustomRoutes) checkAssociationChanges(tables []*ec2.RouteTable) {
+	current := getSubnetAssociations(tables)
+	if r.lastAssociations != nil {
+		logAssociationChanges(r.log, r.lastAssociations, current)
+	}
+	r.lastAssociations = current
+}
+
+func logAssociationChanges(log logr.Logger, previous, current subnetAssociations) {
+	for subnetID, previousTableID := range previous {
+		currentTableID := current[subnetID]
+		if currentTableID == previousTableID {
+			continue
+		}
+		metrics.RouteTableAssociationChanges.Inc()
+		if currentTableID == "" {
+			log.Info("WARNING: subnet is not associated with a cluster route table anymore", "subnet", subnetID, "previousTable", previousTableID)
+		} else {
+			log.Info("WARNING: subnet associated with another route table", "subnet", subnetID, "previousTable", previousTableID, "table", currentTableID)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// maxRoleSessionNameLength is the maximum length of an IAM role session name
+	maxRoleSessionNameLength = 64
+	// maxSessionTags is the maximum number of session tags of an assumed role
+	maxSessionTags = 50
+	// maxSessionTagKeyLength and maxSessionTagValueLength are the maximum lengths of session tag keys and values
+	maxSessionTagKeyLength   = 128
+	maxSessionTagValueLength = 256
+)
+
+// invalidRoleSessionNameChars matches the characters not allowed in an IAM role session name
+var invalidRoleSessionNameChars = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// DefaultRoleSessionName returns the role session name shown in CloudTrail for the component and cluster,
+// i.e. <component>-<cluster name> with invalid characters replaced and truncated to the maximum length.
+func DefaultRoleSessionName(component, clusterName string) string {
+	name := invalidRoleSessionNameChars.ReplaceAllString(component+"-"+clusterName, "-")
+	if len(name) > maxRoleSessionNameLength {
+		name = name[:maxRoleSessionNameLength]
+	}
+	return name
+}
+
+// NewAWSSTS creates an STS client for assuming roles with the given credentials
+func NewAWSSTS(creds *Credentials, region string, options AWSClientOptions) (stscreds.AssumeRoler, error) {
+	s, config, err := newSession(creds, sts.EndpointsID, region, options)
+	if err != nil {
+		return nil, err
+	}
+	return sts.New(s, config), nil
+}
+
+// ParseSessionTags parses session tags in the form <key>=<value>, e.g. for attribute-based access control
+// with IAM policy conditions on aws:PrincipalTag. The keys must be unique.
+func ParseSessionTags(values []string) ([]*sts.Tag, error) {
+	if len(values) > maxSessionTags {
+		return nil, fmt.Errorf("%d session tags exceed the maximum of %d", len(values), maxSessionTags)
+	}
+	var (
+		tags []*sts.Tag
+		keys = map[string]bool{}
+	)
+	for _, value := range values {
+		key, tagValue, ok := strings.Cut(value, "=")
+		if !ok || key == "" || len(key) > maxSessionTagKeyLength || len(tagValue) > maxSessionTagValueLength {
+			return nil, fmt.Errorf("invalid session tag %q, expected <key>=<value> with at most %d characters for the key and %d for the value",
+				value, maxSessionTagKeyLength, maxSessionTagValueLength)
+		}
+		// session tag keys are case-insensitive
+		if keys[strings.ToLower(key)] {
+			return nil, fmt.Errorf("duplicate session tag key %q", key)
+		}
+		keys[strings.ToLower(key)] = true
+		tags = append(tags, &sts.Tag{Key: aws.String(key), Value: aws.String(tagValue)})
+	}
+	return tags, nil
+}
+
+// AssumeRole returns the credentials of the role assumed with the client and the session tags (optional),
+// refreshed before they expire
+func AssumeRole(client stscreds.AssumeRoler, roleARN, sessionName string, sessionTags []*sts.Tag) *Credentials {
+	return &Credentials{
+		Provider: &stscreds.AssumeRoleProvider{
+			Client:          client,
+			RoleARN:         roleARN,
+			RoleSessionName: sessionName,
+			Tags:            sessionTags,
+			Duration:        stscreds.DefaultDuration,
+			ExpiryWindow:    stscreds.DefaultDuration / 5,
+		},
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AssumeRole", func() {
+	DescribeTable("should derive the default role session name",
+		func(clusterName, expected string) {
+			Expect(updater.DefaultRoleSessionName("aws-custom-route-controller", clusterName)).To(Equal(expected))
+		},
+		Entry("from the cluster name", "shoot--foo--bar", "aws-custom-route-controller-shoot--foo--bar"),
+		Entry("replacing invalid characters", "foo/bar baz", "aws-custom-route-controller-foo-bar-baz"),
+		Entry("truncated to 64 characters", strings.Repeat("x", 64), "aws-custom-route-controller-"+strings.Repeat("x", 36)),
+	)
+
+	It("should pass the role session name and tags to STS", func() {
+		var (
+			mutex  sync.Mutex
+			params = map[string]string{}
+		)
+		expiration := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			mutex.Lock()
+			for _, key := range []string{"Action", "RoleArn", "RoleSessionName", "Tags.member.1.Key", "Tags.member.1.Value", "Tags.member.2.Key"} {
+				params[key] = r.PostForm.Get(key)
+			}
+			mutex.Unlock()
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult><Credentials>`+
+				`<AccessKeyId>assumed-id</AccessKeyId><SecretAccessKey>assumed-secret</SecretAccessKey><SessionToken>assumed-token</SessionToken>`+
+				`<Expiration>%s</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`, expiration)
+		}))
+		defer server.Close()
+
+		client, err := updater.NewAWSSTS(&updater.Credentials{AccessKeyID: "id", SecretAccessKey: "secret"}, "eu-west-1", updater.AWSClientOptions{})
+		Expect(err).To(BeNil())
+		client.(*sts.STS).Endpoint = server.URL
+
+		tags, err := updater.ParseSessionTags([]string{"cluster=shoot--foo--bar"})
+		Expect(err).To(BeNil())
+		creds := updater.AssumeRole(client, "arn:aws:iam::123456789012:role/routes", "aws-custom-route-controller-shoot--foo--bar", tags)
+		value, err := creds.Provider.Retrieve()
+		Expect(err).To(BeNil())
+		Expect(value.AccessKeyID).To(Equal("assumed-id"))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(params).To(Equal(map[string]string{
+			"Action":              "AssumeRole",
+			"RoleArn":             "arn:aws:iam::123456789012:role/routes",
+			"RoleSessionName":     "aws-custom-route-controller-shoot--foo--bar",
+			"Tags.member.1.Key":   "cluster",
+			"Tags.member.1.Value": "shoot--foo--bar",
+			"Tags.member.2.Key":   "",
+		}))
+	})
+
+	It("should parse the session tags", func() {
+		tags, err := updater.ParseSessionTags([]string{"cluster=shoot--foo--bar", "team="})
+		Expect(err).To(BeNil())
+		Expect(tags).To(Equal([]*sts.Tag{
+			{Key: aws.String("cluster"), Value: aws.String("shoot--foo--bar")},
+			{Key: aws.String("team"), Value: aws.String("")},
+		}))
+	})
+
+	DescribeTable("should reject invalid session tags",
+		func(values []string, message string) {
+			_, err := updater.ParseSessionTags(values)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("missing value separator", []string{"cluster"}, `invalid session tag "cluster"`),
+		Entry("empty key", []string{"=shoot--foo--bar"}, `invalid session tag "=shoot--foo--bar"`),
+		Entry("too long key", []string{strings.Repeat("k", 129) + "=v"}, "invalid session tag"),
+		Entry("duplicate key", []string{"cluster=a", "Cluster=b"}, `duplicate session tag key "Cluster"`),
+	)
+})
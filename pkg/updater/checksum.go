@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// tableChecksum calculates a checksum over the managed routes of the table, the desired and the kept routes.
+// If it is unchanged since the table was found in sync, the table does not need to be diffed again.
+func (r *CustomRoutes) tableChecksum(table *ec2.RouteTable, desired []internalNodeRoute, keep []string) string {
+	var lines []string
+	for _, route := range r.managedRoutes(table) {
+		lines = append(lines, "current "+*route.DestinationCidrBlock+" "+targetOf(route).String()+" "+aws.StringValue(route.State))
+	}
+	if !r.isMainTable(table) {
+		for _, nr := range desired {
+			lines = append(lines, "desired "+nr.destinationCidrBlock+" "+nr.target.String())
+		}
+	}
+	for _, cidr := range keep {
+		lines = append(lines, "keep "+cidr)
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
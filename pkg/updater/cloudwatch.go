@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+)
+
+// NewAWSCloudWatch creates a CloudWatch client using the same credentials as for EC2
+func NewAWSCloudWatch(creds *Credentials, region string, options AWSClientOptions) (*cloudwatch.CloudWatch, error) {
+	s, config, err := newSession(creds, cloudwatch.EndpointsID, region, options)
+	if err != nil {
+		return nil, err
+	}
+	return cloudwatch.New(s, config), nil
+}
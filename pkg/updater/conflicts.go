@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"sort"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// resolveInstanceConflicts keeps only a single node route per instance. During node replacements, two node
+// objects may briefly resolve to the same instance. The node is picked according to the instance conflict policy.
+func (r *CustomRoutes) resolveInstanceConflicts(routes []NodeRoute) []NodeRoute {
+	byInstance := map[string][]NodeRoute{}
+	for _, route := range routes {
+		byInstance[route.InstanceID] = append(byInstance[route.InstanceID], route)
+	}
+	if len(byInstance) == len(routes) {
+		metrics.InstanceConflicts.WithLabelValues(r.clusterName).Set(0)
+		return routes
+	}
+
+	var (
+		result    []NodeRoute
+		conflicts int
+	)
+	for _, route := range routes {
+		claims := byInstance[route.InstanceID]
+		if len(claims) == 1 {
+			result = append(result, route)
+			continue
+		}
+		if claims[0] != route {
+			// all claims are handled with the first one
+			continue
+		}
+		conflicts++
+		winner := r.pickConflictWinner(claims)
+		var losers []string
+		for _, claim := range claims {
+			if claim.NodeName != winner.NodeName {
+				losers = append(losers, claim.NodeName)
+			}
+		}
+		r.log.Info("WARNING: multiple nodes resolve to the same instance, only the route of one node is programmed",
+			"instanceId", route.InstanceID, "node", winner.NodeName, "podCIDR", winner.PodCIDR, "skippedNodes", losers)
+		result = append(result, winner)
+	}
+	metrics.InstanceConflicts.WithLabelValues(r.clusterName).Set(float64(conflicts))
+	return result
+}
+
+// pickConflictWinner deterministically selects one of the node routes claiming the same instance
+func (r *CustomRoutes) pickConflictWinner(claims []NodeRoute) NodeRoute {
+	sorted := append([]NodeRoute{}, claims...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if r.options.InstanceConflictPolicy == InstanceConflictPolicyPreferReady && a.Ready != b.Ready {
+			return a.Ready
+		}
+		if !a.CreationTimestamp.Equal(b.CreationTimestamp) {
+			return a.CreationTimestamp.After(b.CreationTimestamp)
+		}
+		return a.NodeName < b.NodeName
+	})
+	return sorted[0]
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// CredentialsSourceKubernetesSecret loads the credentials from a secret on the control plane
+	CredentialsSourceKubernetesSecret = "k8s-secret"
+	// CredentialsSourceSSM loads the credentials from an AWS SSM parameter
+	CredentialsSourceSSM = "ssm"
+	// CredentialsSourceSecretsManager loads the credentials from an AWS Secrets Manager secret
+	CredentialsSourceSecretsManager = "secrets-manager"
+)
+
+// SSMParameterGetter is the part of the SSM API needed to load credentials
+type SSMParameterGetter interface {
+	GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error)
+}
+
+// SecretValueGetter is the part of the Secrets Manager API needed to load credentials
+type SecretValueGetter interface {
+	GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// NewBootstrapSession creates an AWS session using the default credential chain (e.g. the instance profile)
+// for loading the credentials from SSM or Secrets Manager.
+func NewBootstrapSession(region string, options AWSClientOptions) (*session.Session, error) {
+	awsConfig := &aws.Config{Region: aws.String(region), HTTPClient: newHTTPClient(options.ProxyURL)}
+	if options.UseFIPSEndpoints {
+		awsConfig.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+	}
+	return session.NewSession(awsConfig)
+}
+
+// LoadCredentialsFromSSM loads the credentials from a (secure string) SSM parameter
+// containing a JSON object with the given fields (by default `accessKeyID` and `secretAccessKey`).
+func LoadCredentialsFromSSM(client SSMParameterGetter, name string, fields CredentialFields) (*Credentials, error) {
+	output, err := client.GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting SSM parameter %s failed: %w", name, err)
+	}
+	if output.Parameter == nil || output.Parameter.Value == nil {
+		return nil, fmt.Errorf("SSM parameter %s has no value", name)
+	}
+	return parseCredentials(*output.Parameter.Value, fields)
+}
+
+// LoadCredentialsFromSecretsManager loads the credentials from a Secrets Manager secret
+// containing a JSON object with the given fields (by default `accessKeyID` and `secretAccessKey`).
+func LoadCredentialsFromSecretsManager(client SecretValueGetter, secretID string, fields CredentialFields) (*Credentials, error) {
+	output, err := client.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting secret %s from Secrets Manager failed: %w", secretID, err)
+	}
+	if output.SecretString == nil {
+		return nil, fmt.Errorf("secret %s from Secrets Manager has no string value", secretID)
+	}
+	return parseCredentials(*output.SecretString, fields)
+}
+
+func parseCredentials(value string, fields CredentialFields) (*Credentials, error) {
+	data := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return nil, fmt.Errorf("credentials are no valid JSON object: %w", err)
+	}
+	fields = fields.withDefaults()
+	creds := &Credentials{
+		AccessKeyID:     data[fields.AccessKeyID],
+		SecretAccessKey: data[fields.SecretAccessKey],
+		SessionToken:    data[fields.SessionToken],
+	}
+	if creds.AccessKeyID == "" {
+		return nil, fmt.Errorf("missing %q field in credentials", fields.AccessKeyID)
+	}
+	if creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("missing %q field in credentials", fields.SecretAccessKey)
+	}
+	return creds, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeSSM struct {
+	parameters map[string]string
+	input      *ssm.GetParameterInput
+}
+
+func (f *fakeSSM) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	f.input = input
+	value, ok := f.parameters[aws.StringValue(input.Name)]
+	if !ok {
+		return nil, fmt.Errorf("parameter not found")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Value: aws.String(value)}}, nil
+}
+
+type fakeSecretsManager struct {
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f.secrets[aws.StringValue(input.SecretId)]
+	if !ok {
+		return nil, fmt.Errorf("secret not found")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+var _ = Describe("credential sources", func() {
+	const validCredentials = `{"accessKeyID": "AKIA123", "secretAccessKey": "secret"}`
+
+	It("should load the credentials from an SSM parameter", func() {
+		client := &fakeSSM{parameters: map[string]string{"/shoot/credentials": validCredentials}}
+
+		creds, err := updater.LoadCredentialsFromSSM(client, "/shoot/credentials", updater.DefaultCredentialFields())
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(&updater.Credentials{AccessKeyID: "AKIA123", SecretAccessKey: "secret"}))
+		Expect(aws.BoolValue(client.input.WithDecryption)).To(BeTrue())
+
+		_, err = updater.LoadCredentialsFromSSM(client, "/shoot/other", updater.DefaultCredentialFields())
+		Expect(err).To(MatchError(ContainSubstring("parameter not found")))
+	})
+
+	It("should load the credentials from a Secrets Manager secret", func() {
+		client := &fakeSecretsManager{secrets: map[string]string{"shoot-credentials": validCredentials}}
+
+		creds, err := updater.LoadCredentialsFromSecretsManager(client, "shoot-credentials", updater.DefaultCredentialFields())
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(&updater.Credentials{AccessKeyID: "AKIA123", SecretAccessKey: "secret"}))
+
+		_, err = updater.LoadCredentialsFromSecretsManager(client, "other", updater.DefaultCredentialFields())
+		Expect(err).To(MatchError(ContainSubstring("secret not found")))
+	})
+
+	It("should load the credentials from custom fields", func() {
+		client := &fakeSSM{parameters: map[string]string{
+			"/shoot/credentials": `{"aws-access-key": "AKIA123", "aws-secret-key": "secret", "aws-session-token": "token"}`,
+		}}
+		fields := updater.CredentialFields{AccessKeyID: "aws-access-key", SecretAccessKey: "aws-secret-key", SessionToken: "aws-session-token"}
+
+		creds, err := updater.LoadCredentialsFromSSM(client, "/shoot/credentials", fields)
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(&updater.Credentials{AccessKeyID: "AKIA123", SecretAccessKey: "secret", SessionToken: "token"}))
+
+		_, err = updater.LoadCredentialsFromSSM(client, "/shoot/credentials", updater.DefaultCredentialFields())
+		Expect(err).To(MatchError(ContainSubstring(`missing "accessKeyID" field`)))
+	})
+
+	It("should reject invalid credential material", func() {
+		client := &fakeSecretsManager{secrets: map[string]string{
+			"no-json":    "AKIA123:secret",
+			"incomplete": `{"accessKeyID": "AKIA123"}`,
+		}}
+
+		_, err := updater.LoadCredentialsFromSecretsManager(client, "no-json", updater.DefaultCredentialFields())
+		Expect(err).To(MatchError(ContainSubstring("no valid JSON")))
+		_, err = updater.LoadCredentialsFromSecretsManager(client, "incomplete", updater.DefaultCredentialFields())
+		Expect(err).To(MatchError(ContainSubstring("secretAccessKey")))
+	})
+})
@@ -10,6 +10,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -22,6 +23,8 @@ const (
 	AccessKeyID = "accessKeyID"
 	// SecretAccessKey is a constant for the key in a cloud provider secret and backup secret that holds the AWS secret access key.
 	SecretAccessKey = "secretAccessKey"
+	// SessionToken is a constant for the optional key in a cloud provider secret that holds the AWS session token.
+	SessionToken = "sessionToken"
 	// InClusterConfig is a special name for the kubeconfig to use in-cluster client
 	InClusterConfig = "inClusterConfig"
 )
@@ -29,16 +32,56 @@ const (
 type Credentials struct {
 	AccessKeyID     string
 	SecretAccessKey string
+	SessionToken    string
+	// Provider replaces the static keys, e.g. by assume role credentials refreshed before they expire (optional)
+	Provider credentials.Provider
 }
 
-func LoadCredentials(controlKubeconfig, namespace, secretName string) (*Credentials, error) {
-	var err error
-	var config *rest.Config
-	if controlKubeconfig == InClusterConfig || controlKubeconfig == "" {
-		config, err = rest.InClusterConfig()
-	} else {
-		config, err = clientcmd.BuildConfigFromFlags("", controlKubeconfig)
+// CredentialFields are the names of the fields holding the credentials in a secret
+type CredentialFields struct {
+	// AccessKeyID is the field of the access key id
+	AccessKeyID string
+	// SecretAccessKey is the field of the secret access key
+	SecretAccessKey string
+	// SessionToken is the field of the optional session token
+	SessionToken string
+}
+
+// DefaultCredentialFields returns the default field names of the credentials
+func DefaultCredentialFields() CredentialFields {
+	return CredentialFields{
+		AccessKeyID:     AccessKeyID,
+		SecretAccessKey: SecretAccessKey,
+		SessionToken:    SessionToken,
 	}
+}
+
+// withDefaults replaces empty field names by the default ones
+func (f CredentialFields) withDefaults() CredentialFields {
+	defaults := DefaultCredentialFields()
+	if f.AccessKeyID == "" {
+		f.AccessKeyID = defaults.AccessKeyID
+	}
+	if f.SecretAccessKey == "" {
+		f.SecretAccessKey = defaults.SecretAccessKey
+	}
+	if f.SessionToken == "" {
+		f.SessionToken = defaults.SessionToken
+	}
+	return f
+}
+
+// BuildConfig creates a rest config from the kubeconfig path or the in-cluster config for the InClusterConfig sentinel or an empty path
+func BuildConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig == InClusterConfig || kubeconfig == "" {
+		return rest.InClusterConfig()
+	}
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
+
+// LoadCredentials loads the credentials from the secret on the control plane
+func LoadCredentials(controlKubeconfig, namespace, secretName string, fields CredentialFields) (*Credentials, error) {
+	config, err := BuildConfig(controlKubeconfig)
 	if err != nil {
 		return nil, err
 	}
@@ -53,20 +96,27 @@ func LoadCredentials(controlKubeconfig, namespace, secretName string) (*Credenti
 		return nil, err
 	}
 
-	return extractCredentials(secret)
+	return ExtractCredentials(secret, fields)
 }
 
-func extractCredentials(secret *corev1.Secret) (*Credentials, error) {
+// ExtractCredentials reads the credentials from the given fields of the secret data
+func ExtractCredentials(secret *corev1.Secret, fields CredentialFields) (*Credentials, error) {
 	if secret.Data == nil {
 		return nil, fmt.Errorf("secret does not contain any data")
 	}
+	fields = fields.withDefaults()
+
+	accessKeyID, err := getSecretDataValue(secret, fields.AccessKeyID, nil, true)
+	if err != nil {
+		return nil, err
+	}
 
-	accessKeyID, err := getSecretDataValue(secret, AccessKeyID, nil, true)
+	secretAccessKey, err := getSecretDataValue(secret, fields.SecretAccessKey, nil, true)
 	if err != nil {
 		return nil, err
 	}
 
-	secretAccessKey, err := getSecretDataValue(secret, SecretAccessKey, nil, true)
+	sessionToken, err := getSecretDataValue(secret, fields.SessionToken, nil, false)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +124,7 @@ func extractCredentials(secret *corev1.Secret) (*Credentials, error) {
 	return &Credentials{
 		AccessKeyID:     string(accessKeyID),
 		SecretAccessKey: string(secretAccessKey),
+		SessionToken:    string(sessionToken),
 	}, nil
 }
 
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// awsCredentials returns the credentials of the provider or the static keys
+func (c *Credentials) awsCredentials() *credentials.Credentials {
+	if c.Provider != nil {
+		return credentials.NewCredentials(c.Provider)
+	}
+	return credentials.NewStaticCredentials(c.AccessKeyID, c.SecretAccessKey, c.SessionToken)
+}
+
+// RecordCredentialsExpiry exports the expiry time of the credentials used for the service.
+// It is called after each request, as the expiry changes when the credentials are refreshed.
+// Credentials without expiry, like static ones, are not exported.
+func RecordCredentialsExpiry(service string, creds *credentials.Credentials) {
+	if creds == nil {
+		return
+	}
+	expiresAt, err := creds.ExpiresAt()
+	if err != nil || expiresAt.IsZero() {
+		metrics.CredentialsExpiry.DeleteLabelValues(service)
+		return
+	}
+	metrics.CredentialsExpiry.WithLabelValues(service).Set(float64(expiresAt.Unix()))
+}
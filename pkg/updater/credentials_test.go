@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+var _ = Describe("BuildConfig", func() {
+	It("should use the in-cluster config for the sentinel", func() {
+		GinkgoT().Setenv("KUBERNETES_SERVICE_HOST", "")
+		GinkgoT().Setenv("KUBERNETES_SERVICE_PORT", "")
+
+		_, err := updater.BuildConfig(updater.InClusterConfig)
+		Expect(err).To(MatchError(rest.ErrNotInCluster))
+	})
+
+	It("should load a kubeconfig file", func() {
+		kubeconfig := filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+		Expect(os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: target
+  cluster:
+    server: https://target.example.com
+contexts:
+- name: target
+  context:
+    cluster: target
+    user: target
+current-context: target
+users:
+- name: target
+  user:
+    token: foo
+`), 0600)).To(Succeed())
+
+		config, err := updater.BuildConfig(kubeconfig)
+		Expect(err).To(BeNil())
+		Expect(config.Host).To(Equal("https://target.example.com"))
+	})
+})
+
+var _ = Describe("ExtractCredentials", func() {
+	It("should read the default fields", func() {
+		secret := &corev1.Secret{Data: map[string][]byte{
+			updater.AccessKeyID:     []byte("AKIA123"),
+			updater.SecretAccessKey: []byte("secret"),
+		}}
+
+		creds, err := updater.ExtractCredentials(secret, updater.DefaultCredentialFields())
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(&updater.Credentials{AccessKeyID: "AKIA123", SecretAccessKey: "secret"}))
+	})
+
+	It("should read custom fields", func() {
+		secret := &corev1.Secret{Data: map[string][]byte{
+			"aws-access-key":    []byte("AKIA123"),
+			"aws-secret-key":    []byte("secret"),
+			"aws-session-token": []byte("token"),
+		}}
+		fields := updater.CredentialFields{AccessKeyID: "aws-access-key", SecretAccessKey: "aws-secret-key", SessionToken: "aws-session-token"}
+
+		creds, err := updater.ExtractCredentials(secret, fields)
+		Expect(err).To(BeNil())
+		Expect(creds).To(Equal(&updater.Credentials{AccessKeyID: "AKIA123", SecretAccessKey: "secret", SessionToken: "token"}))
+
+		_, err = updater.ExtractCredentials(secret, updater.DefaultCredentialFields())
+		Expect(err).To(MatchError(`missing "accessKeyID" field in secret`))
+	})
+
+	It("should use the default for empty field names", func() {
+		secret := &corev1.Secret{Data: map[string][]byte{
+			updater.AccessKeyID: []byte("AKIA123"),
+			"aws-secret-key":    []byte("secret"),
+		}}
+
+		creds, err := updater.ExtractCredentials(secret, updater.CredentialFields{SecretAccessKey: "aws-secret-key"})
+		Expect(err).To(BeNil())
+		Expect(creds.AccessKeyID).To(Equal("AKIA123"))
+	})
+})
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// ErrDefaultRoute is returned for routes with a default destination like 0.0.0.0/0 or ::/0.
+// Such a route would redirect all traffic of the VPC to an instance, so it is never created.
+var ErrDefaultRoute = errors.New("refusing to create default route")
+
+// isDefaultRoute returns true if the destination covers all addresses
+func isDefaultRoute(destination string) bool {
+	_, ipnet, err := net.ParseCIDR(destination)
+	if err != nil {
+		return false
+	}
+	ones, _ := ipnet.Mask.Size()
+	return ones == 0
+}
+
+// refuseDefaultRoute returns an error if the request would create a default route.
+// It is checked right before calling AWS, independently of how the desired routes have been determined.
+func (r *CustomRoutes) refuseDefaultRoute(req *ec2.CreateRouteInput, nodeName string) error {
+	for _, destination := range []*string{req.DestinationCidrBlock, req.DestinationIpv6CidrBlock} {
+		if destination == nil || !isDefaultRoute(*destination) {
+			continue
+		}
+		metrics.DefaultRoutesRefused.Inc()
+		r.log.Error(ErrDefaultRoute, "REFUSING TO CREATE DEFAULT ROUTE - please investigate",
+			"table", aws.StringValue(req.RouteTableId), "destination", *destination, "node", nodeName)
+		return fmt.Errorf("%w %s%s", ErrDefaultRoute, *destination, ofNode(nodeName))
+	}
+	return nil
+}
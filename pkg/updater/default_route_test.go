@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("default route guard", func() {
+	var cloud *fake.EC2
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+	})
+
+	DescribeTable("should never create a default route",
+		func(podNetworkCIDR, destination string) {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", podNetworkCIDR, updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+			before := testutil.ToFloat64(metrics.DefaultRoutesRefused)
+
+			result, err := customRoutes.Update([]updater.NodeRoute{
+				{NodeName: "bad", InstanceID: "i-0001", PodCIDR: destination},
+				{NodeName: "good", InstanceID: "i-0002", PodCIDR: "10.243.1.0/24"},
+			}, updater.UpdateOptions{})
+			Expect(err).To(MatchError(updater.ErrDefaultRoute))
+			Expect(err).To(MatchError(ContainSubstring(destination + " of node bad")))
+			Expect(result.Created).To(Equal(1))
+			Expect(testutil.ToFloat64(metrics.DefaultRoutesRefused)).To(Equal(before + 1))
+
+			routes := cloud.RouteTable("rtb-0001").Routes
+			Expect(routes).To(HaveLen(1))
+			Expect(aws.StringValue(routes[0].DestinationCidrBlock)).To(Equal("10.243.1.0/24"))
+		},
+		Entry("IPv4 default route inside the pod network", "0.0.0.0/0", "0.0.0.0/0"),
+		Entry("IPv4 default route outside the pod network", "10.243.0.0/19", "0.0.0.0/0"),
+		Entry("non-canonical IPv4 default route", "0.0.0.0/0", "10.0.0.0/0"),
+		Entry("IPv6 default route", "10.243.0.0/19", "::/0"),
+	)
+})
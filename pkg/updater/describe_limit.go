@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+// describeLimiter bounds the number of describe requests in flight, independently of the route table concurrency
+type describeLimiter chan struct{}
+
+func newDescribeLimiter(limit int) describeLimiter {
+	return make(describeLimiter, max(limit, 1))
+}
+
+// do calls f as soon as less than the limit of describe requests are in flight
+func (l describeLimiter) do(f func() error) error {
+	l <- struct{}{}
+	defer func() { <-l }()
+	return f()
+}
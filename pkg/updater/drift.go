@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// programmedRoutes maps route table IDs to the routes programmed in them
+type programmedRoutes map[string][]internalNodeRoute
+
+// DetectDrift checks whether routes programmed by the last complete update are missing in the route tables,
+// e.g. because they have been deleted out-of-band. It only reads the route tables and returns the number of
+// missing routes. It must not be called concurrently with Update.
+func (r *CustomRoutes) DetectDrift() (int, error) {
+	if len(r.programmed) == 0 {
+		return 0, nil
+	}
+	tables, err := r.findRouteTables()
+	if err != nil {
+		return 0, err
+	}
+	missing := 0
+	for _, table := range tables {
+		for _, expected := range r.programmed[*table.RouteTableId] {
+			if !tableHasRoute(table, expected) {
+				r.log.Info("WARNING: managed route missing", "table", *table.RouteTableId,
+					"destination", expected.destinationCidrBlock, "target", expected.target.String())
+				missing++
+			}
+		}
+	}
+	metrics.DriftedRoutes.Add(float64(missing))
+	return missing, nil
+}
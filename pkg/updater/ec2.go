@@ -7,10 +7,15 @@
 package updater
 
 import (
+	"fmt"
+	"net/url"
+
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
 )
 
 // TagNameKubernetesClusterPrefix is the tag name we use to differentiate multiple
@@ -31,21 +36,60 @@ type EC2Routes interface {
 	DescribeRouteTables(request *ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error)
 	CreateRoute(request *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error)
 	DeleteRoute(request *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error)
+	DescribeInstances(request *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+	DescribeSubnets(request *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
+	ModifyInstanceAttribute(request *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error)
+	DescribeVpcs(request *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error)
+	CreateTags(request *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error)
+}
+
+// AWSClientOptions contains optional settings for the AWS clients
+type AWSClientOptions struct {
+	// UseFIPSEndpoints selects the FIPS variants of the AWS endpoints
+	UseFIPSEndpoints bool
+	// ProxyURL is the proxy for all AWS requests, overriding the proxy environment variables (optional)
+	ProxyURL *url.URL
+}
+
+func NewAWSEC2Routes(creds *Credentials, region string, options AWSClientOptions) (EC2Routes, error) {
+	s, config, err := newSession(creds, ec2.EndpointsID, region, options)
+	if err != nil {
+		return nil, err
+	}
+	return ec2.New(s, config), nil
 }
 
-func NewAWSEC2Routes(creds *Credentials, region string) (EC2Routes, error) {
+// newSession creates an AWS session with static credentials for the service with the given endpoints ID
+func newSession(creds *Credentials, endpointsID, region string, options AWSClientOptions) (*session.Session, *aws.Config, error) {
 	var (
 		awsConfig = &aws.Config{
-			Credentials: credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, ""),
+			Credentials: creds.awsCredentials(),
+			HTTPClient:  newHTTPClient(options.ProxyURL),
 		}
 		config = &aws.Config{Region: aws.String(region)}
 	)
+	if options.UseFIPSEndpoints {
+		if _, err := endpoints.DefaultResolver().EndpointFor(endpointsID, region, func(o *endpoints.Options) {
+			o.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+			o.StrictMatching = true
+		}); err != nil {
+			return nil, nil, fmt.Errorf("region %s does not support FIPS endpoints for %s: %w", region, endpointsID, err)
+		}
+		awsConfig.UseFIPSEndpoint = endpoints.FIPSEndpointStateEnabled
+	}
 
 	s, err := session.NewSession(awsConfig)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return ec2.New(s, config), nil
+	s.Handlers.UnmarshalError.PushBackNamed(retryAfterHandler)
+	s.Handlers.Complete.PushBack(func(r *request.Request) {
+		RecordCredentialsExpiry(endpointsID, r.Config.Credentials)
+		if r.Operation != nil {
+			metrics.ObserveAWSRequestLatency(endpointsID, r.Operation.Name, r.Time)
+		}
+	})
+	return s, config, nil
 }
 
 func ClusterTagKey(clusterID string) string {
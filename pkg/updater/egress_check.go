@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// hasDefaultRoute returns true if the route table has an active IPv4 default route, e.g. to an internet or NAT gateway
+func hasDefaultRoute(table *ec2.RouteTable) bool {
+	for _, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == "0.0.0.0/0" && aws.StringValue(route.State) == ec2.RouteStateActive {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDefaultRoutes warns about the route tables the node routes are programmed into which lack a default route,
+// as pods using them may have no egress. Each table is only logged when it starts lacking the default route.
+func (r *CustomRoutes) checkDefaultRoutes(plans []tableChanges) {
+	missing := map[string]bool{}
+	metrics.ResetCluster(metrics.RouteTableMissingDefaultRoute, r.clusterName)
+	for _, plan := range plans {
+		if len(plan.desired) == 0 || hasDefaultRoute(plan.table) {
+			continue
+		}
+		tableID := aws.StringValue(plan.table.RouteTableId)
+		missing[tableID] = true
+		metrics.RouteTableMissingDefaultRoute.WithLabelValues(r.clusterName, tableID).Set(1)
+		if !r.missingDefaultRoute[tableID] {
+			r.log.Info("WARNING: route table has no active default route, pods may have no egress", "table", tableID)
+		}
+	}
+	r.missingDefaultRoute = missing
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("missing default route warning", func() {
+	var cloud *fake.EC2
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		clusterTag := &ec2.Tag{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-egress"),
+			Tags:         []*ec2.Tag{clusterTag},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String("0.0.0.0/0"),
+				NatGatewayId:         aws.String("nat-0001"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+				State:                aws.String(ec2.RouteStateActive),
+			}},
+		})
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-isolated"),
+			Tags:         []*ec2.Tag{clusterTag},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		metrics.RouteTableMissingDefaultRoute.Reset()
+	})
+
+	update := func(warn bool) {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			WarnMissingDefaultRoute: warn,
+		})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update([]updater.NodeRoute{{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"}}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+	}
+
+	It("should report the route tables without default route", func() {
+		update(true)
+		Expect(testutil.CollectAndCount(metrics.RouteTableMissingDefaultRoute)).To(Equal(1))
+		Expect(testutil.ToFloat64(metrics.RouteTableMissingDefaultRoute.WithLabelValues("test", "rtb-isolated"))).To(Equal(1.0))
+		Expect(cloud.RouteTable("rtb-isolated").Routes).To(HaveLen(1))
+	})
+
+	It("should not check the route tables if disabled", func() {
+		update(false)
+		Expect(testutil.CollectAndCount(metrics.RouteTableMissingDefaultRoute)).To(Equal(0))
+	})
+})
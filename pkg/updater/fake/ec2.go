@@ -0,0 +1,482 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fake provides an in-memory implementation of the EC2 API used by the updater.
+// It is meant for tests of this module and of downstream consumers.
+package fake
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+)
+
+const (
+	// ErrCodeRouteTableNotFound is returned for unknown route table IDs
+	ErrCodeRouteTableNotFound = "InvalidRouteTableID.NotFound"
+	// ErrCodeRouteNotFound is returned when deleting a route not existing
+	ErrCodeRouteNotFound = "InvalidRoute.NotFound"
+	// ErrCodeRouteAlreadyExists is returned when creating a route for an existing destination
+	ErrCodeRouteAlreadyExists = "RouteAlreadyExists"
+	// ErrCodeInstanceNotFound is returned for unknown instance IDs
+	ErrCodeInstanceNotFound = "InvalidInstanceID.NotFound"
+	// ErrCodeVpcNotFound is returned for unknown VPC IDs
+	ErrCodeVpcNotFound = "InvalidVpcID.NotFound"
+	// ErrCodeSubnetNotFound is returned for unknown subnet IDs
+	ErrCodeSubnetNotFound = "InvalidSubnetID.NotFound"
+	// ErrCodeInvalidParameterValue is returned for malformed or missing parameters
+	ErrCodeInvalidParameterValue = "InvalidParameterValue"
+	// ErrCodeDryRunOperation is returned for dry run requests, as they would have succeeded
+	ErrCodeDryRunOperation = "DryRunOperation"
+	// ErrCodeInvalidParameterCombination is returned if not exactly one route target is given
+	ErrCodeInvalidParameterCombination = "InvalidParameterCombination"
+)
+
+// EC2 is an in-memory fake of updater.EC2Routes holding route tables, instances, subnets and VPCs.
+// All methods are safe for concurrent use. Returned objects are copies of the internal state.
+type EC2 struct {
+	lock        sync.Mutex
+	routeTables map[string]*ec2.RouteTable
+	instances   map[string]*ec2.Instance
+	subnets     map[string]*ec2.Subnet
+	vpcs        map[string]*ec2.Vpc
+	errors      map[string]error
+	calls       map[string]int
+}
+
+var _ updater.EC2Routes = &EC2{}
+
+// NewEC2 creates an empty fake
+func NewEC2() *EC2 {
+	return &EC2{
+		routeTables: map[string]*ec2.RouteTable{},
+		instances:   map[string]*ec2.Instance{},
+		subnets:     map[string]*ec2.Subnet{},
+		vpcs:        map[string]*ec2.Vpc{},
+		errors:      map[string]error{},
+		calls:       map[string]int{},
+	}
+}
+
+// AddRouteTable adds or replaces a route table
+func (f *EC2) AddRouteTable(table *ec2.RouteTable) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.routeTables[aws.StringValue(table.RouteTableId)] = copyOf(table)
+}
+
+// AddInstance adds or replaces an instance
+func (f *EC2) AddInstance(instance *ec2.Instance) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.instances[aws.StringValue(instance.InstanceId)] = copyOf(instance)
+}
+
+// AddSubnet adds or replaces a subnet
+func (f *EC2) AddSubnet(subnet *ec2.Subnet) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.subnets[aws.StringValue(subnet.SubnetId)] = copyOf(subnet)
+}
+
+// AddVpc adds or replaces a VPC
+func (f *EC2) AddVpc(vpc *ec2.Vpc) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.vpcs[aws.StringValue(vpc.VpcId)] = copyOf(vpc)
+}
+
+// RemoveInstance removes an instance, e.g. to simulate its termination
+func (f *EC2) RemoveInstance(instanceID string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.instances, instanceID)
+}
+
+// RouteTable returns a copy of the route table or nil if it does not exist
+func (f *EC2) RouteTable(tableID string) *ec2.RouteTable {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	table := f.routeTables[tableID]
+	if table == nil {
+		return nil
+	}
+	return copyOf(table)
+}
+
+// Instance returns a copy of the instance or nil if it does not exist
+func (f *EC2) Instance(instanceID string) *ec2.Instance {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	instance := f.instances[instanceID]
+	if instance == nil {
+		return nil
+	}
+	return copyOf(instance)
+}
+
+// SetError makes all further calls of the given operation (e.g. "CreateRoute") fail with err, including dry run calls.
+// A nil error removes the failure again.
+func (f *EC2) SetError(operation string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err == nil {
+		delete(f.errors, operation)
+		return
+	}
+	f.errors[operation] = err
+}
+
+// Calls returns how often the given operation has been called
+func (f *EC2) Calls(operation string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[operation]
+}
+
+// call records the call of the operation and returns the injected error if any
+func (f *EC2) call(operation string) error {
+	f.calls[operation]++
+	return f.errors[operation]
+}
+
+// DescribeRouteTables returns the route tables selected by ID, or all if no IDs are given.
+// Only the filters "route-table-id" and "vpc-id" are supported.
+func (f *EC2) DescribeRouteTables(request *ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("DescribeRouteTables"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	for _, id := range request.RouteTableIds {
+		if f.routeTables[aws.StringValue(id)] == nil {
+			return nil, awserr.New(ErrCodeRouteTableNotFound, fmt.Sprintf("The routeTable ID '%s' does not exist", aws.StringValue(id)), nil)
+		}
+	}
+	output := &ec2.DescribeRouteTablesOutput{}
+	for _, id := range sortedKeys(f.routeTables) {
+		table := f.routeTables[id]
+		if len(request.RouteTableIds) > 0 && !contains(request.RouteTableIds, id) {
+			continue
+		}
+		ok, err := matchesFilters(request.Filters, map[string]string{
+			"route-table-id": id,
+			"vpc-id":         aws.StringValue(table.VpcId),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			output.RouteTables = append(output.RouteTables, copyOf(table))
+		}
+	}
+	return output, nil
+}
+
+// CreateRoute adds a route to a route table. Exactly one target must be given.
+func (f *EC2) CreateRoute(request *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("CreateRoute"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	tableID := aws.StringValue(request.RouteTableId)
+	table := f.routeTables[tableID]
+	if table == nil {
+		return nil, awserr.New(ErrCodeRouteTableNotFound, fmt.Sprintf("The routeTable ID '%s' does not exist", tableID), nil)
+	}
+	destination := aws.StringValue(request.DestinationCidrBlock)
+	if _, _, err := net.ParseCIDR(destination); err != nil {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("Value (%s) for parameter destinationCidrBlock is invalid", destination), nil)
+	}
+	route := &ec2.Route{
+		DestinationCidrBlock:   aws.String(destination),
+		InstanceId:             request.InstanceId,
+		NetworkInterfaceId:     request.NetworkInterfaceId,
+		TransitGatewayId:       request.TransitGatewayId,
+		NatGatewayId:           request.NatGatewayId,
+		GatewayId:              request.GatewayId,
+		VpcPeeringConnectionId: request.VpcPeeringConnectionId,
+		Origin:                 aws.String(ec2.RouteOriginCreateRoute),
+		State:                  aws.String(ec2.RouteStateActive),
+	}
+	targets := 0
+	for _, id := range []*string{route.InstanceId, route.NetworkInterfaceId, route.TransitGatewayId, route.NatGatewayId, route.GatewayId, route.VpcPeeringConnectionId} {
+		if aws.StringValue(id) != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return nil, awserr.New(ErrCodeInvalidParameterCombination, "The request must contain exactly one of gatewayId, natGatewayId, networkInterfaceId, vpcPeeringConnectionId, transitGatewayId or instanceId", nil)
+	}
+	if route.InstanceId != nil {
+		instance := f.instances[aws.StringValue(route.InstanceId)]
+		if instance == nil {
+			return nil, instanceNotFound(aws.StringValue(route.InstanceId))
+		}
+		// AWS reports the primary network interface for instance routes
+		for _, eni := range instance.NetworkInterfaces {
+			if eni.Attachment != nil && aws.Int64Value(eni.Attachment.DeviceIndex) == 0 {
+				route.NetworkInterfaceId = eni.NetworkInterfaceId
+			}
+		}
+	}
+	for _, existing := range table.Routes {
+		if aws.StringValue(existing.DestinationCidrBlock) == destination {
+			return nil, awserr.New(ErrCodeRouteAlreadyExists, fmt.Sprintf("The route identified by %s already exists.", destination), nil)
+		}
+	}
+	table.Routes = append(table.Routes, route)
+	return &ec2.CreateRouteOutput{Return: aws.Bool(true)}, nil
+}
+
+// DeleteRoute removes the route with the given destination from a route table
+func (f *EC2) DeleteRoute(request *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("DeleteRoute"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	tableID := aws.StringValue(request.RouteTableId)
+	table := f.routeTables[tableID]
+	if table == nil {
+		return nil, awserr.New(ErrCodeRouteTableNotFound, fmt.Sprintf("The routeTable ID '%s' does not exist", tableID), nil)
+	}
+	destination := aws.StringValue(request.DestinationCidrBlock)
+	for i, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) != destination {
+			continue
+		}
+		if aws.StringValue(route.Origin) == ec2.RouteOriginCreateRouteTable {
+			return nil, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("cannot remove local route %s in route table %s", destination, tableID), nil)
+		}
+		table.Routes = append(table.Routes[:i], table.Routes[i+1:]...)
+		return &ec2.DeleteRouteOutput{}, nil
+	}
+	return nil, awserr.New(ErrCodeRouteNotFound, fmt.Sprintf("no route with destination-cidr-block %s in route table %s", destination, tableID), nil)
+}
+
+// DescribeInstances returns the instances selected by ID, or all if no IDs are given.
+// Only the filters "instance-id" and "instance-state-name" are supported.
+func (f *EC2) DescribeInstances(request *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("DescribeInstances"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	for _, id := range request.InstanceIds {
+		if f.instances[aws.StringValue(id)] == nil {
+			return nil, instanceNotFound(aws.StringValue(id))
+		}
+	}
+	reservation := &ec2.Reservation{}
+	for _, id := range sortedKeys(f.instances) {
+		instance := f.instances[id]
+		if len(request.InstanceIds) > 0 && !contains(request.InstanceIds, id) {
+			continue
+		}
+		var state string
+		if instance.State != nil {
+			state = aws.StringValue(instance.State.Name)
+		}
+		ok, err := matchesFilters(request.Filters, map[string]string{
+			"instance-id":         id,
+			"instance-state-name": state,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			reservation.Instances = append(reservation.Instances, copyOf(instance))
+		}
+	}
+	output := &ec2.DescribeInstancesOutput{}
+	if len(reservation.Instances) > 0 {
+		output.Reservations = []*ec2.Reservation{reservation}
+	}
+	return output, nil
+}
+
+// DescribeSubnets returns the subnets selected by ID, or all if no IDs are given
+func (f *EC2) DescribeSubnets(request *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("DescribeSubnets"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	if len(request.Filters) > 0 {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, "filters are not supported by the fake", nil)
+	}
+	output := &ec2.DescribeSubnetsOutput{}
+	if len(request.SubnetIds) == 0 {
+		for _, id := range sortedKeys(f.subnets) {
+			output.Subnets = append(output.Subnets, copyOf(f.subnets[id]))
+		}
+		return output, nil
+	}
+	for _, id := range request.SubnetIds {
+		subnet := f.subnets[aws.StringValue(id)]
+		if subnet == nil {
+			return nil, awserr.New(ErrCodeSubnetNotFound, fmt.Sprintf("The subnet ID '%s' does not exist", aws.StringValue(id)), nil)
+		}
+		output.Subnets = append(output.Subnets, copyOf(subnet))
+	}
+	return output, nil
+}
+
+// ModifyInstanceAttribute supports changing the source/destination check of an instance only
+func (f *EC2) ModifyInstanceAttribute(request *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("ModifyInstanceAttribute"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	instanceID := aws.StringValue(request.InstanceId)
+	instance := f.instances[instanceID]
+	if instance == nil {
+		return nil, instanceNotFound(instanceID)
+	}
+	if request.SourceDestCheck == nil || request.SourceDestCheck.Value == nil {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, "only the source/destination check attribute is supported by the fake", nil)
+	}
+	instance.SourceDestCheck = aws.Bool(aws.BoolValue(request.SourceDestCheck.Value))
+	return &ec2.ModifyInstanceAttributeOutput{}, nil
+}
+
+// DescribeVpcs returns the VPCs selected by ID, or all if no IDs are given
+func (f *EC2) DescribeVpcs(request *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("DescribeVpcs"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	if len(request.Filters) > 0 {
+		return nil, awserr.New(ErrCodeInvalidParameterValue, "filters are not supported by the fake", nil)
+	}
+	output := &ec2.DescribeVpcsOutput{}
+	if len(request.VpcIds) == 0 {
+		for _, id := range sortedKeys(f.vpcs) {
+			output.Vpcs = append(output.Vpcs, copyOf(f.vpcs[id]))
+		}
+		return output, nil
+	}
+	for _, id := range request.VpcIds {
+		vpc := f.vpcs[aws.StringValue(id)]
+		if vpc == nil {
+			return nil, awserr.New(ErrCodeVpcNotFound, fmt.Sprintf("The vpc ID '%s' does not exist", aws.StringValue(id)), nil)
+		}
+		output.Vpcs = append(output.Vpcs, copyOf(vpc))
+	}
+	return output, nil
+}
+
+// CreateTags adds or overwrites tags of route tables. All resources must exist, otherwise no tag is changed.
+func (f *EC2) CreateTags(request *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if err := f.call("CreateTags"); err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(request.DryRun) {
+		return nil, dryRunSucceeded()
+	}
+	for _, id := range request.Resources {
+		if f.routeTables[aws.StringValue(id)] == nil {
+			return nil, awserr.New(ErrCodeRouteTableNotFound, fmt.Sprintf("The routeTable ID '%s' does not exist", aws.StringValue(id)), nil)
+		}
+	}
+	for _, id := range request.Resources {
+		table := f.routeTables[aws.StringValue(id)]
+		for _, tag := range request.Tags {
+			table.Tags = setTag(table.Tags, copyOf(tag))
+		}
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// setTag replaces the tag with the same key or adds it
+func setTag(tags []*ec2.Tag, tag *ec2.Tag) []*ec2.Tag {
+	for i, existing := range tags {
+		if aws.StringValue(existing.Key) == aws.StringValue(tag.Key) {
+			tags[i] = tag
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+func dryRunSucceeded() error {
+	return awserr.New(ErrCodeDryRunOperation, "Request would have succeeded, but DryRun flag is set.", nil)
+}
+
+func instanceNotFound(instanceID string) error {
+	return awserr.New(ErrCodeInstanceNotFound, fmt.Sprintf("The instance ID '%s' does not exist", instanceID), nil)
+}
+
+// matchesFilters returns true if all filters match the given attribute values.
+// Filters on unsupported attributes result in an error.
+func matchesFilters(filters []*ec2.Filter, attributes map[string]string) (bool, error) {
+	for _, filter := range filters {
+		name := aws.StringValue(filter.Name)
+		value, ok := attributes[name]
+		if !ok {
+			return false, awserr.New(ErrCodeInvalidParameterValue, fmt.Sprintf("filter %q is not supported by the fake", name), nil)
+		}
+		if !contains(filter.Values, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func contains(values []*string, value string) bool {
+	for _, v := range values {
+		if aws.StringValue(v) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// copyOf returns a deep copy to decouple the internal state from callers
+func copyOf[T any](obj *T) *T {
+	return awsutil.CopyOf(obj).(*T)
+}
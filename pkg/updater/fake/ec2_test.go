@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fake_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("EC2", func() {
+	const (
+		clusterName = "test"
+		tableID     = "rtb-0001"
+		vpcID       = "vpc-0001"
+		instanceID  = "i-0001"
+	)
+
+	var f *fake.EC2
+
+	BeforeEach(func() {
+		f = fake.NewEC2()
+		f.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String(tableID),
+			VpcId:        aws.String(vpcID),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey(clusterName)), Value: aws.String("1")}},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String("10.250.0.0/16"),
+				GatewayId:            aws.String("local"),
+				Origin:               aws.String(ec2.RouteOriginCreateRouteTable),
+			}},
+		})
+		f.AddInstance(&ec2.Instance{
+			InstanceId:      aws.String(instanceID),
+			SourceDestCheck: aws.Bool(true),
+			State:           &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			NetworkInterfaces: []*ec2.InstanceNetworkInterface{{
+				NetworkInterfaceId: aws.String("eni-0001"),
+				Attachment:         &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)},
+			}},
+		})
+		f.AddVpc(&ec2.Vpc{VpcId: aws.String(vpcID), CidrBlock: aws.String("10.250.0.0/16")})
+	})
+
+	expectCode := func(err error, code string) {
+		var awsErr awserr.Error
+		ExpectWithOffset(1, errors.As(err, &awsErr)).To(BeTrue())
+		ExpectWithOffset(1, awsErr.Code()).To(Equal(code))
+	}
+
+	It("should create and delete routes", func() {
+		_, err := f.CreateRoute(&ec2.CreateRouteInput{
+			RouteTableId:         aws.String(tableID),
+			DestinationCidrBlock: aws.String("10.243.0.0/24"),
+			InstanceId:           aws.String(instanceID),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		table := f.RouteTable(tableID)
+		Expect(table.Routes).To(HaveLen(2))
+		Expect(aws.StringValue(table.Routes[1].InstanceId)).To(Equal(instanceID))
+		Expect(aws.StringValue(table.Routes[1].NetworkInterfaceId)).To(Equal("eni-0001"))
+		Expect(aws.StringValue(table.Routes[1].Origin)).To(Equal(ec2.RouteOriginCreateRoute))
+
+		_, err = f.CreateRoute(&ec2.CreateRouteInput{
+			RouteTableId:         aws.String(tableID),
+			DestinationCidrBlock: aws.String("10.243.0.0/24"),
+			InstanceId:           aws.String(instanceID),
+		})
+		expectCode(err, fake.ErrCodeRouteAlreadyExists)
+
+		_, err = f.DeleteRoute(&ec2.DeleteRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.243.0.0/24")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.RouteTable(tableID).Routes).To(HaveLen(1))
+
+		_, err = f.DeleteRoute(&ec2.DeleteRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.243.0.0/24")})
+		expectCode(err, fake.ErrCodeRouteNotFound)
+		_, err = f.DeleteRoute(&ec2.DeleteRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.250.0.0/16")})
+		expectCode(err, fake.ErrCodeInvalidParameterValue)
+	})
+
+	DescribeTable("should reject invalid routes",
+		func(input *ec2.CreateRouteInput, code string) {
+			_, err := f.CreateRoute(input)
+			expectCode(err, code)
+			Expect(f.RouteTable(tableID).Routes).To(HaveLen(1))
+		},
+		Entry("unknown table", &ec2.CreateRouteInput{RouteTableId: aws.String("rtb-9999"), DestinationCidrBlock: aws.String("10.243.0.0/24"), InstanceId: aws.String(instanceID)}, fake.ErrCodeRouteTableNotFound),
+		Entry("invalid destination", &ec2.CreateRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.243.0.0"), InstanceId: aws.String(instanceID)}, fake.ErrCodeInvalidParameterValue),
+		Entry("unknown instance", &ec2.CreateRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.243.0.0/24"), InstanceId: aws.String("i-9999")}, fake.ErrCodeInstanceNotFound),
+		Entry("no target", &ec2.CreateRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.243.0.0/24")}, fake.ErrCodeInvalidParameterCombination),
+		Entry("two targets", &ec2.CreateRouteInput{RouteTableId: aws.String(tableID), DestinationCidrBlock: aws.String("10.243.0.0/24"), InstanceId: aws.String(instanceID), GatewayId: aws.String("igw-0001")}, fake.ErrCodeInvalidParameterCombination),
+	)
+
+	It("should return copies of its state", func() {
+		output, err := f.DescribeRouteTables(&ec2.DescribeRouteTablesInput{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.RouteTables).To(HaveLen(1))
+		output.RouteTables[0].Routes = nil
+		Expect(f.RouteTable(tableID).Routes).To(HaveLen(1))
+	})
+
+	It("should filter route tables and instances", func() {
+		output, err := f.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("vpc-id"), Values: aws.StringSlice([]string{"vpc-9999"})}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.RouteTables).To(BeEmpty())
+
+		_, err = f.DescribeRouteTables(&ec2.DescribeRouteTablesInput{RouteTableIds: aws.StringSlice([]string{"rtb-9999"})})
+		expectCode(err, fake.ErrCodeRouteTableNotFound)
+		_, err = f.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("tag-key"), Values: aws.StringSlice([]string{"foo"})}},
+		})
+		Expect(err).To(HaveOccurred())
+
+		instances, err := f.DescribeInstances(&ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("instance-id"), Values: aws.StringSlice([]string{instanceID, "i-9999"})}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instances.Reservations).To(HaveLen(1))
+		Expect(instances.Reservations[0].Instances).To(HaveLen(1))
+
+		_, err = f.DescribeInstances(&ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice([]string{"i-9999"})})
+		expectCode(err, fake.ErrCodeInstanceNotFound)
+	})
+
+	It("should modify the source/destination check", func() {
+		_, err := f.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+			InstanceId:      aws.String(instanceID),
+			SourceDestCheck: &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(aws.BoolValue(f.Instance(instanceID).SourceDestCheck)).To(BeFalse())
+
+		f.RemoveInstance(instanceID)
+		_, err = f.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+			InstanceId:      aws.String(instanceID),
+			SourceDestCheck: &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+		})
+		expectCode(err, fake.ErrCodeInstanceNotFound)
+	})
+
+	It("should inject errors and count calls", func() {
+		injected := errors.New("injected")
+		f.SetError("DescribeVpcs", injected)
+		_, err := f.DescribeVpcs(&ec2.DescribeVpcsInput{})
+		Expect(err).To(MatchError(injected))
+
+		f.SetError("DescribeVpcs", nil)
+		output, err := f.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: aws.StringSlice([]string{vpcID})})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.Vpcs).To(HaveLen(1))
+		Expect(f.Calls("DescribeVpcs")).To(Equal(2))
+	})
+
+	It("should serve the custom routes updater", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), f, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		routes := []updater.NodeRoute{{InstanceID: instanceID, PodCIDR: "10.243.0.0/24"}}
+		result, err := customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Created).To(Equal(1))
+		Expect(f.RouteTable(tableID).Routes).To(HaveLen(2))
+
+		result, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Created).To(Equal(0))
+		Expect(f.Calls("CreateRoute")).To(Equal(1))
+
+		result, err = customRoutes.Update(nil, updater.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Deleted).To(Equal(1))
+		Expect(f.RouteTable(tableID).Routes).To(HaveLen(1))
+	})
+})
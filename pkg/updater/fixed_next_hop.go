@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// networkInterfaceIDPattern is the format of EC2 network interface IDs
+var networkInterfaceIDPattern = regexp.MustCompile(`^eni-[0-9a-f]+$`)
+
+// fixedNextHopTargetResolver returns the resolver routing the pod CIDRs of all nodes to the fixed instance or network interface.
+// It returns nil if no fixed next hop is given.
+func fixedNextHopTargetResolver(instanceID, networkInterfaceID string) (TargetResolver, error) {
+	switch {
+	case instanceID != "" && networkInterfaceID != "":
+		return nil, fmt.Errorf("only one of fixed next hop instance ID and network interface ID can be set")
+	case instanceID != "":
+		if !IsValidInstanceID(instanceID) {
+			return nil, fmt.Errorf("invalid fixed next hop instance ID %q", instanceID)
+		}
+		return FixedInstanceTargetResolver{InstanceID: instanceID}, nil
+	case networkInterfaceID != "":
+		if !networkInterfaceIDPattern.MatchString(networkInterfaceID) {
+			return nil, fmt.Errorf("invalid fixed next hop network interface ID %q", networkInterfaceID)
+		}
+		return NetworkInterfaceTargetResolver{NetworkInterfaceID: networkInterfaceID}, nil
+	}
+	return nil, nil
+}
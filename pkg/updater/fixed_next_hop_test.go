@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("fixed next hop", func() {
+	var (
+		cloud  *fake.EC2
+		routes []updater.NodeRoute
+	)
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		for _, instanceID := range []string{"i-0001", "i-0002", "i-0a0b0c"} {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(instanceID)})
+		}
+		routes = []updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"},
+		}
+	})
+
+	It("should route all nodes to the fixed instance", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			FixedNextHopInstanceID: "i-0a0b0c",
+		})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(ConsistOf(
+			And(HaveField("DestinationCidrBlock", Equal(aws.String("10.243.1.0/24"))), HaveField("InstanceId", Equal(aws.String("i-0a0b0c")))),
+			And(HaveField("DestinationCidrBlock", Equal(aws.String("10.243.2.0/24"))), HaveField("InstanceId", Equal(aws.String("i-0a0b0c")))),
+		))
+
+		// the routes to the fixed instance are kept
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.Calls("CreateRoute")).To(Equal(2))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(0))
+	})
+
+	It("should route all nodes to the fixed network interface", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			FixedNextHopENIID: "eni-0a0b0c",
+		})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(2))
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveEach(And(
+			HaveField("NetworkInterfaceId", Equal(aws.String("eni-0a0b0c"))),
+			HaveField("InstanceId", BeNil()),
+		)))
+	})
+
+	DescribeTable("should reject invalid fixed next hops",
+		func(options updater.CustomRoutesOptions, message string) {
+			_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", options)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("instance and network interface",
+			updater.CustomRoutesOptions{FixedNextHopInstanceID: "i-0a0b0c", FixedNextHopENIID: "eni-0a0b0c"},
+			"only one of fixed next hop instance ID and network interface ID can be set"),
+		Entry("invalid instance ID", updater.CustomRoutesOptions{FixedNextHopInstanceID: "eni-0a0b0c"}, `invalid fixed next hop instance ID "eni-0a0b0c"`),
+		Entry("invalid network interface ID", updater.CustomRoutesOptions{FixedNextHopENIID: "i-0a0b0c"}, `invalid fixed next hop network interface ID "i-0a0b0c"`),
+		Entry("combined with VPC peering connection",
+			updater.CustomRoutesOptions{
+				FixedNextHopInstanceID: "i-0a0b0c",
+				TargetResolver:         updater.VpcPeeringConnectionTargetResolver{VpcPeeringConnectionID: "pcx-1"},
+			},
+			"a fixed next hop cannot be combined with another route target"),
+	)
+})
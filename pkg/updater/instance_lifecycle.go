@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	// InstanceLifecyclePolicyIgnore programs the routes regardless of the instance state (except stopped instances with StoppedInstancePolicyRemove)
+	InstanceLifecyclePolicyIgnore = "ignore"
+	// InstanceLifecyclePolicyStateAware defers creating routes to pending instances and treats shutting-down and terminated instances as gone
+	InstanceLifecyclePolicyStateAware = "state-aware"
+)
+
+// instanceAction is the handling of a node route depending on the state of its instance
+type instanceAction string
+
+const (
+	// instanceActionProgram programs the route
+	instanceActionProgram instanceAction = "program"
+	// instanceActionDefer does not create the route but keeps an existing one, the update is repeated later
+	instanceActionDefer instanceAction = "defer"
+	// instanceActionSkip removes the route until the instance is running again, the update is repeated later
+	instanceActionSkip instanceAction = "skip"
+	// instanceActionRemove removes the route as if the node were gone
+	instanceActionRemove instanceAction = "remove"
+)
+
+// needsInstanceStates returns true if the routes depend on the states of the instances
+func (r *CustomRoutes) needsInstanceStates() bool {
+	return r.options.StoppedInstancePolicy == StoppedInstancePolicyRemove || r.options.InstanceLifecyclePolicy == InstanceLifecyclePolicyStateAware
+}
+
+// instanceActionOf returns the handling of a route to an instance in the given state (empty if unknown)
+func (r *CustomRoutes) instanceActionOf(state string) instanceAction {
+	if state == ec2.InstanceStateNameStopped && r.options.StoppedInstancePolicy == StoppedInstancePolicyRemove {
+		return instanceActionSkip
+	}
+	if r.options.InstanceLifecyclePolicy != InstanceLifecyclePolicyStateAware {
+		return instanceActionProgram
+	}
+	switch state {
+	case ec2.InstanceStateNamePending:
+		return instanceActionDefer
+	case ec2.InstanceStateNameShuttingDown, ec2.InstanceStateNameTerminated:
+		return instanceActionRemove
+	default:
+		return instanceActionProgram
+	}
+}
+
+// filterByInstanceState removes the node routes not to be programmed because of the state of their instances.
+// It returns the remaining routes, the destinations of the deferred routes to be kept, and if the update must be repeated later.
+// The routes of instances which could not be looked up are deferred as well and returned as error.
+func (r *CustomRoutes) filterByInstanceState(routes []NodeRoute) ([]NodeRoute, []string, bool, error) {
+	instances, failures := r.describeInstances(uniqueInstanceIDs(routes))
+	routes, deferred, err := withoutFailedLookups(routes, failures)
+	var (
+		result  []NodeRoute
+		recheck = len(failures) > 0
+	)
+	for _, route := range routes {
+		state := instanceState(instances[route.InstanceID])
+		switch r.instanceActionOf(state) {
+		case instanceActionSkip:
+			r.log.Info("skipping route to stopped instance", "destination", route.PodCIDR, "instanceId", route.InstanceID)
+			recheck = true
+		case instanceActionDefer:
+			r.log.Info("deferring route to pending instance", "destination", route.PodCIDR, "instanceId", route.InstanceID)
+			deferred = append(deferred, route.PodCIDR)
+			recheck = true
+		case instanceActionRemove:
+			r.log.Info("removing route to instance going away", "destination", route.PodCIDR, "instanceId", route.InstanceID, "state", state)
+		default:
+			result = append(result, route)
+		}
+	}
+	return result, deferred, recheck, err
+}
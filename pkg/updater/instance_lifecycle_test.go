@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// failingInstanceEC2 fails all instance lookups including the instance
+type failingInstanceEC2 struct {
+	*fake.EC2
+	instanceID string
+}
+
+func (f *failingInstanceEC2) DescribeInstances(request *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	for _, filter := range request.Filters {
+		for _, value := range filter.Values {
+			if aws.StringValue(value) == f.instanceID {
+				return nil, awserr.New("InternalError", "lookup failed", nil)
+			}
+		}
+	}
+	return f.EC2.DescribeInstances(request)
+}
+
+var _ = Describe("instance lifecycle policy", func() {
+	const destination = "10.243.1.0/24"
+
+	DescribeTable("should handle the route depending on the instance state",
+		func(lifecyclePolicy, stoppedPolicy, state string, existing, programmed, recheck bool) {
+			cloud := fake.NewEC2()
+			table := &ec2.RouteTable{
+				RouteTableId: aws.String("rtb-0001"),
+				Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			}
+			if existing {
+				table.Routes = []*ec2.Route{{
+					DestinationCidrBlock: aws.String(destination),
+					InstanceId:           aws.String("i-0001"),
+					Origin:               aws.String(ec2.RouteOriginCreateRoute),
+					State:                aws.String(ec2.RouteStateActive),
+				}}
+			}
+			cloud.AddRouteTable(table)
+			cloud.AddInstance(&ec2.Instance{
+				InstanceId: aws.String("i-0001"),
+				State:      &ec2.InstanceState{Name: aws.String(state)},
+			})
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+				InstanceLifecyclePolicy: lifecyclePolicy,
+				StoppedInstancePolicy:   stoppedPolicy,
+			})
+			Expect(err).To(BeNil())
+
+			result, err := customRoutes.Update([]updater.NodeRoute{{NodeName: "node1", InstanceID: "i-0001", PodCIDR: destination}}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(Equal(recheck))
+			if programmed {
+				Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+			} else {
+				Expect(cloud.RouteTable("rtb-0001").Routes).To(BeEmpty())
+			}
+		},
+		Entry("state-aware: running instance is programmed", updater.InstanceLifecyclePolicyStateAware, "", ec2.InstanceStateNameRunning, false, true, false),
+		Entry("state-aware: route to pending instance is not created", updater.InstanceLifecyclePolicyStateAware, "", ec2.InstanceStateNamePending, false, false, true),
+		Entry("state-aware: existing route to pending instance is kept", updater.InstanceLifecyclePolicyStateAware, "", ec2.InstanceStateNamePending, true, true, true),
+		Entry("state-aware: route to shutting-down instance is removed", updater.InstanceLifecyclePolicyStateAware, "", ec2.InstanceStateNameShuttingDown, true, false, false),
+		Entry("state-aware: route to terminated instance is removed", updater.InstanceLifecyclePolicyStateAware, "", ec2.InstanceStateNameTerminated, true, false, false),
+		Entry("state-aware: route to stopped instance is kept by default", updater.InstanceLifecyclePolicyStateAware, "", ec2.InstanceStateNameStopped, true, true, false),
+		Entry("state-aware: route to stopped instance is removed with stopped instance policy", updater.InstanceLifecyclePolicyStateAware, updater.StoppedInstancePolicyRemove, ec2.InstanceStateNameStopped, true, false, true),
+		Entry("ignore: route to pending instance is created", updater.InstanceLifecyclePolicyIgnore, "", ec2.InstanceStateNamePending, false, true, false),
+		Entry("ignore: route to terminated instance is kept", "", "", ec2.InstanceStateNameTerminated, true, true, false),
+	)
+
+	It("should only skip the routes of instances which cannot be looked up", func() {
+		cloud := fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String("10.243.2.0/24"),
+				InstanceId:           aws.String("i-0002"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+				State:                aws.String(ec2.RouteStateActive),
+			}},
+		})
+		for _, instanceID := range []string{"i-0001", "i-0002", "i-0003"} {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(instanceID), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}})
+		}
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), &failingInstanceEC2{EC2: cloud, instanceID: "i-0002"}, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			InstanceLifecyclePolicy: updater.InstanceLifecyclePolicyStateAware,
+		})
+		Expect(err).To(BeNil())
+
+		result, err := customRoutes.Update([]updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"},
+			{NodeName: "node3", InstanceID: "i-0003", PodCIDR: "10.243.3.0/24"},
+		}, updater.UpdateOptions{})
+		Expect(err).To(MatchError(ContainSubstring("looking up instance i-0002 of node node2 failed, route kept")))
+		Expect(result.Recheck).To(BeTrue())
+		Expect(result.Created).To(Equal(2))
+		Expect(result.Deleted).To(Equal(0))
+		Expect(result.NodeRouteTables).To(HaveKey("node1"))
+		Expect(result.NodeRouteTables).To(HaveKey("node3"))
+		Expect(result.NodeRouteTables).NotTo(HaveKey("node2"))
+		Expect(result.KeptNodes).To(Equal(map[string]bool{"node2": true}))
+		// the existing route of node2 is kept until its instance can be looked up again
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(ConsistOf(
+			HaveField("DestinationCidrBlock", Equal(aws.String("10.243.1.0/24"))),
+			HaveField("DestinationCidrBlock", Equal(aws.String("10.243.2.0/24"))),
+			HaveField("DestinationCidrBlock", Equal(aws.String("10.243.3.0/24"))),
+		))
+	})
+
+	It("should reject an invalid instance lifecycle policy", func() {
+		_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), fake.NewEC2(), "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			InstanceLifecyclePolicy: "invalid",
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid instance lifecycle policy")))
+	})
+})
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"go.uber.org/multierr"
+)
+
+const (
+	// maxInstanceIDsPerRequest is the maximum number of instance IDs used in a single filter of a DescribeInstances request
+	maxInstanceIDsPerRequest = 100
+	// errCodeInstanceNotFound is the AWS error code for unknown instance IDs
+	errCodeInstanceNotFound = "InvalidInstanceID.NotFound"
+)
+
+// instanceLookupFailures maps the IDs of the instances which could not be looked up to the errors
+type instanceLookupFailures map[string]error
+
+// describeInstances looks up the given instances in batches, of which up to the describe concurrency are in flight.
+// Instances not existing are missing in the returned map. If a batch fails, its instances are looked up one by one,
+// so that a failure only affects the instances which cannot be looked up.
+func (r *CustomRoutes) describeInstances(instanceIDs []string) (map[string]*ec2.Instance, instanceLookupFailures) {
+	var (
+		mutex     sync.Mutex
+		instances = map[string]*ec2.Instance{}
+		failures  = instanceLookupFailures{}
+	)
+	batches := (len(instanceIDs) + maxInstanceIDsPerRequest - 1) / maxInstanceIDsPerRequest
+	forEachConcurrently(batches, r.options.DescribeConcurrency, func(i int) {
+		start := i * maxInstanceIDsPerRequest
+		ids := instanceIDs[start:min(start+maxInstanceIDsPerRequest, len(instanceIDs))]
+		batch, batchFailures, err := r.describeInstanceBatch(ids)
+		if err != nil && len(ids) > 1 {
+			r.log.Info("describing instances failed, looking them up one by one", "instances", len(ids), "error", err.Error())
+			batch, batchFailures = map[string]*ec2.Instance{}, instanceLookupFailures{}
+			for _, id := range ids {
+				single, singleFailures, err := r.describeInstanceBatch([]string{id})
+				if err != nil {
+					singleFailures = instanceLookupFailures{id: err}
+				}
+				for id, instance := range single {
+					batch[id] = instance
+				}
+				for id, err := range singleFailures {
+					batchFailures[id] = err
+				}
+			}
+		} else if err != nil {
+			batchFailures = instanceLookupFailures{ids[0]: err}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for id, instance := range batch {
+			instances[id] = instance
+		}
+		for id, err := range batchFailures {
+			failures[id] = err
+		}
+	})
+	return instances, failures
+}
+
+// withoutFailedLookups removes the node routes whose instances could not be looked up. Their destinations are returned
+// to be kept, so that their existing routes are not deleted because of a temporary failure, and they are retried with the next update.
+func withoutFailedLookups(routes []NodeRoute, failures instanceLookupFailures) ([]NodeRoute, []string, error) {
+	if len(failures) == 0 {
+		return routes, nil, nil
+	}
+	var (
+		result []NodeRoute
+		kept   []string
+		errs   error
+	)
+	for _, route := range routes {
+		if err, failed := failures[route.InstanceID]; failed {
+			errs = multierr.Append(errs, fmt.Errorf("looking up instance %s%s failed, route kept: %w", route.InstanceID, ofNode(route.NodeName), err))
+			kept = append(kept, route.PodCIDR)
+			continue
+		}
+		result = append(result, route)
+	}
+	return result, kept, errs
+}
+
+// describeInstanceBatch looks up the instances of a single filter with all result pages.
+// It returns the instances whose lookup is ambiguous as failures and fails for errors of the requests.
+func (r *CustomRoutes) describeInstanceBatch(instanceIDs []string) (map[string]*ec2.Instance, instanceLookupFailures, error) {
+	matches := map[string][]instanceMatch{}
+	request := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("instance-id"),
+				Values: aws.StringSlice(instanceIDs),
+			},
+		},
+	}
+	for {
+		var response *ec2.DescribeInstancesOutput
+		err := r.describeLimiter.do(func() (err error) {
+			response, err = r.ec2.DescribeInstances(request)
+			return
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, reservation := range response.Reservations {
+			for _, instance := range reservation.Instances {
+				id := aws.StringValue(instance.InstanceId)
+				matches[id] = append(matches[id], instanceMatch{instance: instance, reservationID: aws.StringValue(reservation.ReservationId)})
+			}
+		}
+		if aws.StringValue(response.NextToken) == "" {
+			instances, failures := r.resolveInstanceMatches(matches)
+			return instances, failures, nil
+		}
+		request.NextToken = response.NextToken
+	}
+}
+
+// uniqueInstanceIDs returns the sorted instance IDs of the given node routes without duplicates
+func uniqueInstanceIDs(routes []NodeRoute) []string {
+	set := map[string]struct{}{}
+	for _, route := range routes {
+		set[route.InstanceID] = struct{}{}
+	}
+	var ids []string
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func isInstanceNotFound(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == errCodeInstanceNotFound
+}
+
+func instanceState(instance *ec2.Instance) string {
+	if instance == nil || instance.State == nil {
+		return ""
+	}
+	return aws.StringValue(instance.State.Name)
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// localGatewayID is the gateway of the implicit local routes of the VPC CIDRs
+const localGatewayID = "local"
+
+// ErrLocalRouteShadowed is returned for routes with a destination equal to or broader than a local route of the VPC.
+// Such a route would take over the traffic within the VPC, so it is never created.
+var ErrLocalRouteShadowed = errors.New("refusing to shadow local route")
+
+// shadowsLocalRoute returns true if the destination equals or contains the local CIDR
+func shadowsLocalRoute(destination, local string) bool {
+	_, destinationNet, err := net.ParseCIDR(destination)
+	if err != nil {
+		return false
+	}
+	_, localNet, err := net.ParseCIDR(local)
+	if err != nil {
+		return false
+	}
+	destinationOnes, destinationBits := destinationNet.Mask.Size()
+	localOnes, localBits := localNet.Mask.Size()
+	return destinationBits == localBits && destinationOnes <= localOnes && destinationNet.Contains(localNet.IP)
+}
+
+// refuseLocalRouteShadowing returns an error if the request would shadow a local route of the table,
+// e.g. because of a pod CIDR matching the VPC CIDR.
+func (r *CustomRoutes) refuseLocalRouteShadowing(table *ec2.RouteTable, req *ec2.CreateRouteInput, nodeName string) error {
+	if req.DestinationCidrBlock == nil {
+		return nil
+	}
+	for _, route := range table.Routes {
+		if aws.StringValue(route.GatewayId) != localGatewayID || route.DestinationCidrBlock == nil {
+			continue
+		}
+		if !shadowsLocalRoute(*req.DestinationCidrBlock, *route.DestinationCidrBlock) {
+			continue
+		}
+		r.log.Error(ErrLocalRouteShadowed, "refusing to create route shadowing the local route of the VPC",
+			"table", aws.StringValue(table.RouteTableId), "destination", *req.DestinationCidrBlock, "local", *route.DestinationCidrBlock, "node", nodeName)
+		return fmt.Errorf("%w %s with %s%s", ErrLocalRouteShadowed, *route.DestinationCidrBlock, *req.DestinationCidrBlock, ofNode(nodeName))
+	}
+	return nil
+}
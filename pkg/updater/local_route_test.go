@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("local route guard", func() {
+	var cloud *fake.EC2
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String("10.250.0.0/16"),
+				GatewayId:            aws.String("local"),
+				Origin:               aws.String(ec2.RouteOriginCreateRouteTable),
+			}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+	})
+
+	DescribeTable("should never shadow the local route of the VPC",
+		func(destination string) {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/8", updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+
+			result, err := customRoutes.Update([]updater.NodeRoute{
+				{NodeName: "bad", InstanceID: "i-0001", PodCIDR: destination},
+				{NodeName: "good", InstanceID: "i-0002", PodCIDR: "10.243.1.0/24"},
+			}, updater.UpdateOptions{})
+			Expect(err).To(MatchError(updater.ErrLocalRouteShadowed))
+			Expect(err).To(MatchError(ContainSubstring("10.250.0.0/16 with " + destination + " of node bad")))
+			Expect(result.Created).To(Equal(1))
+			Expect(cloud.RouteTable("rtb-0001").Routes).To(ConsistOf(
+				HaveField("GatewayId", Equal(aws.String("local"))),
+				HaveField("DestinationCidrBlock", Equal(aws.String("10.243.1.0/24"))),
+			))
+		},
+		Entry("equal to the VPC CIDR", "10.250.0.0/16"),
+		Entry("broader than the VPC CIDR", "10.248.0.0/13"),
+	)
+
+	It("should create routes more specific than the VPC CIDR", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.0.0.0/8", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update([]updater.NodeRoute{{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.250.1.0/24"}}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(2))
+	})
+})
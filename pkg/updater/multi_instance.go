@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+const (
+	// MultiInstancePolicyError skips the route of the node and keeps its existing route if the lookup of an instance ID
+	// returns several instances (default)
+	MultiInstancePolicyError = "error"
+	// MultiInstancePolicyPreferRunning picks a running instance, then the latest launched one, then the one of the lowest reservation ID
+	MultiInstancePolicyPreferRunning = "prefer-running"
+)
+
+// instanceMatch is an instance returned by the lookup of its instance ID
+type instanceMatch struct {
+	instance      *ec2.Instance
+	reservationID string
+}
+
+// resolveInstanceMatches returns the instance for each instance ID according to the multi instance policy,
+// and the ambiguous lookups as failures. Ambiguous lookups are always logged and counted, an instance is never picked arbitrarily.
+func (r *CustomRoutes) resolveInstanceMatches(matches map[string][]instanceMatch) (map[string]*ec2.Instance, instanceLookupFailures) {
+	instances := make(map[string]*ec2.Instance, len(matches))
+	failures := instanceLookupFailures{}
+	for id, candidates := range matches {
+		if len(candidates) > 1 {
+			metrics.MultiInstanceMatches.Inc()
+			if r.options.MultiInstancePolicy != MultiInstancePolicyPreferRunning {
+				r.log.Info("WARNING: instance lookup is ambiguous, skipping route", "instanceId", id, "instances", len(candidates))
+				failures[id] = fmt.Errorf("lookup of instance %s returned %d instances", id, len(candidates))
+				continue
+			}
+			sortInstanceMatches(candidates)
+			r.log.Info("WARNING: instance lookup is ambiguous, picking instance", "instanceId", id, "instances", len(candidates),
+				"reservationId", candidates[0].reservationID, "state", instanceState(candidates[0].instance))
+		}
+		instances[id] = candidates[0].instance
+	}
+	return instances, failures
+}
+
+// sortInstanceMatches orders running instances first, then the latest launched ones, then by reservation ID
+func sortInstanceMatches(candidates []instanceMatch) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		aRunning := instanceState(a.instance) == ec2.InstanceStateNameRunning
+		bRunning := instanceState(b.instance) == ec2.InstanceStateNameRunning
+		if aRunning != bRunning {
+			return aRunning
+		}
+		aLaunch, bLaunch := aws.TimeValue(a.instance.LaunchTime), aws.TimeValue(b.instance.LaunchTime)
+		if !aLaunch.Equal(bLaunch) {
+			return aLaunch.After(bLaunch)
+		}
+		return a.reservationID < b.reservationID
+	})
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("multi instance policy", func() {
+	var (
+		ec2RoutesMock *updater.MockEC2Routes
+		nodeRoutes    = []updater.NodeRoute{{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"}}
+	)
+
+	BeforeEach(func() {
+		ec2RoutesMock = updater.NewMockEC2Routes(gomock.NewController(GinkgoT()))
+	})
+
+	expectAmbiguousLookup := func() {
+		ec2RoutesMock.EXPECT().DescribeRouteTables(gomock.Any()).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		}}}, nil)
+		// the lookup of the instance ID returns a stopped and a running instance
+		ec2RoutesMock.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{
+			{
+				ReservationId: aws.String("r-0002"),
+				Instances: []*ec2.Instance{{
+					InstanceId: aws.String("i-0001"),
+					State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped)},
+					LaunchTime: aws.Time(time.Now()),
+				}},
+			},
+			{
+				ReservationId: aws.String("r-0001"),
+				Instances: []*ec2.Instance{{
+					InstanceId: aws.String("i-0001"),
+					State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+					LaunchTime: aws.Time(time.Now().Add(-time.Hour)),
+				}},
+			},
+		}}, nil)
+	}
+
+	newCustomRoutes := func(policy string) *updater.CustomRoutes {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			StoppedInstancePolicy: updater.StoppedInstancePolicyRemove,
+			MultiInstancePolicy:   policy,
+		})
+		Expect(err).To(BeNil())
+		return customRoutes
+	}
+
+	It("should fail the update by default", func() {
+		expectAmbiguousLookup()
+		before := testutil.ToFloat64(metrics.MultiInstanceMatches)
+		_, err := newCustomRoutes("").Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(MatchError(ContainSubstring("lookup of instance i-0001 returned 2 instances")))
+		Expect(testutil.ToFloat64(metrics.MultiInstanceMatches)).To(Equal(before + 1))
+	})
+
+	It("should pick the running instance with prefer-running", func() {
+		expectAmbiguousLookup()
+		ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+			DestinationCidrBlock: aws.String("10.243.1.0/24"),
+			InstanceId:           aws.String("i-0001"),
+			RouteTableId:         aws.String("rtb-0001"),
+		})
+		before := testutil.ToFloat64(metrics.MultiInstanceMatches)
+		result, err := newCustomRoutes(updater.MultiInstancePolicyPreferRunning).Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Created).To(Equal(1))
+		Expect(testutil.ToFloat64(metrics.MultiInstanceMatches)).To(Equal(before + 1))
+	})
+
+	It("should reject an invalid multi instance policy", func() {
+		_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			MultiInstancePolicy: "invalid",
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid multi instance policy")))
+	})
+})
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// NextHopIPAnnotation is the node annotation for routing the node pod CIDR to the network interface
+// owning the given private IP of the node instance
+const NextHopIPAnnotation = "aws-custom-route-controller.gardener.cloud/next-hop-ip"
+
+// describeNextHopInstances looks up the instances of the node routes with next hop IP
+func (r *CustomRoutes) describeNextHopInstances(routes []NodeRoute) (map[string]*ec2.Instance, instanceLookupFailures) {
+	var instanceIDs []string
+	seen := map[string]bool{}
+	for _, route := range routes {
+		if route.NextHopIP != "" && route.VpcPeeringConnectionID == "" && !seen[route.InstanceID] {
+			seen[route.InstanceID] = true
+			instanceIDs = append(instanceIDs, route.InstanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return nil, nil
+	}
+	return r.describeInstances(instanceIDs)
+}
+
+// nextHopNetworkInterface returns the ID of the network interface of the instance owning the private IP
+func nextHopNetworkInterface(instance *ec2.Instance, instanceID, ip string) (string, error) {
+	if net.ParseIP(ip).To4() == nil {
+		return "", fmt.Errorf("invalid next hop IP %q", ip)
+	}
+	if instance == nil {
+		return "", fmt.Errorf("instance %s of next hop IP %s not found", instanceID, ip)
+	}
+	for _, eni := range instance.NetworkInterfaces {
+		for _, address := range eni.PrivateIpAddresses {
+			if aws.StringValue(address.PrivateIpAddress) == ip {
+				return aws.StringValue(eni.NetworkInterfaceId), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("next hop IP %s does not belong to instance %s", ip, instanceID)
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("next hop IP", func() {
+	var (
+		cloud        *fake.EC2
+		customRoutes *updater.CustomRoutes
+	)
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{
+			InstanceId: aws.String("i-0001"),
+			NetworkInterfaces: []*ec2.InstanceNetworkInterface{
+				{
+					NetworkInterfaceId: aws.String("eni-primary"),
+					Attachment:         &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(0)},
+					PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{{PrivateIpAddress: aws.String("10.250.0.10"), Primary: aws.Bool(true)}},
+				},
+				{
+					NetworkInterfaceId: aws.String("eni-secondary"),
+					Attachment:         &ec2.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int64(1)},
+					PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{
+						{PrivateIpAddress: aws.String("10.250.0.20"), Primary: aws.Bool(true)},
+						{PrivateIpAddress: aws.String("10.250.0.21"), Primary: aws.Bool(false)},
+					},
+				},
+			},
+		})
+		var err error
+		customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+	})
+
+	It("should route to the network interface owning the secondary IP", func() {
+		_, err := customRoutes.Update([]updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24", NextHopIP: "10.250.0.21"},
+		}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(ConsistOf(HaveField("NetworkInterfaceId", Equal(aws.String("eni-secondary")))))
+		Expect(cloud.RouteTable("rtb-0001").Routes[0].InstanceId).To(BeNil())
+
+		// the route is kept as long as the IP belongs to the network interface
+		_, err = customRoutes.Update([]updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24", NextHopIP: "10.250.0.21"},
+		}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.Calls("CreateRoute")).To(Equal(1))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(0))
+	})
+
+	DescribeTable("should skip routes with next hop IP not belonging to the instance",
+		func(instanceID, ip, message string) {
+			_, err := customRoutes.Update([]updater.NodeRoute{
+				{NodeName: "node1", InstanceID: instanceID, PodCIDR: "10.243.1.0/24", NextHopIP: ip},
+			}, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring(message)))
+			Expect(cloud.RouteTable("rtb-0001").Routes).To(BeEmpty())
+		},
+		Entry("foreign IP", "i-0001", "10.250.0.30", "next hop IP 10.250.0.30 does not belong to instance i-0001"),
+		Entry("invalid IP", "i-0001", "10.250.0", `invalid next hop IP "10.250.0"`),
+		Entry("unknown instance", "i-0002", "10.250.0.21", "instance i-0002 of next hop IP 10.250.0.21 not found"),
+	)
+})
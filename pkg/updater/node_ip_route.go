@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// extractNodeIPRoute extracts a route for the internal IPv4 address of the node as /32 destination.
+// It is the fallback for setups routing the node IPs instead of pod CIDRs.
+func extractNodeIPRoute(node *corev1.Node) *NodeRoute {
+	if node == nil {
+		return nil
+	}
+	ip := NodeInternalIPv4(node)
+	if ip == nil {
+		return nil
+	}
+	zone, instanceID, _ := decodeRegionAndInstanceID(node.Spec.ProviderID)
+	route := NewNodeRoute(instanceID, (&net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}).String())
+	if route != nil {
+		setNodeDetails(route, node, zone)
+	}
+	return route
+}
+
+// NodeInternalIPv4 returns the first internal IPv4 address of the node
+func NodeInternalIPv4(node *corev1.Node) net.IP {
+	for _, address := range node.Status.Addresses {
+		if address.Type != corev1.NodeInternalIP {
+			continue
+		}
+		if ip := net.ParseIP(address.Address).To4(); ip != nil {
+			return ip
+		}
+	}
+	return nil
+}
@@ -9,8 +9,11 @@ package updater
 import (
 	"fmt"
 	"net"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gardener/aws-custom-route-controller/pkg/util"
 	corev1 "k8s.io/api/core/v1"
@@ -18,12 +21,37 @@ import (
 
 // NodeRoute stores node internal IP and the pod CIDRs
 type NodeRoute struct {
+	NodeName   string
 	InstanceID string
-	PodCIDR    string
+	// PodCIDR is the destination of the route, i.e. the pod CIDR of the node or its internal IP as /32 with the node IP fallback
+	PodCIDR string
+	// Zone is the availability zone of the node (if known)
+	Zone string
+	// Ready is set if the node is ready
+	Ready bool
+	// NotReadySince is the last transition time of the ready condition if the node is not ready (zero if unknown)
+	NotReadySince time.Time
+	// CreationTimestamp is the creation time of the node
+	CreationTimestamp time.Time
+	// VpcPeeringConnectionID overrides the route target with a VPC peering connection (optional)
+	VpcPeeringConnectionID string
+	// NextHopIP routes the pod CIDR to the network interface of the instance owning this private IP (optional)
+	NextHopIP string
+}
+
+// VpcPeeringConnectionAnnotation is the node annotation for routing the node pod CIDR to a VPC peering connection
+const VpcPeeringConnectionAnnotation = "aws-custom-route-controller.gardener.cloud/vpc-peering-connection-id"
+
+// instanceIDPattern is the format of EC2 instance IDs
+var instanceIDPattern = regexp.MustCompile(`^i-[0-9a-f]+$`)
+
+// IsValidInstanceID returns true if the instance ID has the format of EC2 instance IDs
+func IsValidInstanceID(instanceID string) bool {
+	return instanceIDPattern.MatchString(instanceID)
 }
 
 func NewNodeRoute(instanceID, podCIDR string) *NodeRoute {
-	if instanceID == "" || podCIDR == "" {
+	if !IsValidInstanceID(instanceID) || podCIDR == "" {
 		return nil
 	}
 
@@ -37,19 +65,73 @@ func NewNodeRoute(instanceID, podCIDR string) *NodeRoute {
 	return nodeRoute
 }
 
+// Equals returns true if the routing relevant fields are equal. The readiness and the creation time of the node
+// are side data, which change without affecting the route.
 func (r NodeRoute) Equals(other *NodeRoute) bool {
 	if other == nil {
 		return false
 	}
-	return r == *other
+	return r.NodeName == other.NodeName && r.InstanceID == other.InstanceID && r.PodCIDR == other.PodCIDR && r.Zone == other.Zone &&
+		r.VpcPeeringConnectionID == other.VpcPeeringConnectionID && r.NextHopIP == other.NextHopIP
 }
 
-type NodeRoutesUpdater func(routes []NodeRoute) error
+// UpdateOptions controls which route changes are applied by a NodeRoutesUpdater
+type UpdateOptions struct {
+	// CreateOnly skips the deletion of routes not contained in the given node routes
+	CreateOnly bool
+	// Force diffs all route tables, even if they have not changed since they were found in sync
+	Force bool
+	// Abort stops applying changes as soon as it is closed, e.g. when the leadership is lost (optional)
+	Abort <-chan struct{}
+	// KeepCIDRs are destinations whose routes are never deleted, e.g. the pod CIDRs of nodes managed by another controller
+	KeepCIDRs []string
+	// ReuseRouteTables skips describing the route tables and uses the ones found by the previous update instead,
+	// e.g. for the create-only batches of a full sync, which do not depend on the routes created by the other batches
+	ReuseRouteTables bool
+}
+
+// UpdateResult contains details about the outcome of an update
+type UpdateResult struct {
+	// Recheck is set if some node routes have been skipped temporarily and the update should be repeated soon
+	Recheck bool
+	// RouteTables maps the pod CIDRs to the IDs of the route tables containing their routes after the update
+	RouteTables map[string][]string
+	// NodeRouteTables maps the node names to the IDs of all route tables containing their routes after the update,
+	// including the main route table, e.g. for tracing AZ-scoped routing
+	NodeRouteTables map[string][]string
+	// KeptNodes contains the nodes skipped by the update whose existing routes are kept, e.g. after a failed instance lookup
+	KeptNodes map[string]bool
+	// Created is the number of routes created by the update
+	Created int
+	// Deleted is the number of routes deleted by the update
+	Deleted int
+	// Failed is the number of routes which could not be created
+	Failed int
+	// DeletionsSkipped is the number of route deletions skipped because they exceed MaxDeletionsPerUpdate
+	DeletionsSkipped int
+	// Adopted is the number of pre-existing routes adopted by the update with AdoptExistingRoutes
+	Adopted int
+	// MissingRoutes is the number of desired routes not existing in observe mode
+	MissingRoutes int
+	// ObsoleteRoutes is the number of managed routes which would be deleted in observe mode
+	ObsoleteRoutes int
+	// NodeDrift maps the node names to descriptions of the route changes which would be applied for them in observe mode
+	NodeDrift map[string][]string
+}
+
+type NodeRoutesUpdater func(routes []NodeRoute, options UpdateOptions) (*UpdateResult, error)
+
+// DriftDetector checks for missing routes and returns their number
+type DriftDetector func() (int, error)
 
 type NamedNodeRoutes struct {
 	sync.Mutex
 	routes  map[string]NodeRoute
 	changed bool
+	// pendingSince is the time of the first node route change not yet returned by GetRoutesIfChanged
+	pendingSince time.Time
+	// fallbackToNodeIP routes the internal IP of nodes without pod CIDR
+	fallbackToNodeIP bool
 }
 
 func NewNamedNodeRoutes() *NamedNodeRoutes {
@@ -58,8 +140,18 @@ func NewNamedNodeRoutes() *NamedNodeRoutes {
 	}
 }
 
+// SetFallbackToNodeIP enables routing a /32 destination for the internal IP of nodes without pod CIDR to their instance
+func (r *NamedNodeRoutes) SetFallbackToNodeIP(enabled bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.fallbackToNodeIP = enabled
+}
+
 func (r *NamedNodeRoutes) AddNodeRoute(node *corev1.Node) (*NodeRoute, bool) {
 	route := extractNodeRoute(node)
+	if route == nil && r.isFallbackToNodeIP() {
+		route = extractNodeIPRoute(node)
+	}
 	if route == nil {
 		return nil, false
 	}
@@ -69,13 +161,21 @@ func (r *NamedNodeRoutes) AddNodeRoute(node *corev1.Node) (*NodeRoute, bool) {
 
 	changed := false
 	if !r.routes[node.Name].Equals(route) {
-		r.routes[node.Name] = *route
 		changed = true
 		r.changed = true
+		r.markPending()
 	}
+	// the side data is kept up to date for the next update
+	r.routes[node.Name] = *route
 	return route, changed
 }
 
+func (r *NamedNodeRoutes) isFallbackToNodeIP() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.fallbackToNodeIP
+}
+
 func (r *NamedNodeRoutes) RemoveNodeRoute(nodeName string) *NodeRoute {
 	r.Lock()
 	defer r.Unlock()
@@ -83,6 +183,7 @@ func (r *NamedNodeRoutes) RemoveNodeRoute(nodeName string) *NodeRoute {
 	if nr, ok := r.routes[nodeName]; ok {
 		delete(r.routes, nodeName)
 		r.changed = true
+		r.markPending()
 		return &nr
 	}
 
@@ -100,9 +201,37 @@ func (r *NamedNodeRoutes) GetRoutesIfChanged() []NodeRoute {
 		routes = append(routes, route)
 	}
 	r.changed = false
+	r.pendingSince = time.Time{}
 	return routes
 }
 
+// markPending remembers the time of the first pending node route change, must be called with lock held
+func (r *NamedNodeRoutes) markPending() {
+	if r.pendingSince.IsZero() {
+		r.pendingSince = time.Now()
+	}
+}
+
+// Settled returns true if no node route change is pending for less than the debounce window.
+// The window starts with the first pending change, so that continuous changes cannot delay the update any longer.
+func (r *NamedNodeRoutes) Settled(window time.Duration) bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.pendingSince.IsZero() || time.Since(r.pendingSince) >= window
+}
+
+// PodCIDRs returns the sorted destinations of all node routes
+func (r *NamedNodeRoutes) PodCIDRs() []string {
+	r.Lock()
+	defer r.Unlock()
+	var cidrs []string
+	for _, route := range r.routes {
+		cidrs = append(cidrs, route.PodCIDR)
+	}
+	sort.Strings(cidrs)
+	return cidrs
+}
+
 func (r *NamedNodeRoutes) SetChanged() {
 	r.Lock()
 	defer r.Unlock()
@@ -114,9 +243,69 @@ func extractNodeRoute(node *corev1.Node) *NodeRoute {
 	if node == nil {
 		return nil
 	}
+	zone, instanceID, _ := decodeRegionAndInstanceID(node.Spec.ProviderID)
+	podCIDR, _ := util.GetIPv4CIDR(NodePodCIDRs(node))
+	route := NewNodeRoute(instanceID, podCIDR)
+	if route != nil {
+		setNodeDetails(route, node, zone)
+	}
+	return route
+}
+
+// setNodeDetails sets the node specific fields of the route
+func setNodeDetails(route *NodeRoute, node *corev1.Node, zone string) {
+	route.NodeName = node.Name
+	route.Ready = IsNodeReady(node)
+	if !route.Ready {
+		route.NotReadySince = nodeNotReadySince(node)
+	}
+	route.CreationTimestamp = node.CreationTimestamp.Time
+	route.VpcPeeringConnectionID = node.Annotations[VpcPeeringConnectionAnnotation]
+	route.NextHopIP = node.Annotations[NextHopIPAnnotation]
+	route.Zone = zone
+	if label := node.Labels[corev1.LabelTopologyZone]; label != "" {
+		route.Zone = label
+	}
+}
+
+// IsNodeReady returns true if the node has the ready condition with status true
+func IsNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeNotReadySince returns the last transition time of the ready condition (zero if the node has none)
+func nodeNotReadySince(node *corev1.Node) time.Time {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
+}
+
+// NodePodCIDRs returns the pod CIDRs of the node. Some nodes (e.g. Windows nodes in mixed clusters
+// set up by older tooling) only report the single pod CIDR field, which is used as fallback then.
+func NodePodCIDRs(node *corev1.Node) []string {
+	if len(node.Spec.PodCIDRs) == 0 && node.Spec.PodCIDR != "" {
+		return []string{node.Spec.PodCIDR}
+	}
+	return node.Spec.PodCIDRs
+}
+
+// NodeInstanceID returns the instance ID from the provider ID of the node (empty if not decodable)
+func NodeInstanceID(node *corev1.Node) string {
 	_, instanceID, _ := decodeRegionAndInstanceID(node.Spec.ProviderID)
-	podCIDR, _ := util.GetIPv4CIDR(node.Spec.PodCIDRs)
-	return NewNodeRoute(instanceID, podCIDR)
+	return instanceID
+}
+
+// IsForeignProviderID returns true if the provider ID is set but not of AWS, e.g. for nodes of other clouds in hybrid clusters
+func IsForeignProviderID(providerID string) bool {
+	return providerID != "" && !strings.HasPrefix(providerID, "aws://")
 }
 
 // decodeRegionAndInstanceID extracts region and instanceID
@@ -5,6 +5,8 @@
 package updater_test
 
 import (
+	"time"
+
 	"github.com/gardener/aws-custom-route-controller/pkg/updater"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -29,7 +31,8 @@ var _ = Describe("NamedNodeRoutes", func() {
 		node2InstanceID = "i-0001"
 		node2           = &corev1.Node{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "node2",
+				Name:   "node2",
+				Labels: map[string]string{corev1.LabelTopologyZone: "eu-west-1b"},
 			},
 			Spec: corev1.NodeSpec{
 				PodCIDRs:   podCIDRs2,
@@ -50,14 +53,14 @@ var _ = Describe("NamedNodeRoutes", func() {
 	It("should extract node data", func() {
 		routes := updater.NewNamedNodeRoutes()
 		route1, changed1 := routes.AddNodeRoute(node1)
-		Expect(route1).To(Equal(updater.NewNodeRoute(node1InstanceID, podCIDRs1[0])))
+		Expect(route1).To(Equal(&updater.NodeRoute{NodeName: node1.Name, InstanceID: node1InstanceID, PodCIDR: podCIDRs1[0], Zone: "eu-west-1a"}))
 		Expect(changed1).To(BeTrue())
 		route1b, changed1b := routes.AddNodeRoute(node1)
 		Expect(route1b).NotTo(BeNil())
 		Expect(changed1b).To(BeFalse())
 
 		route2, changed2 := routes.AddNodeRoute(node2)
-		Expect(route2).To(Equal(updater.NewNodeRoute(node2InstanceID, podCIDRs2[0])))
+		Expect(route2).To(Equal(&updater.NodeRoute{NodeName: node2.Name, InstanceID: node2InstanceID, PodCIDR: podCIDRs2[0], Zone: "eu-west-1b"}))
 		Expect(changed2).To(BeTrue())
 
 		route3, changed3 := routes.AddNodeRoute(node3)
@@ -83,6 +86,102 @@ var _ = Describe("NamedNodeRoutes", func() {
 		routes2 := routes.GetRoutesIfChanged()
 		Expect(len(routes2)).To(Equal(1))
 	})
+
+	It("should route the node IP as fallback only if enabled", func() {
+		nodeWithoutPodCIDR := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node4",
+			},
+			Spec: corev1.NodeSpec{
+				ProviderID: makeProviderID("i-0004"),
+			},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeHostName, Address: "node4"},
+					{Type: corev1.NodeInternalIP, Address: "fd00::4"},
+					{Type: corev1.NodeInternalIP, Address: "10.250.0.4"},
+				},
+			},
+		}
+		routes := updater.NewNamedNodeRoutes()
+		route, changed := routes.AddNodeRoute(nodeWithoutPodCIDR)
+		Expect(route).To(BeNil())
+		Expect(changed).To(BeFalse())
+
+		routes.SetFallbackToNodeIP(true)
+		route, changed = routes.AddNodeRoute(nodeWithoutPodCIDR)
+		Expect(route).To(Equal(&updater.NodeRoute{NodeName: "node4", InstanceID: "i-0004", PodCIDR: "10.250.0.4/32", Zone: "eu-west-1a"}))
+		Expect(changed).To(BeTrue())
+
+		// nodes with pod CIDR use the normal path
+		route, _ = routes.AddNodeRoute(node1)
+		Expect(route.PodCIDR).To(Equal(podCIDRs1[0]))
+	})
+
+	DescribeTable("should validate instance IDs",
+		func(instanceID string, valid bool) {
+			Expect(updater.IsValidInstanceID(instanceID)).To(Equal(valid))
+			Expect(updater.NewNodeRoute(instanceID, "10.0.1.0/24") != nil).To(Equal(valid))
+		},
+		Entry("short instance ID", "i-0a1b2c3d", true),
+		Entry("long instance ID", "i-0123456789abcdef0", true),
+		Entry("empty", "", false),
+		Entry("missing prefix", "0123456789abcdef0", false),
+		Entry("upper case", "i-0123456789ABCDEF0", false),
+		Entry("garbage", "i-12; DROP", false),
+		Entry("other resource", "eni-0123", false),
+	)
+
+	It("should take the VPC peering connection from the node annotation", func() {
+		peeredNode := node1.DeepCopy()
+		peeredNode.Annotations = map[string]string{updater.VpcPeeringConnectionAnnotation: "pcx-0001"}
+		routes := updater.NewNamedNodeRoutes()
+		route, changed := routes.AddNodeRoute(peeredNode)
+		Expect(changed).To(BeTrue())
+		Expect(route.VpcPeeringConnectionID).To(Equal("pcx-0001"))
+
+		_, changed = routes.AddNodeRoute(node1)
+		Expect(changed).To(BeTrue())
+	})
+
+	It("should take the next hop IP from the node annotation", func() {
+		node := node1.DeepCopy()
+		node.Annotations = map[string]string{updater.NextHopIPAnnotation: "10.250.0.21"}
+		routes := updater.NewNamedNodeRoutes()
+		route, changed := routes.AddNodeRoute(node)
+		Expect(changed).To(BeTrue())
+		Expect(route.NextHopIP).To(Equal("10.250.0.21"))
+	})
+
+	It("should keep readiness changes as side data without reporting a change", func() {
+		routes := updater.NewNamedNodeRoutes()
+		_, changed := routes.AddNodeRoute(node1)
+		Expect(changed).To(BeTrue())
+		Expect(routes.GetRoutesIfChanged()).To(HaveLen(1))
+
+		readyNode := node1.DeepCopy()
+		readyNode.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+		route, changed := routes.AddNodeRoute(readyNode)
+		Expect(changed).To(BeFalse())
+		Expect(route.Ready).To(BeTrue())
+		Expect(route.NotReadySince.IsZero()).To(BeTrue())
+		Expect(routes.GetRoutesIfChanged()).To(BeNil())
+
+		routes.SetChanged()
+		stored := routes.GetRoutesIfChanged()
+		Expect(stored).To(HaveLen(1))
+		Expect(stored[0].Ready).To(BeTrue())
+	})
+
+	It("should record since when the node is not ready", func() {
+		routes := updater.NewNamedNodeRoutes()
+		since := metav1.NewTime(metav1.Now().Add(-time.Minute).Truncate(time.Second))
+		notReadyNode := node1.DeepCopy()
+		notReadyNode.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse, LastTransitionTime: since}}
+		route, _ := routes.AddNodeRoute(notReadyNode)
+		Expect(route.Ready).To(BeFalse())
+		Expect(route.NotReadySince).To(Equal(since.Time))
+	})
 })
 
 func makeProviderID(instanceID string) string {
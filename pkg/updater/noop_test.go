@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("no-op routes", func() {
+	It("should count the routes already existing with the correct target", func() {
+		cloud := fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{
+				{DestinationCidrBlock: aws.String("10.243.1.0/24"), InstanceId: aws.String("i-0001"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				{DestinationCidrBlock: aws.String("10.243.2.0/24"), InstanceId: aws.String("i-0001"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+			},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0003")})
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		routes := []updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"},
+			{NodeName: "node3", InstanceID: "i-0003", PodCIDR: "10.243.3.0/24"},
+		}
+		before := testutil.ToFloat64(metrics.RoutesNoop)
+
+		// only the route of node1 is correct, the route of node2 has the wrong target
+		result, err := customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Created).To(Equal(2))
+		Expect(result.Deleted).To(Equal(1))
+		Expect(testutil.ToFloat64(metrics.RoutesNoop)).To(Equal(before + 1))
+
+		// all routes are correct now, also if the route table is skipped as unchanged
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{Force: true})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.RoutesNoop)).To(Equal(before + 4))
+		_, err = customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.RoutesNoop)).To(Equal(before + 7))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(2))
+	})
+})
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import "fmt"
+
+const (
+	// ModeManage creates and deletes the routes
+	ModeManage = "manage"
+	// ModeObserve never modifies AWS resources but only reports the differences between desired and actual routes
+	ModeObserve = "observe"
+)
+
+// observeChanges reports the planned changes of a route table without applying them.
+// The missing routes are reported as failed, so that they are not considered programmed.
+func (r *CustomRoutes) observeChanges(plan tableChanges, result *UpdateResult) tableOutcome {
+	table := plan.table
+	outcome := tableOutcome{failed: map[string]bool{}}
+	obsolete := map[string]internalNodeRoute{}
+	for _, del := range plan.toBeDeleted {
+		r.log.Info("drift: obsolete route", "table", *table.RouteTableId, "destination", del.destinationCidrBlock, "target", del.target.String())
+		obsolete[del.destinationCidrBlock] = del
+	}
+	for _, create := range plan.toBeCreated {
+		r.log.Info("drift: missing route", "table", *table.RouteTableId, "destination", create.destinationCidrBlock, "target", create.target.String())
+		outcome.failed[create.destinationCidrBlock] = true
+		if create.nodeName == "" {
+			continue
+		}
+		if result.NodeDrift == nil {
+			result.NodeDrift = map[string][]string{}
+		}
+		change := fmt.Sprintf("route %s -> %s would be created in table %s", create.destinationCidrBlock, create.target, *table.RouteTableId)
+		if del, ok := obsolete[create.destinationCidrBlock]; ok {
+			change = fmt.Sprintf("route %s in table %s would be changed from %s to %s", create.destinationCidrBlock, *table.RouteTableId, del.target, create.target)
+		}
+		result.NodeDrift[create.nodeName] = append(result.NodeDrift[create.nodeName], change)
+	}
+	result.MissingRoutes += len(plan.toBeCreated)
+	result.ObsoleteRoutes += len(plan.toBeDeleted)
+	return outcome
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("observe mode", func() {
+	It("should describe the route changes per node", func() {
+		cloud := fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{
+				{DestinationCidrBlock: aws.String("10.243.1.0/24"), InstanceId: aws.String("i-0001"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				{DestinationCidrBlock: aws.String("10.243.2.0/24"), InstanceId: aws.String("i-0009"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+			},
+		})
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			Mode: updater.ModeObserve,
+		})
+		Expect(err).To(BeNil())
+
+		result, err := customRoutes.Update([]updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"},
+			{NodeName: "node3", InstanceID: "i-0003", PodCIDR: "10.243.3.0/24"},
+		}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.NodeDrift).To(Equal(map[string][]string{
+			"node2": {"route 10.243.2.0/24 in table rtb-0001 would be changed from i-0009 to i-0002"},
+			"node3": {"route 10.243.3.0/24 -> i-0003 would be created in table rtb-0001"},
+		}))
+		Expect(cloud.Calls("CreateRoute")).To(Equal(0))
+		Expect(cloud.Calls("DeleteRoute")).To(Equal(0))
+	})
+})
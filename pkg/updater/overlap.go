@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"net"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+)
+
+// countShadowedRoutes counts the desired routes of the table overlapping with a more specific existing route.
+// As AWS uses longest-prefix match, these routes do not take effect for the overlapping part.
+func (r *CustomRoutes) countShadowedRoutes(table *ec2.RouteTable, desired []internalNodeRoute) int {
+	if r.isMainTable(table) {
+		return 0
+	}
+	// managed routes are either desired or deleted
+	managed := map[string]bool{}
+	for _, route := range r.managedRoutes(table) {
+		managed[*route.DestinationCidrBlock] = true
+	}
+	count := 0
+	for _, nr := range desired {
+		_, destination, err := net.ParseCIDR(nr.destinationCidrBlock)
+		if err != nil {
+			continue
+		}
+		for _, route := range table.Routes {
+			if route.DestinationCidrBlock == nil || *route.DestinationCidrBlock == nr.destinationCidrBlock || managed[*route.DestinationCidrBlock] {
+				continue
+			}
+			_, other, err := net.ParseCIDR(*route.DestinationCidrBlock)
+			if err != nil || !util.ContainsCIDR(destination, other) {
+				continue
+			}
+			r.log.Info("WARNING: route is shadowed by a more specific route and does not take effect completely",
+				"table", *table.RouteTableId, "destination", nr.destinationCidrBlock, "moreSpecificRoute", *route.DestinationCidrBlock,
+				"target", targetOf(route).String())
+			count++
+			break
+		}
+	}
+	return count
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	// PermissionAllowed is the result of an action permitted for the credentials
+	PermissionAllowed = "PASS"
+	// PermissionDenied is the result of an action not permitted for the credentials
+	PermissionDenied = "FAIL"
+	// PermissionUnknown is the result of an action whose probe failed for another reason
+	PermissionUnknown = "UNKNOWN"
+
+	// errCodeDryRunOperation is the AWS error code of a dry run request which would have succeeded
+	errCodeDryRunOperation = "DryRunOperation"
+	// placeholderInstanceID is used for probing actions on instances
+	placeholderInstanceID = "i-00000000000000000"
+	// placeholderRouteTableID is used for probing route actions if no route table could be found
+	placeholderRouteTableID = "rtb-00000000000000000"
+)
+
+// permissionDeniedCodes are the AWS error codes signalling missing permissions
+var permissionDeniedCodes = map[string]bool{
+	"UnauthorizedOperation": true,
+	"AuthFailure":           true,
+	"AccessDenied":          true,
+}
+
+// PermissionCheck is the result of probing an EC2 action with a dry run request
+type PermissionCheck struct {
+	// Action is the IAM action, e.g. ec2:CreateRoute
+	Action string
+	// Result is one of PermissionAllowed, PermissionDenied and PermissionUnknown
+	Result string
+	// Message explains the result if the action is not allowed
+	Message string
+}
+
+// permissionProbe sends a dry run request for an action
+type permissionProbe struct {
+	action string
+	probe  func(tableID string) error
+}
+
+// permissionProbes returns the probes of the actions needed with the configured options
+func (r *CustomRoutes) permissionProbes() []permissionProbe {
+	podNetwork := r.podNetwork.String()
+	probes := []permissionProbe{
+		{"ec2:DescribeRouteTables", func(_ string) error {
+			_, err := r.ec2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{DryRun: aws.Bool(true)})
+			return err
+		}},
+		{"ec2:DescribeVpcs", func(_ string) error {
+			_, err := r.ec2.DescribeVpcs(&ec2.DescribeVpcsInput{DryRun: aws.Bool(true)})
+			return err
+		}},
+	}
+	observe := r.options.Mode == ModeObserve
+	if !observe {
+		probes = append(probes,
+			permissionProbe{"ec2:CreateRoute", func(tableID string) error {
+				_, err := r.ec2.CreateRoute(&ec2.CreateRouteInput{
+					DryRun:               aws.Bool(true),
+					RouteTableId:         aws.String(tableID),
+					DestinationCidrBlock: aws.String(podNetwork),
+					InstanceId:           aws.String(placeholderInstanceID),
+				})
+				return err
+			}},
+			permissionProbe{"ec2:DeleteRoute", func(tableID string) error {
+				_, err := r.ec2.DeleteRoute(&ec2.DeleteRouteInput{
+					DryRun:               aws.Bool(true),
+					RouteTableId:         aws.String(tableID),
+					DestinationCidrBlock: aws.String(podNetwork),
+				})
+				return err
+			}},
+		)
+	}
+	if r.options.SelfTagRouteTables != SelfTagRouteTablesOff && !observe {
+		probes = append(probes, permissionProbe{"ec2:CreateTags", func(tableID string) error {
+			_, err := r.ec2.CreateTags(&ec2.CreateTagsInput{
+				DryRun:    aws.Bool(true),
+				Resources: []*string{aws.String(tableID)},
+				Tags:      []*ec2.Tag{{Key: aws.String(SelfTagKey), Value: aws.String(selfTagValue)}},
+			})
+			return err
+		}})
+	}
+	if r.needsInstanceStates() {
+		probes = append(probes, permissionProbe{"ec2:DescribeInstances", func(_ string) error {
+			_, err := r.ec2.DescribeInstances(&ec2.DescribeInstancesInput{DryRun: aws.Bool(true)})
+			return err
+		}})
+	}
+	if r.options.AZScopedRouting || r.options.PerAZRouteMetrics {
+		probes = append(probes, permissionProbe{"ec2:DescribeSubnets", func(_ string) error {
+			_, err := r.ec2.DescribeSubnets(&ec2.DescribeSubnetsInput{DryRun: aws.Bool(true)})
+			return err
+		}})
+	}
+	if r.options.ManageSourceDestCheck && !observe {
+		probes = append(probes, permissionProbe{"ec2:ModifyInstanceAttribute", func(_ string) error {
+			_, err := r.ec2.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+				DryRun:          aws.Bool(true),
+				InstanceId:      aws.String(placeholderInstanceID),
+				SourceDestCheck: &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+			})
+			return err
+		}})
+	}
+	return probes
+}
+
+// CheckPermissions probes the EC2 actions needed with the configured options using dry run requests.
+// Route actions are probed on the first cluster route table, no changes are made.
+func (r *CustomRoutes) CheckPermissions() []PermissionCheck {
+	tableID := placeholderRouteTableID
+	if tables, err := r.findRouteTables(); err == nil {
+		tableID = aws.StringValue(tables[0].RouteTableId)
+	}
+	var checks []PermissionCheck
+	for _, p := range r.permissionProbes() {
+		checks = append(checks, permissionCheckOf(p.action, p.probe(tableID)))
+	}
+	return checks
+}
+
+// permissionCheckOf interprets the error of a dry run request
+func permissionCheckOf(action string, err error) PermissionCheck {
+	check := PermissionCheck{Action: action, Result: PermissionUnknown}
+	var awsErr awserr.Error
+	switch {
+	case err == nil:
+		check.Result = PermissionAllowed
+	case errors.As(err, &awsErr) && awsErr.Code() == errCodeDryRunOperation:
+		check.Result = PermissionAllowed
+	case errors.As(err, &awsErr) && permissionDeniedCodes[awsErr.Code()]:
+		check.Result = PermissionDenied
+		check.Message = err.Error()
+	default:
+		check.Message = err.Error()
+	}
+	return check
+}
+
+// PermissionReport formats the results of the permission checks with one line per action
+func PermissionReport(checks []PermissionCheck) string {
+	var sb strings.Builder
+	for _, check := range checks {
+		line := fmt.Sprintf("%-7s %s", check.Result, check.Action)
+		if check.Message != "" {
+			line += ": " + strings.ReplaceAll(check.Message, "\n", " ")
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// PermissionsGranted returns true if no check has been denied
+func PermissionsGranted(checks []PermissionCheck) bool {
+	for _, check := range checks {
+		if check.Result == PermissionDenied {
+			return false
+		}
+	}
+	return true
+}
+
+// RequiredIAMPolicy returns the minimal IAM policy document allowing the checked actions
+func RequiredIAMPolicy(checks []PermissionCheck) ([]byte, error) {
+	var actions []string
+	for _, check := range checks {
+		actions = append(actions, check.Action)
+	}
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect":   "Allow",
+				"Action":   actions,
+				"Resource": "*",
+			},
+		},
+	}
+	return json.MarshalIndent(policy, "", "  ")
+}
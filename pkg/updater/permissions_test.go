@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("CheckPermissions", func() {
+	var cloud *fake.EC2
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+	})
+
+	newCustomRoutes := func(options updater.CustomRoutesOptions) *updater.CustomRoutes {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/19", options)
+		Expect(err).To(BeNil())
+		return customRoutes
+	}
+
+	It("should report mixed results", func() {
+		cloud.SetError("DeleteRoute", awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil))
+		cloud.SetError("ModifyInstanceAttribute", errors.New("connection reset"))
+		checks := newCustomRoutes(updater.CustomRoutesOptions{ManageSourceDestCheck: true}).CheckPermissions()
+
+		Expect(checks).To(Equal([]updater.PermissionCheck{
+			{Action: "ec2:DescribeRouteTables", Result: updater.PermissionAllowed},
+			{Action: "ec2:DescribeVpcs", Result: updater.PermissionAllowed},
+			{Action: "ec2:CreateRoute", Result: updater.PermissionAllowed},
+			{Action: "ec2:DeleteRoute", Result: updater.PermissionDenied, Message: "UnauthorizedOperation: You are not authorized to perform this operation."},
+			{Action: "ec2:ModifyInstanceAttribute", Result: updater.PermissionUnknown, Message: "connection reset"},
+		}))
+		Expect(updater.PermissionsGranted(checks)).To(BeFalse())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(BeEmpty())
+
+		Expect(updater.PermissionReport(checks)).To(Equal(`PASS    ec2:DescribeRouteTables
+PASS    ec2:DescribeVpcs
+PASS    ec2:CreateRoute
+FAIL    ec2:DeleteRoute: UnauthorizedOperation: You are not authorized to perform this operation.
+UNKNOWN ec2:ModifyInstanceAttribute: connection reset
+`))
+	})
+
+	It("should only probe the actions needed with the options", func() {
+		checks := newCustomRoutes(updater.CustomRoutesOptions{
+			Mode:                  updater.ModeObserve,
+			ManageSourceDestCheck: true,
+			StoppedInstancePolicy: updater.StoppedInstancePolicyRemove,
+			AZScopedRouting:       true,
+		}).CheckPermissions()
+
+		var actions []string
+		for _, check := range checks {
+			actions = append(actions, check.Action)
+		}
+		Expect(actions).To(Equal([]string{"ec2:DescribeRouteTables", "ec2:DescribeVpcs", "ec2:DescribeInstances", "ec2:DescribeSubnets"}))
+		Expect(updater.PermissionsGranted(checks)).To(BeTrue())
+	})
+
+	It("should create the minimal IAM policy", func() {
+		policy, err := updater.RequiredIAMPolicy(newCustomRoutes(updater.CustomRoutesOptions{}).CheckPermissions())
+		Expect(err).To(BeNil())
+		Expect(json.Valid(policy)).To(BeTrue())
+		Expect(string(policy)).To(MatchJSON(`{
+  "Version": "2012-10-17",
+  "Statement": [{
+    "Effect": "Allow",
+    "Action": ["ec2:DescribeRouteTables", "ec2:DescribeVpcs", "ec2:CreateRoute", "ec2:DeleteRoute"],
+    "Resource": "*"
+  }]
+}`))
+	})
+})
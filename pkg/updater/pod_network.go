@@ -0,0 +1,22 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"net"
+)
+
+// isManagedPodCIDR returns true if the pod CIDR of a node is a valid managed destination.
+// Routes outside of the pod network are never created, as they would not be managed (and cleaned up) afterwards.
+// Invalid CIDRs are left to the target validation.
+func (r *CustomRoutes) isManagedPodCIDR(podCIDR string) bool {
+	_, destination, err := net.ParseCIDR(podCIDR)
+	return err != nil || r.isManagedDestination(destination)
+}
+
+// podNetworkCIDR returns the pod network CIDR
+func (r *CustomRoutes) podNetworkCIDR() string {
+	return r.podNetwork.String()
+}
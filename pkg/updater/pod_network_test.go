@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("pod network", func() {
+	var (
+		cloud      *fake.EC2
+		nodeRoutes = []updater.NodeRoute{
+			{NodeName: "inside", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"},
+			{NodeName: "outside", InstanceID: "i-0002", PodCIDR: "10.243.64.0/24"},
+		}
+	)
+
+	destinations := func() []string {
+		var result []string
+		for _, route := range cloud.RouteTable("rtb-0001").Routes {
+			result = append(result, aws.StringValue(route.DestinationCidrBlock))
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+	})
+
+	It("should program the routes of previously skipped nodes after restarting with a widened pod network", func() {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/19", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+
+		result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Created).To(Equal(1))
+		Expect(destinations()).To(ConsistOf("10.243.1.0/24"))
+
+		result, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Created).To(Equal(0))
+
+		customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+		result, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Created).To(Equal(1))
+		Expect(destinations()).To(ConsistOf("10.243.1.0/24", "10.243.64.0/24"))
+	})
+})
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ParseProxyURL parses the URL of the proxy for the AWS requests. It returns nil for an empty value.
+func ParseProxyURL(value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.Host == "" {
+		// the value is not included, as it may contain the proxy credentials
+		return nil, fmt.Errorf("invalid proxy URL, expected <scheme>://[<user>:<password>@]<host>[:<port>]")
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL scheme %q, must be one of [http,https,socks5]", proxyURL.Scheme)
+	}
+	return proxyURL, nil
+}
+
+// newHTTPClient returns the HTTP client for the AWS requests. Without proxy URL, the proxy is selected by the
+// environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY, otherwise all requests are sent to the proxy URL.
+func newHTTPClient(proxyURL *url.URL) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// orphanQuarantine delays the deletion of orphan routes, i.e. routes whose destination is not a pod CIDR of a node anymore
+type orphanQuarantine struct {
+	log    logr.Logger
+	period time.Duration
+	since  map[string]time.Time
+}
+
+func newOrphanQuarantine(log logr.Logger, period time.Duration) *orphanQuarantine {
+	return &orphanQuarantine{
+		log:    log,
+		period: period,
+		since:  map[string]time.Time{},
+	}
+}
+
+// filter returns the routes to be deleted now. Orphan routes are only returned if they have been orphaned
+// for the whole quarantine period. Routes replaced by a route with another target are not quarantined.
+// The keys of the orphan routes are added to seen.
+func (q *orphanQuarantine) filter(tableID string, toBeDeleted, desired []internalNodeRoute, now time.Time, seen map[string]bool) (expired []internalNodeRoute, pending int) {
+	if q.period <= 0 {
+		return toBeDeleted, 0
+	}
+	desiredDestinations := map[string]bool{}
+	for _, route := range desired {
+		desiredDestinations[route.destinationCidrBlock] = true
+	}
+	for _, route := range toBeDeleted {
+		if desiredDestinations[route.destinationCidrBlock] {
+			expired = append(expired, route)
+			continue
+		}
+		key := tableID + "/" + route.destinationCidrBlock
+		seen[key] = true
+		since, ok := q.since[key]
+		if !ok {
+			since = now
+			q.since[key] = since
+			q.log.Info("orphan route quarantined", "table", tableID, "destination", route.destinationCidrBlock, "target", route.target.String(), "deleteAfter", since.Add(q.period))
+		}
+		if now.Sub(since) < q.period {
+			pending++
+			continue
+		}
+		expired = append(expired, route)
+	}
+	return
+}
+
+// release forgets all quarantined routes which are not orphans anymore
+func (q *orphanQuarantine) release(seen map[string]bool) {
+	for key := range q.since {
+		if !seen[key] {
+			q.log.Info("route released from quarantine", "route", key)
+			delete(q.since, key)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"time"
+)
+
+// withinReadyGrace returns the pod CIDRs of the requested node routes which have been dropped from the remaining ones,
+// but whose nodes are not ready for less than the ready grace period. Their routes must not be deleted yet.
+// Routes of deleted nodes are not requested at all and are therefore never kept, neither are the routes of nodes
+// losing an instance conflict, which are resolved before.
+func (r *CustomRoutes) withinReadyGrace(requested, remaining []NodeRoute, now time.Time) []string {
+	grace := r.options.ReadyGraceBeforeDelete
+	if grace <= 0 {
+		return nil
+	}
+	kept := map[string]bool{}
+	for _, route := range remaining {
+		kept[route.PodCIDR] = true
+	}
+	var graced []string
+	for _, route := range requested {
+		if kept[route.PodCIDR] || !isWithinReadyGrace(route, grace, now) {
+			continue
+		}
+		r.log.Info("route of not ready node kept within grace period", "node", route.NodeName, "instance", route.InstanceID,
+			"podCIDR", route.PodCIDR, "ready", route.Ready, "notReadySince", route.NotReadySince)
+		kept[route.PodCIDR] = true
+		graced = append(graced, route.PodCIDR)
+	}
+	return graced
+}
+
+// isWithinReadyGrace returns true if the node has not been ready for less than the grace period.
+// A ready node is not within the grace period, as it would never leave it. A node without ready condition
+// is regarded as not ready beyond the grace period.
+func isWithinReadyGrace(route NodeRoute, grace time.Duration, now time.Time) bool {
+	if route.Ready {
+		return false
+	}
+	return !route.NotReadySince.IsZero() && now.Sub(route.NotReadySince) < grace
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("ready grace before delete", func() {
+	const destination = "10.243.1.0/24"
+
+	// newStoppedInstanceRoutes sets up an existing route to a stopped instance, which is dropped with StoppedInstancePolicyRemove
+	newStoppedInstanceRoutes := func(grace time.Duration) (*fake.EC2, *updater.CustomRoutes) {
+		cloud := fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String(destination),
+				InstanceId:           aws.String("i-0001"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+				State:                aws.String(ec2.RouteStateActive),
+			}},
+		})
+		cloud.AddInstance(&ec2.Instance{
+			InstanceId: aws.String("i-0001"),
+			State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped)},
+		})
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			StoppedInstancePolicy:  updater.StoppedInstancePolicyRemove,
+			ReadyGraceBeforeDelete: grace,
+		})
+		Expect(err).To(BeNil())
+		return cloud, customRoutes
+	}
+
+	nodeRoute := func(ready bool, notReadyFor time.Duration) updater.NodeRoute {
+		route := updater.NodeRoute{NodeName: "node1", InstanceID: "i-0001", PodCIDR: destination, Ready: ready}
+		if notReadyFor > 0 {
+			route.NotReadySince = time.Now().Add(-notReadyFor)
+		}
+		return route
+	}
+
+	DescribeTable("should only delete the route if the node is not ready beyond the grace period",
+		func(grace time.Duration, route updater.NodeRoute, kept bool) {
+			cloud, customRoutes := newStoppedInstanceRoutes(grace)
+			result, err := customRoutes.Update([]updater.NodeRoute{route}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeTrue())
+			if kept {
+				Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+			} else {
+				Expect(cloud.RouteTable("rtb-0001").Routes).To(BeEmpty())
+			}
+		},
+		Entry("ready node", 5*time.Minute, nodeRoute(true, 0), false),
+		Entry("not ready within grace period", 5*time.Minute, nodeRoute(false, time.Minute), true),
+		Entry("not ready beyond grace period", 5*time.Minute, nodeRoute(false, 10*time.Minute), false),
+		Entry("not ready without transition time", 5*time.Minute, nodeRoute(false, 0), false),
+		Entry("without grace period", time.Duration(0), nodeRoute(true, 0), false),
+	)
+
+	It("should keep the route of a node flapping not ready within the grace period", func() {
+		cloud, customRoutes := newStoppedInstanceRoutes(5 * time.Minute)
+		for _, route := range []updater.NodeRoute{nodeRoute(false, time.Minute), nodeRoute(false, 10*time.Second), nodeRoute(false, 2*time.Minute)} {
+			_, err := customRoutes.Update([]updater.NodeRoute{route}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+		}
+	})
+
+	It("should delete the route once the node is not ready beyond the grace period", func() {
+		cloud, customRoutes := newStoppedInstanceRoutes(5 * time.Minute)
+		since := time.Now().Add(-4 * time.Minute)
+		route := updater.NodeRoute{NodeName: "node1", InstanceID: "i-0001", PodCIDR: destination, NotReadySince: since}
+		_, err := customRoutes.Update([]updater.NodeRoute{route}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+
+		route.NotReadySince = since.Add(-2 * time.Minute)
+		_, err = customRoutes.Update([]updater.NodeRoute{route}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(BeEmpty())
+	})
+
+	It("should delete the route of a node losing an instance conflict immediately", func() {
+		cloud := fake.NewEC2()
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String("rtb-0001"),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}},
+			Routes: []*ec2.Route{{
+				DestinationCidrBlock: aws.String("10.243.2.0/24"),
+				InstanceId:           aws.String("i-0001"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+				State:                aws.String(ec2.RouteStateActive),
+			}},
+		})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{
+			ReadyGraceBeforeDelete: 5 * time.Minute,
+		})
+		Expect(err).To(BeNil())
+		loser := updater.NodeRoute{NodeName: "node2", InstanceID: "i-0001", PodCIDR: "10.243.2.0/24", NotReadySince: time.Now().Add(-time.Minute)}
+		result, err := customRoutes.Update([]updater.NodeRoute{nodeRoute(true, 0), loser}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Recheck).To(BeFalse())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(HaveLen(1))
+		Expect(cloud.RouteTable("rtb-0001").Routes[0].DestinationCidrBlock).To(Equal(aws.String(destination)))
+	})
+
+	It("should delete the route of a deleted node immediately", func() {
+		cloud, customRoutes := newStoppedInstanceRoutes(5 * time.Minute)
+		_, err := customRoutes.Update(nil, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(cloud.RouteTable("rtb-0001").Routes).To(BeEmpty())
+	})
+})
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"go.uber.org/multierr"
+)
+
+// RetryAfterError is a throttling error of AWS with the delay requested by the Retry-After header of the response
+type RetryAfterError struct {
+	awserr.RequestFailure
+	// RetryAfter is the requested delay before the next attempt
+	RetryAfter time.Duration
+}
+
+// Unwrap returns the original AWS error
+func (e *RetryAfterError) Unwrap() error {
+	return e.RequestFailure
+}
+
+// RetryAfter returns the longest delay requested by the throttling errors contained in the (combined) error, or 0 if none
+func RetryAfter(err error) time.Duration {
+	var delay time.Duration
+	for _, e := range multierr.Errors(err) {
+		var retryAfterErr *RetryAfterError
+		if errors.As(e, &retryAfterErr) && retryAfterErr.RetryAfter > delay {
+			delay = retryAfterErr.RetryAfter
+		}
+	}
+	return delay
+}
+
+// ParseRetryAfter returns the delay of a Retry-After header value given as seconds or HTTP date, or 0 if invalid or in the past
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// retryAfterHandler attaches the Retry-After header of throttled responses to their error
+var retryAfterHandler = request.NamedHandler{
+	Name: "awscustomroutecontroller.RetryAfterHandler",
+	Fn: func(r *request.Request) {
+		var failure awserr.RequestFailure
+		if r.HTTPResponse == nil || !errors.As(r.Error, &failure) || !r.IsErrorThrottle() {
+			return
+		}
+		if delay := ParseRetryAfter(r.HTTPResponse.Header.Get("Retry-After"), time.Now()); delay > 0 {
+			r.Error = &RetryAfterError{RequestFailure: failure, RetryAfter: delay}
+		}
+	},
+}
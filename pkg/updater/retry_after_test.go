@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/multierr"
+)
+
+var _ = Describe("retry after", func() {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	DescribeTable("should parse the Retry-After header",
+		func(value string, delay time.Duration) {
+			Expect(updater.ParseRetryAfter(value, now)).To(Equal(delay))
+		},
+		Entry("seconds", "7", 7*time.Second),
+		Entry("HTTP date", now.Add(90*time.Second).Format(http.TimeFormat), 90*time.Second),
+		Entry("HTTP date in the past", now.Add(-time.Minute).Format(http.TimeFormat), time.Duration(0)),
+		Entry("negative seconds", "-3", time.Duration(0)),
+		Entry("invalid", "soon", time.Duration(0)),
+		Entry("empty", "", time.Duration(0)),
+	)
+
+	It("should return the longest requested delay of the combined errors", func() {
+		throttled := func(delay time.Duration) error {
+			return &updater.RetryAfterError{
+				RequestFailure: awserr.NewRequestFailure(awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil), http.StatusServiceUnavailable, "req-1"),
+				RetryAfter:     delay,
+			}
+		}
+		err := multierr.Combine(
+			fmt.Errorf("creating route failed: %w", throttled(2*time.Second)),
+			throttled(5*time.Second),
+			errors.New("other"),
+		)
+		Expect(updater.RetryAfter(err)).To(Equal(5 * time.Second))
+		Expect(updater.RetryAfter(errors.New("other"))).To(BeZero())
+		Expect(updater.RetryAfter(nil)).To(BeZero())
+
+		var awsErr awserr.Error
+		Expect(errors.As(throttled(time.Second), &awsErr)).To(BeTrue())
+		Expect(awsErr.Code()).To(Equal("RequestLimitExceeded"))
+	})
+})
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	// RouteScopeTable programs the route of each node into every cluster route table
+	RouteScopeTable = "table"
+	// RouteScopeVPC programs the route of each node only into a single cluster route table per VPC
+	RouteScopeVPC = "vpc"
+)
+
+// routeOwners maps the VPC and destination of a route to the ID of the route table holding the route in the VPC
+type routeOwners map[string]string
+
+func routeOwnerKey(vpcID, destination string) string {
+	return vpcID + "/" + destination
+}
+
+// assignRouteOwners selects the route table of each desired route per VPC. A table already containing the route is preferred,
+// otherwise the first table (by ID) the route is desired for is used.
+func (r *CustomRoutes) assignRouteOwners(tables []*ec2.RouteTable, desired []internalNodeRoute, zones tableZones) routeOwners {
+	sorted := make([]*ec2.RouteTable, 0, len(tables))
+	for _, table := range tables {
+		if !r.isMainTable(table) {
+			sorted = append(sorted, table)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return aws.StringValue(sorted[i].RouteTableId) < aws.StringValue(sorted[j].RouteTableId)
+	})
+
+	owners := routeOwners{}
+	for _, preferExisting := range []bool{true, false} {
+		for _, table := range sorted {
+			for _, nr := range r.desiredForTable(table, desired, zones) {
+				key := routeOwnerKey(aws.StringValue(table.VpcId), nr.destinationCidrBlock)
+				if _, ok := owners[key]; ok || (preferExisting && !tableHasRoute(table, nr)) {
+					continue
+				}
+				owners[key] = aws.StringValue(table.RouteTableId)
+			}
+		}
+	}
+	return owners
+}
+
+// filter returns the desired routes owned by the table
+func (o routeOwners) filter(table *ec2.RouteTable, desired []internalNodeRoute) []internalNodeRoute {
+	var result []internalNodeRoute
+	for _, nr := range desired {
+		if o[routeOwnerKey(aws.StringValue(table.VpcId), nr.destinationCidrBlock)] == aws.StringValue(table.RouteTableId) {
+			result = append(result, nr)
+		}
+	}
+	return result
+}
@@ -9,54 +9,317 @@ package updater
 import (
 	"fmt"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
 	"github.com/go-logr/logr"
 	"go.uber.org/multierr"
 )
 
+const (
+	// StoppedInstancePolicyKeep keeps the routes to stopped instances
+	StoppedInstancePolicyKeep = "keep"
+	// StoppedInstancePolicyRemove removes the routes to stopped instances until they are running again
+	StoppedInstancePolicyRemove = "remove"
+	// InstanceConflictPolicyPreferReady picks the ready node if several nodes resolve to the same instance, then the newest one
+	InstanceConflictPolicyPreferReady = "prefer-ready"
+	// InstanceConflictPolicyNewest picks the newest node if several nodes resolve to the same instance
+	InstanceConflictPolicyNewest = "newest"
+)
+
+// CustomRoutesOptions contains optional settings for CustomRoutes
+type CustomRoutesOptions struct {
+	// StoppedInstancePolicy is the handling of routes to stopped instances (default is StoppedInstancePolicyKeep)
+	StoppedInstancePolicy string
+	// InstanceLifecyclePolicy is the handling of routes to pending, shutting-down and terminated instances (default is InstanceLifecyclePolicyIgnore)
+	InstanceLifecyclePolicy string
+	// ReadyGraceBeforeDelete keeps the routes of existing nodes dropped by the instance state handling as long as
+	// the node is not ready for less than this period (0 disables it)
+	ReadyGraceBeforeDelete time.Duration
+	// MultiInstancePolicy is the handling of instance lookups returning several instances (default is MultiInstancePolicyError)
+	MultiInstancePolicy string
+	// SelfTagRouteTables controls writing and evaluating the self-tag on the cluster route tables (default is SelfTagRouteTablesOff)
+	SelfTagRouteTables string
+	// Mode is ModeManage (default) or ModeObserve
+	Mode string
+	// InstanceConflictPolicy selects the node if several nodes resolve to the same instance (default is InstanceConflictPolicyPreferReady)
+	InstanceConflictPolicy string
+	// TargetResolver determines the route targets of the nodes (default is InstanceTargetResolver)
+	TargetResolver TargetResolver
+	// FixedNextHopInstanceID routes the pod CIDRs of all nodes to this instance instead of their own (optional)
+	FixedNextHopInstanceID string
+	// FixedNextHopENIID routes the pod CIDRs of all nodes to this network interface instead of their instances (optional)
+	FixedNextHopENIID string
+	// InstanceNotFoundRetries is the number of retries for creating a route if the instance is not found yet (nil for the default of 3)
+	InstanceNotFoundRetries *int
+	// InstanceNotFoundRetryDelay is the delay between these retries (default is 2s)
+	InstanceNotFoundRetryDelay time.Duration
+	// OrphanQuarantinePeriod is the time a route must be orphaned before it is deleted (0 deletes immediately)
+	OrphanQuarantinePeriod time.Duration
+	// MaxDeletionsPerUpdate is the maximum number of routes deleted by a single update, all deletions are skipped if exceeded (0 means unlimited).
+	// The skipped deletions are reported in UpdateResult.DeletionsSkipped without failing the update.
+	MaxDeletionsPerUpdate int
+	// ForeignPodNetworkCIDRs are the pod networks of other clusters sharing the VPC, routes overlapping them are never touched
+	ForeignPodNetworkCIDRs []string
+	// RouteTableConcurrency is the maximum number of route tables updated concurrently (default is 1)
+	RouteTableConcurrency int
+	// DescribeConcurrency is the maximum number of describe requests in flight, e.g. for looking up the instances in batches (default is 1)
+	DescribeConcurrency int
+	// VerifyAfterWrite reads back the route table after creating a route to check that the route exists with the expected target
+	VerifyAfterWrite bool
+	// VerifyRetries is the number of retries for reading back a created route (nil for the default of 3)
+	VerifyRetries *int
+	// VerifyRetryDelay is the delay between these retries (default is 1s)
+	VerifyRetryDelay time.Duration
+	// AZScopedRouting only programs the route of a node into the route tables associated with subnets in the zone of the node
+	AZScopedRouting bool
+	// PerAZRouteMetrics determines the zones of the route tables for the per-AZ route metrics also without AZ scoped routing
+	PerAZRouteMetrics bool
+	// NodeNetworkCIDR is the network of the node IPs. With the node IP fallback, /32 routes inside of it are managed
+	// in addition to the routes inside of the pod network.
+	NodeNetworkCIDR string
+	// ManageSourceDestCheck disables the source/destination check of the instances targeted by the routes
+	ManageSourceDestCheck bool
+	// RouteScope is RouteScopeTable (default) for a route per node in every route table or RouteScopeVPC for a single route per node and VPC
+	RouteScope string
+	// ExcludeVPCMainRouteTable ignores the main route table of the VPC, even if it is tagged for the cluster
+	ExcludeVPCMainRouteTable bool
+	// WarnMissingDefaultRoute warns about route tables programmed into which lack an active 0.0.0.0/0 route
+	WarnMissingDefaultRoute bool
+	// AdoptExistingRoutes logs and counts the desired routes already existing with the correct target before the first update
+	// as adopted, e.g. if they have been created manually before the controller is deployed
+	AdoptExistingRoutes bool
+	// RespectAggregateRoutes skips the routes of nodes whose pod CIDR is covered by a summarized route of the table
+	// inside of the pod network. Such routes broader than the pod CIDRs of the nodes are never deleted.
+	RespectAggregateRoutes bool
+}
+
 // CustomRoutes updates route tables for an AWS cluster
 type CustomRoutes struct {
 	log         logr.Logger
 	ec2         EC2Routes
 	clusterName string
 	podNetwork  net.IPNet
+	options     CustomRoutesOptions
+
+	foreignNetworks []*net.IPNet
+	nodeNetwork     *net.IPNet
+
+	lastAssociations subnetAssociations
+	staleRoutes      *staleRouteTracker
+	quarantine       *orphanQuarantine
+	// inSyncChecksums are the checksums of the route tables found in sync by the last update
+	inSyncChecksums map[string]string
+	// sourceDestCheckDisabled contains the instances whose source/destination check has been disabled
+	sourceDestCheckDisabled map[string]bool
+	// programmed are the routes programmed by the last complete update, checked by the drift detection
+	programmed programmedRoutes
+	// describeLimiter bounds the describe requests in flight
+	describeLimiter describeLimiter
+	// missingDefaultRoute contains the route tables found without default route by the last update
+	missingDefaultRoute map[string]bool
+	// existing are the routes found by the first update for adopting them
+	existing existingRoutes
+	// aggregated maps the routes skipped by the last update to their covering aggregate routes, for logging changes only
+	aggregated map[string]string
+	// aggregatePrefix is the prefix length of the node pod CIDRs, aggregate routes have a shorter one
+	aggregatePrefix int
+	// routeTables are the route tables found by the last update, reused with UpdateOptions.ReuseRouteTables
+	routeTables []*ec2.RouteTable
 }
 
 // NewCustomRoutes creates a new CustomRoutes instance
-func NewCustomRoutes(log logr.Logger, ec2Routes EC2Routes, clusterName, podNetworkCIDR string) (*CustomRoutes, error) {
+func NewCustomRoutes(log logr.Logger, ec2Routes EC2Routes, clusterName, podNetworkCIDR string, options CustomRoutesOptions) (*CustomRoutes, error) {
 	_, ipnet, err := net.ParseCIDR(podNetworkCIDR)
 	if err != nil {
 		return nil, err
 	}
+	switch options.StoppedInstancePolicy {
+	case "":
+		options.StoppedInstancePolicy = StoppedInstancePolicyKeep
+	case StoppedInstancePolicyKeep, StoppedInstancePolicyRemove:
+	default:
+		return nil, fmt.Errorf("invalid stopped instance policy %q", options.StoppedInstancePolicy)
+	}
+	switch options.InstanceLifecyclePolicy {
+	case "":
+		options.InstanceLifecyclePolicy = InstanceLifecyclePolicyIgnore
+	case InstanceLifecyclePolicyIgnore, InstanceLifecyclePolicyStateAware:
+	default:
+		return nil, fmt.Errorf("invalid instance lifecycle policy %q", options.InstanceLifecyclePolicy)
+	}
+	switch options.MultiInstancePolicy {
+	case "":
+		options.MultiInstancePolicy = MultiInstancePolicyError
+	case MultiInstancePolicyError, MultiInstancePolicyPreferRunning:
+	default:
+		return nil, fmt.Errorf("invalid multi instance policy %q", options.MultiInstancePolicy)
+	}
+	switch options.Mode {
+	case "":
+		options.Mode = ModeManage
+	case ModeManage, ModeObserve:
+	default:
+		return nil, fmt.Errorf("invalid mode %q", options.Mode)
+	}
+	switch options.RouteScope {
+	case "":
+		options.RouteScope = RouteScopeTable
+	case RouteScopeTable, RouteScopeVPC:
+	default:
+		return nil, fmt.Errorf("invalid route scope %q", options.RouteScope)
+	}
+	switch options.SelfTagRouteTables {
+	case "":
+		options.SelfTagRouteTables = SelfTagRouteTablesOff
+	case SelfTagRouteTablesOff, SelfTagRouteTablesWrite, SelfTagRouteTablesRestrict:
+	default:
+		return nil, fmt.Errorf("invalid self-tag route tables mode %q", options.SelfTagRouteTables)
+	}
+	switch options.InstanceConflictPolicy {
+	case "":
+		options.InstanceConflictPolicy = InstanceConflictPolicyPreferReady
+	case InstanceConflictPolicyPreferReady, InstanceConflictPolicyNewest:
+	default:
+		return nil, fmt.Errorf("invalid instance conflict policy %q", options.InstanceConflictPolicy)
+	}
+	fixedNextHop, err := fixedNextHopTargetResolver(options.FixedNextHopInstanceID, options.FixedNextHopENIID)
+	if err != nil {
+		return nil, err
+	}
+	if fixedNextHop != nil {
+		if options.TargetResolver != nil {
+			return nil, fmt.Errorf("a fixed next hop cannot be combined with another route target")
+		}
+		options.TargetResolver = fixedNextHop
+	}
+	if options.TargetResolver == nil {
+		options.TargetResolver = InstanceTargetResolver{}
+	}
+	var foreignNetworks []*net.IPNet
+	for _, cidr := range options.ForeignPodNetworkCIDRs {
+		_, foreign, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid foreign pod network CIDR: %w", err)
+		}
+		foreignNetworks = append(foreignNetworks, foreign)
+	}
+	var nodeNetwork *net.IPNet
+	if options.NodeNetworkCIDR != "" {
+		if _, nodeNetwork, err = net.ParseCIDR(options.NodeNetworkCIDR); err != nil {
+			return nil, fmt.Errorf("invalid node network CIDR: %w", err)
+		}
+	}
+	if options.InstanceNotFoundRetries == nil {
+		options.InstanceNotFoundRetries = aws.Int(3)
+	}
+	if options.InstanceNotFoundRetryDelay == 0 {
+		options.InstanceNotFoundRetryDelay = 2 * time.Second
+	}
+	if options.VerifyRetries == nil {
+		options.VerifyRetries = aws.Int(3)
+	}
+	if options.VerifyRetryDelay == 0 {
+		options.VerifyRetryDelay = 1 * time.Second
+	}
 	return &CustomRoutes{
 		log:         log,
 		ec2:         ec2Routes,
 		clusterName: clusterName,
 		podNetwork:  *ipnet,
+		options:     options,
+		staleRoutes: newStaleRouteTracker(clusterName),
+		quarantine:  newOrphanQuarantine(log, options.OrphanQuarantinePeriod),
+
+		foreignNetworks: foreignNetworks,
+		nodeNetwork:     nodeNetwork,
+		inSyncChecksums: map[string]string{},
+
+		sourceDestCheckDisabled: map[string]bool{},
+		describeLimiter:         newDescribeLimiter(options.DescribeConcurrency),
 	}, nil
 }
 
+// tableChanges are the changes planned for a route table
+type tableChanges struct {
+	table *ec2.RouteTable
+	// desired are the desired routes of the table
+	desired []internalNodeRoute
+	// covered are the desired routes skipped because of an aggregate route of the table
+	covered     []internalNodeRoute
+	toBeCreated []internalNodeRoute
+	toBeDeleted []internalNodeRoute
+	// checksum is set if the table is in sync after applying the changes successfully
+	checksum string
+	// skipped contains the destinations of the desired routes not created because the deletions have been skipped
+	skipped map[string]bool
+}
+
+// skipDeletions drops the deletions and the creations replacing the deleted routes
+func (c *tableChanges) skipDeletions() {
+	replaced := map[string]bool{}
+	for _, del := range c.toBeDeleted {
+		replaced[del.destinationCidrBlock] = true
+	}
+	var toBeCreated []internalNodeRoute
+	for _, create := range c.toBeCreated {
+		if !replaced[create.destinationCidrBlock] {
+			toBeCreated = append(toBeCreated, create)
+		}
+	}
+	c.skipped = replaced
+	c.toBeCreated = toBeCreated
+	c.toBeDeleted = nil
+}
+
 type internalNodeRoute struct {
 	destinationCidrBlock string
-	instanceId           string
+	target               *RouteTarget
+	zone                 string
+	// nodeName is the name of the node of a desired route (empty for existing routes)
+	nodeName string
+}
+
+// String returns the destination and target of the route and its node (if known) for error messages
+func (nr internalNodeRoute) String() string {
+	return fmt.Sprintf("%s -> %s%s", nr.destinationCidrBlock, nr.target, ofNode(nr.nodeName))
+}
+
+// ofNode returns the node name suffix for error messages (empty if unknown)
+func ofNode(nodeName string) string {
+	if nodeName == "" {
+		return ""
+	}
+	return " of node " + nodeName
 }
 
 func (r *CustomRoutes) findRouteTables() ([]*ec2.RouteTable, error) {
 	var tables []*ec2.RouteTable
 
-	request := &ec2.DescribeRouteTablesInput{}
-	response, err := r.ec2.DescribeRouteTables(request)
+	var response *ec2.DescribeRouteTablesOutput
+	err := r.describeLimiter.do(func() (err error) {
+		response, err = r.ec2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{})
+		return
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("describing route tables failed: %w", err)
 	}
 
 	for _, table := range response.RouteTables {
-		if hasClusterTag(r.clusterName, table.Tags) {
-			tables = append(tables, table)
+		if !hasClusterTag(r.clusterName, table.Tags) {
+			continue
+		}
+		if r.options.ExcludeVPCMainRouteTable && isVPCMainRouteTable(table) {
+			r.log.V(1).Info("skipping main route table of VPC", "table", aws.StringValue(table.RouteTableId))
+			continue
 		}
+		tables = append(tables, table)
 	}
+	tables = r.filterSelfTagged(tables)
 
 	if len(tables) == 0 {
 		return nil, fmt.Errorf("unable to find route table for AWS cluster: %s", r.clusterName)
@@ -66,56 +329,433 @@ func (r *CustomRoutes) findRouteTables() ([]*ec2.RouteTable, error) {
 }
 
 // Update updates all found route tables (tagged with the clusterName) with the podCIDR to node instance routes
-func (r *CustomRoutes) Update(routes []NodeRoute) error {
-	tables, err := r.findRouteTables()
-	if err != nil {
-		return err
+func (r *CustomRoutes) Update(routes []NodeRoute, options UpdateOptions) (*UpdateResult, error) {
+	var err error
+	tables := r.routeTables
+	if !options.ReuseRouteTables || len(tables) == 0 {
+		if tables, err = r.findRouteTables(); err != nil {
+			return nil, err
+		}
+		r.routeTables = tables
+	}
+	r.checkAssociationChanges(tables)
+	result := &UpdateResult{RouteTables: map[string][]string{}, NodeRouteTables: map[string][]string{}}
+	routes = r.resolveInstanceConflicts(routes)
+	requested := routes
+	keepCIDRs := options.KeepCIDRs
+	// lookupErrors are the failed instance lookups, which only affect the routes of these instances
+	var lookupErrors error
+	if r.needsInstanceStates() {
+		var (
+			deferred []string
+			recheck  bool
+		)
+		routes, deferred, recheck, lookupErrors = r.filterByInstanceState(routes)
+		keepCIDRs = append(append([]string{}, keepCIDRs...), deferred...)
+		result.Recheck = result.Recheck || recheck
+	}
+	if graced := r.withinReadyGrace(requested, routes, time.Now()); len(graced) > 0 {
+		keepCIDRs = append(append([]string{}, keepCIDRs...), graced...)
+		result.Recheck = true
+	}
+	nextHopInstances, failures := r.describeNextHopInstances(routes)
+	if len(failures) > 0 {
+		var kept []string
+		routes, kept, err = withoutFailedLookups(routes, failures)
+		lookupErrors = multierr.Append(lookupErrors, err)
+		keepCIDRs = append(append([]string{}, keepCIDRs...), kept...)
+		result.Recheck = true
+	}
+	desired, updateErrors := r.resolveTargets(routes, nextHopInstances)
+	updateErrors = multierr.Append(lookupErrors, updateErrors)
+	observe := r.options.Mode == ModeObserve
+	if !observe {
+		updateErrors = multierr.Append(updateErrors, r.selfTagRouteTables(tables))
+	}
+	if r.options.ManageSourceDestCheck && !observe {
+		updateErrors = multierr.Append(updateErrors, r.disableSourceDestChecks(desired, options.Force, options.Abort))
+	}
+	var zones, metricZones tableZones
+	if r.options.AZScopedRouting {
+		if zones, err = r.getTableZones(tables); err != nil {
+			return nil, err
+		}
+		metricZones = zones
+	} else if r.options.PerAZRouteMetrics {
+		if metricZones, err = r.getTableZones(tables); err != nil {
+			r.log.Error(err, "determining zones of route tables for metrics failed")
+		}
+	}
+	var owners routeOwners
+	if r.options.RouteScope == RouteScopeVPC {
+		owners = r.assignRouteOwners(tables, desired, zones)
+	}
+	keep := map[string]bool{}
+	for _, cidr := range keepCIDRs {
+		keep[cidr] = true
+	}
+	for _, route := range requested {
+		if route.NodeName != "" && keep[route.PodCIDR] {
+			if result.KeptNodes == nil {
+				result.KeptNodes = map[string]bool{}
+			}
+			result.KeptNodes[route.NodeName] = true
+		}
+	}
+	var stale []string
+	now := time.Now()
+	orphans := map[string]bool{}
+	plans := make([]tableChanges, 0, len(tables))
+	inSyncChecksums := map[string]string{}
+	programmed := programmedRoutes{}
+	deletions, shadowed, noops, adopted := 0, 0, 0, 0
+	drift := map[string]int{}
+	aggregated := map[string]string{}
+	if r.options.RespectAggregateRoutes {
+		r.aggregatePrefix = r.nodeCIDRPrefix(desired)
+	}
+	if !observe {
+		r.captureExistingRoutes(tables)
 	}
-	var updateErrors error
 	for _, table := range tables {
-		toBeCreated, toBeDeleted := r.calcRouteChanges(table, routes)
-		for _, del := range toBeDeleted {
-			req := &ec2.DeleteRouteInput{
-				RouteTableId:         table.RouteTableId,
-				DestinationCidrBlock: aws.String(del.destinationCidrBlock),
+		tableDesired := r.desiredForTable(table, desired, zones)
+		if owners != nil {
+			tableDesired = owners.filter(table, tableDesired)
+		}
+		tableDesired, covered, aggregates := r.withoutAggregated(table, tableDesired, aggregated)
+		shadowed += r.countShadowedRoutes(table, tableDesired)
+		var checksum string
+		if !options.CreateOnly {
+			checksum = r.tableChecksum(table, tableDesired, keepCIDRs)
+			if !options.Force && r.inSyncChecksums[*table.RouteTableId] == checksum {
+				r.log.V(1).Info("route table unchanged, skipped", "table", *table.RouteTableId)
+				metrics.RouteTablesSkipped.Inc()
+				drift[*table.RouteTableId] = 0
+				noops += r.noopRoutes(table, tableDesired, nil)
+				plans = append(plans, tableChanges{table: table, desired: tableDesired, covered: covered, checksum: checksum})
+				continue
 			}
-			_, err = r.ec2.DeleteRoute(req)
-			if err != nil {
-				updateErrors = multierr.Append(updateErrors, fmt.Errorf("deleting route %s in table %s failed: %w", del.destinationCidrBlock, *table.RouteTableId, err))
+		}
+		toBeCreated, toBeDeleted := r.calcRouteChanges(table, tableDesired)
+		toBeDeleted = r.withoutKept(*table.RouteTableId, toBeDeleted, keep)
+		toBeDeleted = r.withoutKept(*table.RouteTableId, toBeDeleted, aggregates)
+		drift[*table.RouteTableId] = len(toBeCreated) + len(toBeDeleted)
+		noops += r.noopRoutes(table, tableDesired, toBeCreated)
+		if !observe {
+			adopted += r.adoptExistingRoutes(table, tableDesired, toBeCreated)
+		}
+		if options.CreateOnly {
+			toBeDeleted = nil
+		} else {
+			var pending int
+			toBeDeleted, pending = r.quarantine.filter(*table.RouteTableId, toBeDeleted, desired, now, orphans)
+			result.Recheck = result.Recheck || pending > 0
+			if pending > 0 || len(toBeCreated) > 0 || len(toBeDeleted) > 0 {
+				// the table must be diffed again after the changes
+				checksum = ""
+			}
+		}
+		deletions += len(toBeDeleted)
+		plans = append(plans, tableChanges{table: table, desired: tableDesired, covered: covered, toBeCreated: toBeCreated, toBeDeleted: toBeDeleted, checksum: checksum})
+	}
+	if maxDeletions := r.options.MaxDeletionsPerUpdate; !observe && maxDeletions > 0 && deletions > maxDeletions {
+		r.log.Info("WARNING: number of route deletions exceeds the maximum, skipping all deletions - please investigate",
+			"deletions", deletions, "maxDeletions", maxDeletions)
+		metrics.RouteDeletionsAborted.Inc()
+		result.DeletionsSkipped = deletions
+		for i := range plans {
+			plans[i].skipDeletions()
+		}
+	}
+	if r.options.WarnMissingDefaultRoute {
+		r.checkDefaultRoutes(plans)
+	}
+	outcomes := make([]tableOutcome, len(plans))
+	if observe {
+		for i, plan := range plans {
+			outcomes[i] = r.observeChanges(plan, result)
+		}
+		metrics.ObservedDriftRoutes.WithLabelValues(r.clusterName, "missing").Set(float64(result.MissingRoutes))
+		metrics.ObservedDriftRoutes.WithLabelValues(r.clusterName, "obsolete").Set(float64(result.ObsoleteRoutes))
+	} else {
+		metrics.RoutesNoop.Add(float64(noops))
+		metrics.RoutesAdopted.Add(float64(adopted))
+		result.Adopted = adopted
+		forEachConcurrently(len(plans), r.options.RouteTableConcurrency, func(i int) {
+			outcomes[i] = r.applyChanges(plans[i], options.Abort)
+		})
+	}
+	for i, plan := range plans {
+		table, outcome := plan.table, outcomes[i]
+		updateErrors = multierr.Append(updateErrors, outcome.err)
+		result.Created += outcome.created
+		result.Deleted += len(outcome.deleted)
+		result.Failed += len(outcome.failed)
+		if plan.checksum != "" {
+			inSyncChecksums[*table.RouteTableId] = plan.checksum
+		}
+		for _, nr := range plan.desired {
+			if outcome.failed[nr.destinationCidrBlock] || plan.skipped[nr.destinationCidrBlock] {
 				continue
 			}
-			r.log.Info("route deleted", "table", *table.RouteTableId, "destination", del.destinationCidrBlock, "instanceId", del.instanceId)
+			programmed[*table.RouteTableId] = append(programmed[*table.RouteTableId], nr)
+			if nr.nodeName != "" {
+				result.NodeRouteTables[nr.nodeName] = append(result.NodeRouteTables[nr.nodeName], *table.RouteTableId)
+			}
+			if !r.isMainTable(table) {
+				result.RouteTables[nr.destinationCidrBlock] = append(result.RouteTables[nr.destinationCidrBlock], *table.RouteTableId)
+			}
+		}
+		for _, nr := range plan.covered {
+			// the pod CIDR is routed by the aggregate route
+			if nr.nodeName != "" {
+				result.NodeRouteTables[nr.nodeName] = append(result.NodeRouteTables[nr.nodeName], *table.RouteTableId)
+			}
+		}
+		for _, route := range r.managedRoutes(table) {
+			if isStaleRoute(route) && !outcome.deleted[*route.DestinationCidrBlock] {
+				stale = append(stale, staleRouteKey(*table.RouteTableId, *route.DestinationCidrBlock))
+			}
+		}
+	}
+	r.aggregated = aggregated
+	if !options.CreateOnly {
+		r.quarantine.release(orphans)
+		r.inSyncChecksums = inSyncChecksums
+		r.programmed = programmed
+		metrics.ShadowedRoutes.WithLabelValues(r.clusterName).Set(float64(shadowed))
+		recordRouteDrift(r.clusterName, drift)
+	}
+	managed := 0
+	for _, tableIDs := range result.RouteTables {
+		managed += len(tableIDs)
+	}
+	metrics.ManagedRoutes.WithLabelValues(r.clusterName).Set(float64(managed))
+	if metricZones != nil {
+		recordManagedRoutesPerZone(r.clusterName, programmed, metricZones)
+	}
+	r.staleRoutes.update(stale, now)
+	return result, updateErrors
+}
+
+// recordRouteDrift exports the number of routes differing between desired and actual state of the current route tables
+func recordRouteDrift(clusterName string, drift map[string]int) {
+	metrics.ResetCluster(metrics.RouteDrift, clusterName)
+	for tableID, count := range drift {
+		metrics.RouteDrift.WithLabelValues(clusterName, tableID).Set(float64(count))
+	}
+}
+
+// tableOutcome is the outcome of applying the changes to a route table
+type tableOutcome struct {
+	// deleted contains the destinations of the deleted routes
+	deleted map[string]bool
+	// failed contains the destinations of the routes which could not be created
+	failed map[string]bool
+	// created is the number of created routes
+	created int
+	err     error
+}
+
+// applyChanges deletes and creates the routes of a route table.
+// If the update is aborted, the remaining changes are skipped and the routes not yet created are reported as failed.
+func (r *CustomRoutes) applyChanges(plan tableChanges, abort <-chan struct{}) tableOutcome {
+	table := plan.table
+	outcome := tableOutcome{deleted: map[string]bool{}, failed: map[string]bool{}}
+	skipRemaining := func(notCreated []internalNodeRoute) tableOutcome {
+		r.log.Info("update aborted, skipping remaining changes", "table", *table.RouteTableId)
+		outcome.err = multierr.Append(outcome.err, fmt.Errorf("%w, remaining changes of table %s skipped", ErrUpdateAborted, *table.RouteTableId))
+		for _, create := range notCreated {
+			outcome.failed[create.destinationCidrBlock] = true
+		}
+		return outcome
+	}
+	for _, del := range plan.toBeDeleted {
+		if aborted(abort) {
+			return skipRemaining(plan.toBeCreated)
+		}
+		req := &ec2.DeleteRouteInput{
+			RouteTableId:         table.RouteTableId,
+			DestinationCidrBlock: aws.String(del.destinationCidrBlock),
+		}
+		if _, err := r.ec2.DeleteRoute(req); err != nil {
+			outcome.err = multierr.Append(outcome.err, fmt.Errorf("deleting route %s in table %s failed: %w", del, *table.RouteTableId, err))
+			continue
+		}
+		r.log.Info("route deleted", "table", *table.RouteTableId, "destination", del.destinationCidrBlock, "target", del.target.String())
+		outcome.deleted[del.destinationCidrBlock] = true
+		metrics.RoutesDeleted.Inc()
+	}
+	for i, create := range plan.toBeCreated {
+		if aborted(abort) {
+			return skipRemaining(plan.toBeCreated[i:])
+		}
+		req := &ec2.CreateRouteInput{
+			RouteTableId:         table.RouteTableId,
+			DestinationCidrBlock: aws.String(create.destinationCidrBlock),
+		}
+		create.target.applyTo(req)
+		err := r.refuseDefaultRoute(req, create.nodeName)
+		if err == nil {
+			err = r.refuseLocalRouteShadowing(table, req, create.nodeName)
+		}
+		if err == nil {
+			err = r.createRoute(req, abort)
 		}
-		for _, create := range toBeCreated {
-			req := &ec2.CreateRouteInput{
-				RouteTableId:         table.RouteTableId,
-				DestinationCidrBlock: aws.String(create.destinationCidrBlock),
-				InstanceId:           aws.String(create.instanceId),
+		if isRouteAlreadyExists(err) {
+			// the route may have been created concurrently, e.g. by a former leader
+			exists, existsErr := r.routeExists(*table.RouteTableId, create)
+			if existsErr != nil {
+				err = fmt.Errorf("%w (checking existing route failed: %s)", err, existsErr)
+			} else if exists {
+				r.log.Info("route already exists", "table", *table.RouteTableId, "destination", create.destinationCidrBlock, "target", create.target.String())
+				continue
+			} else {
+				err = fmt.Errorf("route with other target already exists: %w", err)
 			}
-			_, err = r.ec2.CreateRoute(req)
-			if err != nil {
-				updateErrors = multierr.Append(updateErrors, fmt.Errorf("creating route %s -> %s in table %s failed: %w", create.destinationCidrBlock, create.instanceId, *table.RouteTableId, err))
+		}
+		if err != nil {
+			outcome.err = multierr.Append(outcome.err, fmt.Errorf("creating route %s in table %s failed: %w", create, *table.RouteTableId, err))
+			outcome.failed[create.destinationCidrBlock] = true
+			continue
+		}
+		if r.options.VerifyAfterWrite {
+			if err := r.verifyRoute(*table.RouteTableId, create, abort); err != nil {
+				outcome.err = multierr.Append(outcome.err, err)
+				outcome.failed[create.destinationCidrBlock] = true
 				continue
 			}
-			r.log.Info("route created", "table", *table.RouteTableId, "destination", create.destinationCidrBlock, "instanceId", create.instanceId)
 		}
-		if len(toBeDeleted) == 0 && len(toBeCreated) == 0 {
-			r.log.Info("no routes updated", "table", *table.RouteTableId)
+		r.log.Info("route created", "table", *table.RouteTableId, "destination", create.destinationCidrBlock, "target", create.target.String())
+		metrics.RoutesCreated.Inc()
+		outcome.created++
+	}
+	if len(plan.toBeDeleted) == 0 && len(plan.toBeCreated) == 0 {
+		r.log.Info("no routes updated", "table", *table.RouteTableId)
+	}
+	return outcome
+}
+
+// forEachConcurrently calls f for the indices 0 to count-1 with at most limit calls running concurrently
+func forEachConcurrently(count, limit int, f func(i int)) {
+	if limit <= 1 {
+		for i := 0; i < count; i++ {
+			f(i)
 		}
+		return
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
 	}
-	return updateErrors
+	wg.Wait()
+}
+
+// createRoute creates the route. As a new instance may not be visible immediately after launch,
+// the request is repeated a few times with short delay if the instance is not found, unless the update is aborted.
+func (r *CustomRoutes) createRoute(req *ec2.CreateRouteInput, abort <-chan struct{}) error {
+	for attempt := 1; ; attempt++ {
+		_, err := r.ec2.CreateRoute(req)
+		if err == nil || !isInstanceNotFound(err) || attempt > aws.IntValue(r.options.InstanceNotFoundRetries) {
+			return err
+		}
+		r.log.Info("instance not found yet, retrying", "destination", aws.StringValue(req.DestinationCidrBlock),
+			"instanceId", aws.StringValue(req.InstanceId), "attempt", attempt)
+		if !sleep(abort, r.options.InstanceNotFoundRetryDelay) {
+			return fmt.Errorf("%w, retry skipped: %w", ErrUpdateAborted, err)
+		}
+	}
+}
+
+// resolveTargets determines the desired routes. Node routes without resolvable target are skipped.
+func (r *CustomRoutes) resolveTargets(routes []NodeRoute, nextHopInstances map[string]*ec2.Instance) ([]internalNodeRoute, error) {
+	var (
+		desired       []internalNodeRoute
+		resolveErrors error
+	)
+	for _, route := range routes {
+		if err := r.refuseDefaultRoute(&ec2.CreateRouteInput{DestinationCidrBlock: aws.String(route.PodCIDR)}, route.NodeName); err != nil {
+			resolveErrors = multierr.Append(resolveErrors, fmt.Errorf("%w, route skipped", err))
+			continue
+		}
+		if !r.isManagedPodCIDR(route.PodCIDR) {
+			r.log.Info("pod CIDR outside of pod network, route skipped", "destination", route.PodCIDR, "node", route.NodeName, "podNetwork", r.podNetworkCIDR())
+			continue
+		}
+		if foreign := r.foreignNetwork(route.PodCIDR); foreign != nil {
+			resolveErrors = multierr.Append(resolveErrors, fmt.Errorf("pod CIDR %s%s overlaps with foreign pod network %s, route skipped", route.PodCIDR, ofNode(route.NodeName), foreign))
+			continue
+		}
+		target, err := r.resolveTarget(route, nextHopInstances[route.InstanceID])
+		if err != nil {
+			resolveErrors = multierr.Append(resolveErrors, fmt.Errorf("resolving route target for pod CIDR %s%s failed: %w", route.PodCIDR, ofNode(route.NodeName), err))
+			continue
+		}
+		desired = append(desired, internalNodeRoute{
+			destinationCidrBlock: route.PodCIDR,
+			target:               target,
+			zone:                 route.Zone,
+			nodeName:             route.NodeName,
+		})
+	}
+	return desired, resolveErrors
+}
+
+// resolveTarget returns the target of the node route. The VPC peering connection of the node takes precedence over
+// the network interface of its next hop IP, which takes precedence over the target resolver.
+func (r *CustomRoutes) resolveTarget(route NodeRoute, instance *ec2.Instance) (*RouteTarget, error) {
+	var (
+		target *RouteTarget
+		err    error
+	)
+	if route.VpcPeeringConnectionID != "" {
+		target = &RouteTarget{VpcPeeringConnectionID: route.VpcPeeringConnectionID}
+	} else if route.NextHopIP != "" {
+		networkInterfaceID, err := nextHopNetworkInterface(instance, route.InstanceID, route.NextHopIP)
+		if err != nil {
+			return nil, err
+		}
+		target = &RouteTarget{NetworkInterfaceID: networkInterfaceID}
+	} else if target, err = r.options.TargetResolver.Resolve(route); err != nil {
+		return nil, err
+	}
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	return target, nil
 }
 
 func (r *CustomRoutes) isMainTable(table *ec2.RouteTable) bool {
 	return getNameTagValue(table.Tags) == r.clusterName
 }
 
-func (r *CustomRoutes) calcRouteChanges(table *ec2.RouteTable, nodeRoutes []NodeRoute) (toBeCreated, toBeDeleted []internalNodeRoute) {
-	if r.isMainTable(table) {
-		nodeRoutes = nil
+// foreignNetwork returns the foreign pod network overlapping with the CIDR
+func (r *CustomRoutes) foreignNetwork(cidr string) *net.IPNet {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil
 	}
-	found := make([]bool, len(nodeRoutes))
-outer:
+	for _, foreign := range r.foreignNetworks {
+		if foreign.Contains(ipnet.IP) || ipnet.Contains(foreign.IP) {
+			return foreign
+		}
+	}
+	return nil
+}
+
+// managedRoutes returns the routes of the table created by CreateRoute with a destination completely inside
+// of the pod network and not overlapping with a foreign pod network
+func (r *CustomRoutes) managedRoutes(table *ec2.RouteTable) []*ec2.Route {
+	var routes []*ec2.Route
 	for _, route := range table.Routes {
 		if route.Origin != nil && *route.Origin != ec2.RouteOriginCreateRoute {
 			continue
@@ -123,29 +763,74 @@ outer:
 		if route.DestinationCidrBlock == nil {
 			continue
 		}
-		if _, ipnet, err := net.ParseCIDR(*route.DestinationCidrBlock); err != nil || !r.podNetwork.Contains(ipnet.IP) {
+		if _, ipnet, err := net.ParseCIDR(*route.DestinationCidrBlock); err != nil || !r.isManagedDestination(ipnet) {
+			continue
+		}
+		if r.foreignNetwork(*route.DestinationCidrBlock) != nil {
 			continue
 		}
-		for i, nr := range nodeRoutes {
-			if nr.PodCIDR == *route.DestinationCidrBlock && route.InstanceId != nil && nr.InstanceID == *route.InstanceId {
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// isManagedDestination returns true if the destination is inside of the pod network or a node IP in the node network
+func (r *CustomRoutes) isManagedDestination(destination *net.IPNet) bool {
+	if util.ContainsCIDR(&r.podNetwork, destination) {
+		return true
+	}
+	if r.nodeNetwork == nil {
+		return false
+	}
+	ones, bits := destination.Mask.Size()
+	return ones == 32 && bits == 32 && util.ContainsCIDR(r.nodeNetwork, destination)
+}
+
+// withoutKept removes the routes with kept destinations from the routes to be deleted
+func (r *CustomRoutes) withoutKept(tableID string, toBeDeleted []internalNodeRoute, keep map[string]bool) []internalNodeRoute {
+	var result []internalNodeRoute
+	for _, del := range toBeDeleted {
+		if keep[del.destinationCidrBlock] {
+			r.log.V(1).Info("route kept", "table", tableID, "destination", del.destinationCidrBlock)
+			continue
+		}
+		result = append(result, del)
+	}
+	return result
+}
+
+// noopRoutes returns the number of desired routes of the table which already exist with the correct target
+func (r *CustomRoutes) noopRoutes(table *ec2.RouteTable, desired, toBeCreated []internalNodeRoute) int {
+	if r.isMainTable(table) {
+		return 0
+	}
+	return len(desired) - len(toBeCreated)
+}
+
+func (r *CustomRoutes) calcRouteChanges(table *ec2.RouteTable, desired []internalNodeRoute) (toBeCreated, toBeDeleted []internalNodeRoute) {
+	if r.isMainTable(table) {
+		desired = nil
+	}
+	found := make([]bool, len(desired))
+outer:
+	for _, route := range r.managedRoutes(table) {
+		for i, nr := range desired {
+			if nr.destinationCidrBlock == *route.DestinationCidrBlock && nr.target.matches(route) {
 				found[i] = true
 				continue outer
 			}
 		}
 		toBeDeleted = append(toBeDeleted, internalNodeRoute{
 			destinationCidrBlock: *route.DestinationCidrBlock,
+			target:               targetOf(route),
 		})
 	}
 
-	for i, nr := range nodeRoutes {
+	for i, nr := range desired {
 		if found[i] {
 			continue
 		}
-		route := internalNodeRoute{
-			destinationCidrBlock: nr.PodCIDR,
-			instanceId:           nr.InstanceID,
-		}
-		toBeCreated = append(toBeCreated, route)
+		toBeCreated = append(toBeCreated, nr)
 	}
 
 	return
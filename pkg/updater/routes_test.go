@@ -5,12 +5,20 @@
 package updater_test
 
 import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
 	"github.com/gardener/aws-custom-route-controller/pkg/updater"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -110,7 +118,7 @@ var _ = Describe("CustomRoutes", func() {
 		ec2RoutesMock = updater.NewMockEC2Routes(ctrl)
 
 		var err error
-		customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19")
+		customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{})
 		Expect(err).To(BeNil())
 	})
 
@@ -120,7 +128,7 @@ var _ = Describe("CustomRoutes", func() {
 
 	It("should report error if no route tables found", func() {
 		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{}, nil)
-		err := customRoutes.Update(nil)
+		_, err := customRoutes.Update(nil, updater.UpdateOptions{})
 		Expect(err).NotTo(BeNil())
 	})
 
@@ -145,14 +153,1297 @@ var _ = Describe("CustomRoutes", func() {
 			InstanceId:           aws.String(nodeRoutes[1].InstanceID),
 			RouteTableId:         rt2,
 		})
-		err := customRoutes.Update(nodeRoutes)
+		result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(result.Created).To(Equal(3))
+		Expect(result.Deleted).To(Equal(1))
+		Expect(result.Failed).To(Equal(0))
+	})
+
+	It("should export the route drift per route table", func() {
+		metrics.RouteDrift.Reset()
+		metrics.RouteDrift.WithLabelValues("other", "rt1").Set(5)
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables}, nil)
+		ec2RoutesMock.EXPECT().DeleteRoute(gomock.Any()).Times(1)
+		ec2RoutesMock.EXPECT().CreateRoute(gomock.Any()).Times(3)
+		_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.RouteDrift.WithLabelValues(clusterName, "rt1"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(metrics.RouteDrift.WithLabelValues(clusterName, "rt2"))).To(Equal(2.0))
+		Expect(testutil.CollectAndCount(metrics.RouteDrift)).To(Equal(3))
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+		_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.RouteDrift.WithLabelValues(clusterName, "rt1"))).To(Equal(0.0))
+		Expect(testutil.CollectAndCount(metrics.RouteDrift)).To(Equal(2))
+		Expect(testutil.ToFloat64(metrics.RouteDrift.WithLabelValues("other", "rt1"))).To(Equal(5.0))
+	})
+
+	It("should not delete routes if create only", func() {
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+		_, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{CreateOnly: true})
+		Expect(err).To(BeNil())
+	})
+
+	It("should not delete kept routes", func() {
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil).Times(2)
+		result, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{KeepCIDRs: []string{nodeRoutes[1].PodCIDR}})
+		Expect(err).To(BeNil())
+		Expect(result.Deleted).To(Equal(0))
+
+		ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+			DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+			RouteTableId:         rt1,
+		})
+		result, err = customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
 		Expect(err).To(BeNil())
+		Expect(result.Deleted).To(Equal(1))
 	})
 
 	It("should update nothing if unchanged", func() {
 		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
-		err := customRoutes.Update(nodeRoutes)
+		_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+	})
+
+	It("should detect route table association changes between updates", func() {
+		withAssociation := func(tableID *string, subnetID string) *ec2.RouteTable {
+			return &ec2.RouteTable{
+				RouteTableId: tableID,
+				Tags:         []*ec2.Tag{clusterTag},
+				Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String(subnetID), RouteTableId: tableID}},
+			}
+		}
+		before := testutil.ToFloat64(metrics.RouteTableAssociationChanges)
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+			withAssociation(rt1, "subnet-a"), withAssociation(rt2, "subnet-b"),
+		}}, nil).Times(2)
+		Expect(customRoutes.Update(nil, updater.UpdateOptions{})).Error().To(Succeed())
+		Expect(customRoutes.Update(nil, updater.UpdateOptions{})).Error().To(Succeed())
+		Expect(testutil.ToFloat64(metrics.RouteTableAssociationChanges)).To(Equal(before))
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+			withAssociation(rt1, "subnet-a"), withAssociation(rt2, "subnet-x"),
+			{RouteTableId: rt3, Tags: []*ec2.Tag{clusterTag}, Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-b")}}},
+		}}, nil)
+		Expect(customRoutes.Update(nil, updater.UpdateOptions{})).Error().To(Succeed())
+		Expect(testutil.ToFloat64(metrics.RouteTableAssociationChanges)).To(Equal(before + 1))
+	})
+
+	Context("stopped instances", func() {
+		instancesOutput := func(states map[string]string) *ec2.DescribeInstancesOutput {
+			reservation := &ec2.Reservation{}
+			for id, state := range states {
+				reservation.Instances = append(reservation.Instances, &ec2.Instance{
+					InstanceId: aws.String(id),
+					State:      &ec2.InstanceState{Name: aws.String(state)},
+				})
+			}
+			return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{reservation}}
+		}
+		describeInstancesInput := &ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{{Name: aws.String("instance-id"), Values: aws.StringSlice([]string{"i-node1", "i-node3"})}},
+		}
+
+		It("should keep routes to stopped instances by default", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeFalse())
+		})
+
+		It("should remove routes to stopped instances and re-add them when running again", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				StoppedInstancePolicy: updater.StoppedInstancePolicyRemove,
+			})
+			Expect(err).To(BeNil())
+
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			ec2RoutesMock.EXPECT().DescribeInstances(describeInstancesInput).Return(instancesOutput(map[string]string{
+				"i-node1": ec2.InstanceStateNameRunning,
+				"i-node3": ec2.InstanceStateNameStopped,
+			}), nil)
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeTrue())
+
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1, route2, routeNode1},
+				},
+			}}, nil)
+			ec2RoutesMock.EXPECT().DescribeInstances(describeInstancesInput).Return(instancesOutput(map[string]string{
+				"i-node1": ec2.InstanceStateNameRunning,
+				"i-node3": ec2.InstanceStateNameRunning,
+			}), nil)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           routeNode3.InstanceId,
+				RouteTableId:         rt1,
+			})
+			result, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeFalse())
+		})
+
+		It("should reject an invalid stopped instance policy", func() {
+			_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				StoppedInstancePolicy: "invalid",
+			})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	It("should report stale routes until removed", func() {
+		staleRoute := &ec2.Route{
+			DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+			InstanceId:           routeNode3.InstanceId,
+			Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			State:                aws.String(ec2.RouteStateBlackhole),
+		}
+		staleTables := []*ec2.RouteTable{
+			{
+				RouteTableId: rt1,
+				Tags:         []*ec2.Tag{clusterTag},
+				Routes:       []*ec2.Route{route1, routeNode1, staleRoute},
+			},
+		}
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: staleTables}, nil).Times(2)
+		_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.StaleRoutes.WithLabelValues(clusterName))).To(Equal(1.0))
+		time.Sleep(10 * time.Millisecond)
+		_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.StaleRoutes.WithLabelValues(clusterName))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(metrics.StaleRoutesMaxAge.WithLabelValues(clusterName))).To(BeNumerically(">=", 0.01))
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: staleTables}, nil)
+		ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+			DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+			RouteTableId:         rt1,
+		})
+		_, err = customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.StaleRoutes.WithLabelValues(clusterName))).To(Equal(0.0))
+		Expect(testutil.ToFloat64(metrics.StaleRoutesMaxAge.WithLabelValues(clusterName))).To(Equal(0.0))
+	})
+
+	Context("instance not found", func() {
+		notFound := awserr.New("InvalidInstanceID.NotFound", "The instance ID 'i-node3' does not exist", nil)
+		createNode3 := &ec2.CreateRouteInput{
+			DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+			InstanceId:           routeNode3.InstanceId,
+			RouteTableId:         rt1,
+		}
+
+		BeforeEach(func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				InstanceNotFoundRetries:    aws.Int(2),
+				InstanceNotFoundRetryDelay: time.Millisecond,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1, routeNode1},
+				},
+			}}, nil)
+		})
+
+		It("should retry if the instance appears on the second lookup", func() {
+			gomock.InOrder(
+				ec2RoutesMock.EXPECT().CreateRoute(createNode3).Return(nil, notFound),
+				ec2RoutesMock.EXPECT().CreateRoute(createNode3).Return(&ec2.CreateRouteOutput{}, nil),
+			)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+
+		It("should give up after the retries", func() {
+			ec2RoutesMock.EXPECT().CreateRoute(createNode3).Return(nil, notFound).Times(3)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should not retry if the retries are disabled", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				InstanceNotFoundRetries: aws.Int(0),
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().CreateRoute(createNode3).Return(nil, notFound)
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("InvalidInstanceID.NotFound")))
+		})
+
+		It("should stop waiting for the retry if the update is aborted", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				InstanceNotFoundRetryDelay: time.Hour,
+			})
+			Expect(err).To(BeNil())
+			abort := make(chan struct{})
+			ec2RoutesMock.EXPECT().CreateRoute(createNode3).DoAndReturn(func(*ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+				close(abort)
+				return nil, notFound
+			})
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{Abort: abort})
+			Expect(err).To(MatchError(updater.ErrUpdateAborted))
+		})
+
+		It("should not retry other errors", func() {
+			ec2RoutesMock.EXPECT().CreateRoute(createNode3).Return(nil, awserr.New("UnauthorizedOperation", "not allowed", nil))
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Context("orphan quarantine", func() {
+		newQuarantineRoutes := func(period time.Duration) {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				OrphanQuarantinePeriod: period,
+			})
+			Expect(err).To(BeNil())
+		}
+		describeTables2 := func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+		}
+
+		It("should release a route if the node reappears before the deadline", func() {
+			newQuarantineRoutes(time.Hour)
+
+			describeTables2()
+			result, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeTrue())
+
+			describeTables2()
+			result, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeFalse())
+		})
+
+		It("should delete an orphan route after the deadline", func() {
+			newQuarantineRoutes(20 * time.Millisecond)
+
+			describeTables2()
+			result, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeTrue())
+
+			time.Sleep(30 * time.Millisecond)
+			describeTables2()
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			result, err = customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Recheck).To(BeFalse())
+		})
+
+		It("should not quarantine a route replaced by another target", func() {
+			newQuarantineRoutes(time.Hour)
+
+			describeTables2()
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           aws.String("i-node4"),
+				RouteTableId:         rt1,
+			})
+			_, err := customRoutes.Update([]updater.NodeRoute{nodeRoutes[0], {InstanceID: "i-node4", PodCIDR: nodeRoutes[1].PodCIDR}}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("deletion cap", func() {
+		BeforeEach(func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				MaxDeletionsPerUpdate: 1,
+			})
+			Expect(err).To(BeNil())
+		})
+
+		It("should skip all deletions but create routes if the cap is exceeded", func() {
+			aborted := testutil.ToFloat64(metrics.RouteDeletionsAborted)
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: aws.String("10.243.20.0/24"),
+				InstanceId:           aws.String("i-node4"),
+				RouteTableId:         rt1,
+			})
+			result, err := customRoutes.Update([]updater.NodeRoute{{NodeName: "node4", InstanceID: "i-node4", PodCIDR: "10.243.20.0/24"}}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.DeletionsSkipped).To(Equal(2))
+			Expect(result.Created).To(Equal(1))
+			Expect(result.NodeRouteTables).To(HaveKey("node4"))
+			Expect(testutil.ToFloat64(metrics.RouteDeletionsAborted)).To(Equal(aborted + 1))
+		})
+
+		It("should delete routes within the cap", func() {
+			aborted := testutil.ToFloat64(metrics.RouteDeletionsAborted)
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			result, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.DeletionsSkipped).To(BeZero())
+			Expect(testutil.ToFloat64(metrics.RouteDeletionsAborted)).To(Equal(aborted))
+		})
+	})
+
+	Context("route table checksums", func() {
+		describeTables := func(tables ...*ec2.RouteTable) {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables}, nil)
+		}
+
+		It("should skip unchanged tables found in sync", func() {
+			skipped := testutil.ToFloat64(metrics.RouteTablesSkipped)
+			describeTables(tables2...)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(testutil.ToFloat64(metrics.RouteTablesSkipped)).To(Equal(skipped))
+
+			describeTables(tables2...)
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(testutil.ToFloat64(metrics.RouteTablesSkipped)).To(Equal(skipped + 1))
+		})
+
+		It("should process changed tables", func() {
+			skipped := testutil.ToFloat64(metrics.RouteTablesSkipped)
+			describeTables(tables2...)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			changed := &ec2.RouteTable{
+				RouteTableId: rt1,
+				Tags:         []*ec2.Tag{clusterTag},
+				Routes:       []*ec2.Route{route1, route2, routeNode1},
+			}
+			describeTables(changed)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           routeNode3.InstanceId,
+				RouteTableId:         rt1,
+			})
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			describeTables(tables2...)
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			_, err = customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(testutil.ToFloat64(metrics.RouteTablesSkipped)).To(Equal(skipped))
+		})
+
+		It("should not skip tables if forced", func() {
+			skipped := testutil.ToFloat64(metrics.RouteTablesSkipped)
+			describeTables(tables2...)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			describeTables(tables2...)
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{Force: true})
+			Expect(err).To(BeNil())
+			Expect(testutil.ToFloat64(metrics.RouteTablesSkipped)).To(Equal(skipped))
+		})
+	})
+
+	Context("shared VPC", func() {
+		var (
+			otherClusterTag = &ec2.Tag{
+				Key:   aws.String(updater.ClusterTagKey("shoot--foo--other")),
+				Value: aws.String("1"),
+			}
+			otherRoute = &ec2.Route{
+				DestinationCidrBlock: aws.String("10.243.17.0/24"),
+				InstanceId:           aws.String("i-other1"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			}
+			otherWideRoute = &ec2.Route{
+				DestinationCidrBlock: aws.String("10.243.0.0/18"),
+				InstanceId:           aws.String("i-other2"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			}
+			sharedTable = &ec2.RouteTable{
+				RouteTableId: rt1,
+				Tags:         []*ec2.Tag{clusterTag, otherClusterTag},
+				Routes:       []*ec2.Route{route1, routeNode1, routeNode3, otherRoute, otherWideRoute},
+			}
+		)
+
+		BeforeEach(func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				ForeignPodNetworkCIDRs: []string{"10.243.16.0/20"},
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{sharedTable}}, nil)
+		})
+
+		It("should only delete routes of its own cluster", func() {
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			_, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+
+		It("should not create routes overlapping with a foreign pod network", func() {
+			_, err := customRoutes.Update(append(nodeRoutes, updater.NodeRoute{InstanceID: "i-node4", PodCIDR: "10.243.18.0/24"}), updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("overlaps with foreign pod network")))
+		})
+	})
+
+	It("should reject an invalid foreign pod network CIDR", func() {
+		_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+			ForeignPodNetworkCIDRs: []string{"10.243.16.0"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should report routes shadowed by a more specific route", func() {
+		specificRoute := &ec2.Route{
+			DestinationCidrBlock: aws.String("10.243.1.0/25"),
+			GatewayId:            aws.String("vgw-123"),
+			Origin:               aws.String(ec2.RouteOriginEnableVgwRoutePropagation),
+		}
+		table := &ec2.RouteTable{
+			RouteTableId: rt1,
+			Tags:         []*ec2.Tag{clusterTag},
+			Routes:       []*ec2.Route{route1, routeNode1, specificRoute},
+		}
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{table}}, nil)
+		ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+			DestinationCidrBlock: aws.String("10.243.1.0/24"),
+			InstanceId:           aws.String("i-node4"),
+			RouteTableId:         rt1,
+		})
+		_, err := customRoutes.Update([]updater.NodeRoute{nodeRoutes[0], {InstanceID: "i-node4", PodCIDR: "10.243.1.0/24"}}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.ShadowedRoutes.WithLabelValues(clusterName))).To(Equal(1.0))
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+		_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+		Expect(testutil.ToFloat64(metrics.ShadowedRoutes.WithLabelValues(clusterName))).To(Equal(0.0))
+	})
+
+	It("should update route tables concurrently with bounded concurrency", func() {
+		var err error
+		customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+			RouteTableConcurrency: 2,
+		})
+		Expect(err).To(BeNil())
+
+		var concurrentTables []*ec2.RouteTable
+		for i := 0; i < 6; i++ {
+			concurrentTables = append(concurrentTables, &ec2.RouteTable{
+				RouteTableId: aws.String(fmt.Sprintf("rt-concurrent%d", i)),
+				Tags:         []*ec2.Tag{clusterTag},
+				Routes:       []*ec2.Route{route1},
+			})
+		}
+		var (
+			inFlight    atomic.Int32
+			maxInFlight atomic.Int32
+		)
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: concurrentTables}, nil)
+		ec2RoutesMock.EXPECT().CreateRoute(gomock.Any()).Times(len(concurrentTables)).DoAndReturn(func(input *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			if aws.StringValue(input.RouteTableId) == "rt-concurrent3" {
+				return nil, fmt.Errorf("throttled")
+			}
+			return &ec2.CreateRouteOutput{}, nil
+		})
+
+		result, err := customRoutes.Update(nodeRoutes[:1], updater.UpdateOptions{})
+		Expect(err).To(MatchError(ContainSubstring("rt-concurrent3")))
+		Expect(maxInFlight.Load()).To(BeEquivalentTo(2))
+		Expect(result.RouteTables[nodeRoutes[0].PodCIDR]).To(HaveLen(len(concurrentTables) - 1))
+		Expect(result.RouteTables[nodeRoutes[0].PodCIDR]).NotTo(ContainElement("rt-concurrent3"))
+	})
+
+	It("should bound the describe requests in flight independently of the route table concurrency", func() {
+		var err error
+		customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+			StoppedInstancePolicy: updater.StoppedInstancePolicyRemove,
+			DescribeConcurrency:   2,
+		})
+		Expect(err).To(BeNil())
+
+		var manyRoutes []updater.NodeRoute
+		for i := 0; i < 450; i++ {
+			manyRoutes = append(manyRoutes, updater.NodeRoute{
+				InstanceID: fmt.Sprintf("i-node%04d", i),
+				PodCIDR:    fmt.Sprintf("10.243.%d.%d/29", i/32, i%32*8),
+			})
+		}
+		var (
+			inFlight    atomic.Int32
+			maxInFlight atomic.Int32
+		)
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+		ec2RoutesMock.EXPECT().DescribeInstances(gomock.Any()).Times(5).DoAndReturn(func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			reservation := &ec2.Reservation{}
+			for _, id := range input.Filters[0].Values {
+				reservation.Instances = append(reservation.Instances, &ec2.Instance{
+					InstanceId: id,
+					State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped)},
+				})
+			}
+			return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{reservation}}, nil
+		})
+		ec2RoutesMock.EXPECT().DeleteRoute(gomock.Any()).AnyTimes()
+
+		_, err = customRoutes.Update(manyRoutes, updater.UpdateOptions{})
 		Expect(err).To(BeNil())
+		Expect(maxInFlight.Load()).To(BeEquivalentTo(2))
 	})
 
+	Context("verify after write", func() {
+		var (
+			changed = &ec2.RouteTable{
+				RouteTableId: rt1,
+				Tags:         []*ec2.Tag{clusterTag},
+				Routes:       []*ec2.Route{route1, route2, routeNode1},
+			}
+			verifyInput = &ec2.DescribeRouteTablesInput{RouteTableIds: []*string{rt1}}
+		)
+
+		BeforeEach(func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				VerifyAfterWrite: true,
+				VerifyRetries:    aws.Int(2),
+				VerifyRetryDelay: time.Millisecond,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{changed}}, nil)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           routeNode3.InstanceId,
+				RouteTableId:         rt1,
+			})
+		})
+
+		It("should retry reading back the route until it is visible", func() {
+			gomock.InOrder(
+				ec2RoutesMock.EXPECT().DescribeRouteTables(verifyInput).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{changed}}, nil),
+				ec2RoutesMock.EXPECT().DescribeRouteTables(verifyInput).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil),
+			)
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables[*routeNode3.DestinationCidrBlock]).To(Equal([]string{*rt1}))
+		})
+
+		It("should fail if the route is not visible after the retries", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(verifyInput).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{changed}}, nil).Times(3)
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("not found in table rt1 after creation")))
+			Expect(result.RouteTables[*routeNode3.DestinationCidrBlock]).To(BeEmpty())
+		})
+
+		It("should not retry if the retries are disabled", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				VerifyAfterWrite: true,
+				VerifyRetries:    aws.Int(0),
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(verifyInput).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{changed}}, nil)
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("not found in table rt1 after creation")))
+		})
+
+		It("should stop waiting for the retry if the update is aborted", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				VerifyAfterWrite: true,
+				VerifyRetryDelay: time.Hour,
+			})
+			Expect(err).To(BeNil())
+			abort := make(chan struct{})
+			ec2RoutesMock.EXPECT().DescribeRouteTables(verifyInput).DoAndReturn(func(*ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error) {
+				close(abort)
+				return &ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{changed}}, nil
+			})
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{Abort: abort})
+			Expect(err).To(MatchError(updater.ErrUpdateAborted))
+		})
+	})
+
+	Context("zone scoped routing", func() {
+		var (
+			zonedTables = []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1, routeNode3},
+					Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-a")}},
+				},
+				{
+					RouteTableId: rt2,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1},
+					Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-b")}},
+				},
+				{
+					RouteTableId: rt3,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1},
+				},
+			}
+			zonedNodeRoutes = []updater.NodeRoute{
+				{
+					InstanceID: *routeNode1.InstanceId,
+					PodCIDR:    *routeNode1.DestinationCidrBlock,
+					Zone:       "eu-west-1a",
+				},
+				{
+					InstanceID: *routeNode3.InstanceId,
+					PodCIDR:    *routeNode3.DestinationCidrBlock,
+					Zone:       "eu-west-1b",
+				},
+			}
+		)
+
+		BeforeEach(func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				AZScopedRouting: true,
+			})
+			Expect(err).To(BeNil())
+		})
+
+		It("should only program the routes of the nodes into the tables of their zones", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: zonedTables}, nil)
+			ec2RoutesMock.EXPECT().DescribeSubnets(gomock.Any()).Return(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
+				{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("eu-west-1a")},
+				{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("eu-west-1b")},
+			}}, nil)
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode1.DestinationCidrBlock,
+				InstanceId:           routeNode1.InstanceId,
+				RouteTableId:         rt1,
+			})
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           routeNode3.InstanceId,
+				RouteTableId:         rt2,
+			})
+			for _, nr := range zonedNodeRoutes {
+				ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+					DestinationCidrBlock: aws.String(nr.PodCIDR),
+					InstanceId:           aws.String(nr.InstanceID),
+					RouteTableId:         rt3,
+				})
+			}
+
+			result, err := customRoutes.Update(zonedNodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables).To(Equal(map[string][]string{
+				*routeNode1.DestinationCidrBlock: {*rt1, *rt3},
+				*routeNode3.DestinationCidrBlock: {*rt2, *rt3},
+			}))
+		})
+
+		It("should fail if the subnets cannot be described", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: zonedTables}, nil)
+			ec2RoutesMock.EXPECT().DescribeSubnets(gomock.Any()).Return(nil, fmt.Errorf("denied"))
+
+			_, err := customRoutes.Update(zonedNodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("describing subnets failed")))
+		})
+	})
+
+	Context("instance conflicts", func() {
+		var (
+			now            = time.Now()
+			conflictTables = []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1},
+				},
+			}
+			conflictRoutes = []updater.NodeRoute{
+				{
+					NodeName:          "old",
+					InstanceID:        *routeNode1.InstanceId,
+					PodCIDR:           "10.243.3.0/24",
+					Ready:             true,
+					CreationTimestamp: now.Add(-time.Hour),
+				},
+				{
+					NodeName:          "new",
+					InstanceID:        *routeNode1.InstanceId,
+					PodCIDR:           "10.243.13.0/24",
+					CreationTimestamp: now,
+				},
+			}
+		)
+
+		updateWithPolicy := func(policy, expectedCIDR string) {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				InstanceConflictPolicy: policy,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: conflictTables}, nil)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: aws.String(expectedCIDR),
+				InstanceId:           routeNode1.InstanceId,
+				RouteTableId:         rt1,
+			})
+
+			result, err := customRoutes.Update(conflictRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables).To(Equal(map[string][]string{expectedCIDR: {*rt1}}))
+			Expect(testutil.ToFloat64(metrics.InstanceConflicts.WithLabelValues(clusterName))).To(Equal(1.0))
+		}
+
+		It("should prefer the ready node", func() {
+			updateWithPolicy("", "10.243.3.0/24")
+		})
+
+		It("should prefer the newest node", func() {
+			updateWithPolicy(updater.InstanceConflictPolicyNewest, "10.243.13.0/24")
+		})
+
+		It("should reset the metric if there are no conflicts", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(testutil.ToFloat64(metrics.InstanceConflicts.WithLabelValues(clusterName))).To(Equal(0.0))
+		})
+
+		It("should reject an invalid policy", func() {
+			_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				InstanceConflictPolicy: "oldest",
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid instance conflict policy")))
+		})
+	})
+
+	Context("source/destination check", func() {
+		expectModify := func(instanceID *string) *gomock.Call {
+			return ec2RoutesMock.EXPECT().ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+				InstanceId:      instanceID,
+				SourceDestCheck: &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+			}).Return(&ec2.ModifyInstanceAttributeOutput{}, nil)
+		}
+
+		It("should not modify the instances if disabled", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+
+		It("should disable the source/destination check of the target instances once", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				ManageSourceDestCheck: true,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil).Times(3)
+			expectModify(routeNode1.InstanceId).Times(2)
+			expectModify(routeNode3.InstanceId).Times(2)
+
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{Force: true})
+			Expect(err).To(BeNil())
+		})
+
+		It("should still program the routes if modifying an instance fails", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				ManageSourceDestCheck: true,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			expectModify(routeNode1.InstanceId)
+			ec2RoutesMock.EXPECT().ModifyInstanceAttribute(gomock.Any()).Return(nil, fmt.Errorf("unauthorized"))
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("disabling source/destination check of instance i-node3 failed")))
+			Expect(result.RouteTables).To(HaveKey(*routeNode3.DestinationCidrBlock))
+		})
+	})
+
+	Context("drift detection", func() {
+		It("should not read the route tables before a complete update", func() {
+			missing, err := customRoutes.DetectDrift()
+			Expect(err).To(BeNil())
+			Expect(missing).To(Equal(0))
+		})
+
+		It("should detect programmed routes deleted out-of-band", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil).Times(2)
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			missing, err := customRoutes.DetectDrift()
+			Expect(err).To(BeNil())
+			Expect(missing).To(Equal(0))
+
+			before := testutil.ToFloat64(metrics.DriftedRoutes)
+			drifted := []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1, route2, routeNode1},
+				},
+			}
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: drifted}, nil)
+			missing, err = customRoutes.DetectDrift()
+			Expect(err).To(BeNil())
+			Expect(missing).To(Equal(1))
+			Expect(testutil.ToFloat64(metrics.DriftedRoutes)).To(Equal(before + 1))
+		})
+	})
+
+	Context("node IP fallback", func() {
+		var (
+			routeNodeIP = &ec2.Route{
+				DestinationCidrBlock: aws.String("10.250.0.4/32"),
+				InstanceId:           aws.String("i-node4"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			}
+			routeOtherIP = &ec2.Route{
+				DestinationCidrBlock: aws.String("10.251.0.5/32"),
+				InstanceId:           aws.String("i-vpn"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			}
+			nodeIPTables = []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes:       []*ec2.Route{route1, routeNodeIP, routeOtherIP},
+				},
+			}
+		)
+
+		It("should not manage node IP routes by default", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: nodeIPTables}, nil)
+			_, err := customRoutes.Update(nil, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+
+		It("should manage node IP routes inside of the node network", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				NodeNetworkCIDR: "10.250.0.0/16",
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: nodeIPTables}, nil).Times(2)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: aws.String("10.250.0.7/32"),
+				InstanceId:           aws.String("i-node7"),
+				RouteTableId:         rt1,
+			})
+
+			result, err := customRoutes.Update([]updater.NodeRoute{
+				{InstanceID: "i-node4", PodCIDR: "10.250.0.4/32"},
+				{InstanceID: "i-node7", PodCIDR: "10.250.0.7/32"},
+			}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables).To(HaveLen(2))
+
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNodeIP.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			})
+			_, err = customRoutes.Update(nil, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Context("observe mode", func() {
+		BeforeEach(func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				Mode:                  updater.ModeObserve,
+				ManageSourceDestCheck: true,
+				MaxDeletionsPerUpdate: 1,
+			})
+			Expect(err).To(BeNil())
+		})
+
+		It("should report drift without modifying the route tables", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables}, nil).Times(2)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.MissingRoutes).To(Equal(3))
+			Expect(result.ObsoleteRoutes).To(Equal(1))
+			Expect(result.Created).To(Equal(0))
+			Expect(result.Deleted).To(Equal(0))
+			Expect(result.RouteTables).To(HaveKey(*routeNode1.DestinationCidrBlock))
+			Expect(result.RouteTables).NotTo(HaveKey(*routeNode3.DestinationCidrBlock))
+			Expect(testutil.ToFloat64(metrics.ObservedDriftRoutes.WithLabelValues(clusterName, "missing"))).To(Equal(3.0))
+			Expect(testutil.ToFloat64(metrics.ObservedDriftRoutes.WithLabelValues(clusterName, "obsolete"))).To(Equal(1.0))
+
+			// drift is reported again, as nothing has been repaired
+			result, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.MissingRoutes).To(Equal(3))
+		})
+
+		It("should report no drift if in sync", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.MissingRoutes).To(Equal(0))
+			Expect(result.ObsoleteRoutes).To(Equal(0))
+			Expect(testutil.ToFloat64(metrics.ObservedDriftRoutes.WithLabelValues(clusterName, "missing"))).To(Equal(0.0))
+		})
+
+		It("should reject an invalid mode", func() {
+			_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				Mode: "dry-run",
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid mode")))
+		})
+	})
+
+	Context("error context", func() {
+		errAWS := fmt.Errorf("request limit exceeded")
+
+		It("should wrap errors with the node, pod CIDR and route table", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+			ec2RoutesMock.EXPECT().DeleteRoute(gomock.Any()).Return(nil, errAWS)
+			ec2RoutesMock.EXPECT().CreateRoute(gomock.Any()).Return(nil, errAWS)
+
+			_, err := customRoutes.Update([]updater.NodeRoute{
+				{NodeName: "node1", InstanceID: *routeNode1.InstanceId, PodCIDR: *routeNode1.DestinationCidrBlock},
+				{NodeName: "node2", InstanceID: *routeNode2.InstanceId, PodCIDR: *routeNode2.DestinationCidrBlock},
+			}, updater.UpdateOptions{})
+			Expect(err).To(MatchError(errAWS))
+			Expect(err).To(MatchError(ContainSubstring("deleting route 10.243.13.0/24 -> i-node3 in table rt1 failed: request limit exceeded")))
+			Expect(err).To(MatchError(ContainSubstring("creating route 10.243.9.0/24 -> i-node2 of node node2 in table rt1 failed: request limit exceeded")))
+		})
+
+		It("should wrap errors resolving targets with the node", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables2}, nil)
+
+			_, err := customRoutes.Update([]updater.NodeRoute{
+				{NodeName: "node1", InstanceID: *routeNode1.InstanceId, PodCIDR: *routeNode1.DestinationCidrBlock},
+				{NodeName: "node3", InstanceID: *routeNode3.InstanceId, PodCIDR: *routeNode3.DestinationCidrBlock},
+				{NodeName: "node9", PodCIDR: "10.243.19.0/24"},
+			}, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("resolving route target for pod CIDR 10.243.19.0/24 of node node9 failed: missing instance ID")))
+		})
+
+		It("should wrap errors describing the route tables", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(nil, errAWS)
+
+			_, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(errAWS))
+			Expect(err).To(MatchError(ContainSubstring("describing route tables failed")))
+		})
+	})
+
+	Context("aborted update", func() {
+		It("should stop mutating the route tables as soon as the update is aborted", func() {
+			abort := make(chan struct{})
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: tables}, nil)
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: routeNode2.DestinationCidrBlock,
+				RouteTableId:         rt1,
+			}).DoAndReturn(func(_ *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error) {
+				// leadership lost while the update is running
+				close(abort)
+				return &ec2.DeleteRouteOutput{}, nil
+			})
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{Abort: abort})
+			Expect(err).To(MatchError(updater.ErrUpdateAborted))
+			Expect(err).To(MatchError(ContainSubstring("remaining changes of table rt1 skipped")))
+			Expect(err).To(MatchError(ContainSubstring("remaining changes of table rt2 skipped")))
+			Expect(result.Deleted).To(Equal(1))
+			Expect(result.Created).To(Equal(0))
+			Expect(result.RouteTables).NotTo(HaveKey(*routeNode3.DestinationCidrBlock))
+		})
+	})
+
+	Context("VPC main route table", func() {
+		var (
+			mainTable = &ec2.RouteTable{
+				RouteTableId: rt1,
+				Tags:         []*ec2.Tag{clusterTag},
+				Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true), RouteTableId: rt1}},
+				Routes:       []*ec2.Route{route1, routeNode1},
+			}
+			explicitTable = &ec2.RouteTable{
+				RouteTableId: rt2,
+				Tags:         []*ec2.Tag{clusterTag},
+				Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(false), SubnetId: aws.String("subnet-a"), RouteTableId: rt2}},
+				Routes:       []*ec2.Route{route1, routeNode1},
+			}
+		)
+
+		It("should include the main route table by default", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{mainTable, explicitTable}}, nil)
+			for _, table := range []*string{rt1, rt2} {
+				ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+					DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+					InstanceId:           routeNode3.InstanceId,
+					RouteTableId:         table,
+				})
+			}
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables[*routeNode3.DestinationCidrBlock]).To(ConsistOf(*rt1, *rt2))
+		})
+
+		It("should only use explicitly associated route tables if the main route table is excluded", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				ExcludeVPCMainRouteTable: true,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{mainTable, explicitTable}}, nil)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           routeNode3.InstanceId,
+				RouteTableId:         rt2,
+			})
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables[*routeNode3.DestinationCidrBlock]).To(ConsistOf(*rt2))
+		})
+
+		It("should fail if only the excluded main route table is tagged", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				ExcludeVPCMainRouteTable: true,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{mainTable}}, nil)
+
+			_, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("unable to find route table")))
+		})
+	})
+
+	Context("VPC overlap", func() {
+		vpcTables := []*ec2.RouteTable{
+			{RouteTableId: rt1, VpcId: aws.String("vpc-1"), Tags: []*ec2.Tag{clusterTag}},
+			{RouteTableId: rt2, VpcId: aws.String("vpc-1"), Tags: []*ec2.Tag{clusterTag}},
+		}
+		vpc := func(cidrs ...string) *ec2.DescribeVpcsOutput {
+			v := &ec2.Vpc{VpcId: aws.String("vpc-1"), CidrBlock: aws.String(cidrs[0])}
+			for _, cidr := range cidrs {
+				v.CidrBlockAssociationSet = append(v.CidrBlockAssociationSet, &ec2.VpcCidrBlockAssociation{
+					CidrBlock:      aws.String(cidr),
+					CidrBlockState: &ec2.VpcCidrBlockState{State: aws.String(ec2.VpcCidrBlockStateCodeAssociated)},
+				})
+			}
+			return &ec2.DescribeVpcsOutput{Vpcs: []*ec2.Vpc{v}}
+		}
+
+		BeforeEach(func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: vpcTables}, nil)
+		})
+
+		It("should accept a pod network outside of the VPC", func() {
+			ec2RoutesMock.EXPECT().DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: aws.StringSlice([]string{"vpc-1"})}).Return(vpc("10.250.0.0/16", "100.64.0.0/16"), nil)
+			Expect(customRoutes.CheckVPCOverlap()).To(Succeed())
+		})
+
+		It("should report a pod network overlapping with a VPC CIDR", func() {
+			ec2RoutesMock.EXPECT().DescribeVpcs(gomock.Any()).Return(vpc("10.250.0.0/16", "10.243.16.0/20"), nil)
+
+			err := customRoutes.CheckVPCOverlap()
+			var overlapErr *updater.VPCOverlapError
+			Expect(errors.As(err, &overlapErr)).To(BeTrue())
+			Expect(overlapErr.VPCCIDRs).To(ConsistOf("10.243.16.0/20"))
+			Expect(err).To(MatchError("pod network CIDR 10.243.0.0/19 overlaps with VPC CIDR 10.243.16.0/20"))
+		})
+
+		It("should report a VPC CIDR containing the pod network", func() {
+			ec2RoutesMock.EXPECT().DescribeVpcs(gomock.Any()).Return(vpc("10.0.0.0/8"), nil)
+			Expect(customRoutes.CheckVPCOverlap()).To(MatchError(ContainSubstring("overlaps with VPC CIDR 10.0.0.0/8")))
+		})
+
+		It("should return other errors unchanged", func() {
+			ec2RoutesMock.EXPECT().DescribeVpcs(gomock.Any()).Return(nil, fmt.Errorf("unauthorized"))
+
+			err := customRoutes.CheckVPCOverlap()
+			var overlapErr *updater.VPCOverlapError
+			Expect(errors.As(err, &overlapErr)).To(BeFalse())
+			Expect(err).To(MatchError(ContainSubstring("describing VPCs vpc-1 failed: unauthorized")))
+		})
+	})
+
+	Context("route already exists", func() {
+		alreadyExists := awserr.New("RouteAlreadyExists", "The route identified by 10.243.13.0/24 already exists.", nil)
+		expectCreate := func() *gomock.Call {
+			return ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           routeNode3.InstanceId,
+				RouteTableId:         rt1,
+			}).Return(nil, alreadyExists)
+		}
+		table := func(routes ...*ec2.Route) *ec2.DescribeRouteTablesOutput {
+			return &ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{{RouteTableId: rt1, Tags: []*ec2.Tag{clusterTag}, Routes: routes}}}
+		}
+
+		It("should only create missing routes", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(table(route1, routeNode1, routeNode3), nil)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Created).To(Equal(0))
+		})
+
+		It("should treat a route created concurrently as success", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(table(route1, routeNode1), nil)
+			expectCreate()
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{RouteTableIds: []*string{rt1}}).Return(table(route1, routeNode1, routeNode3), nil)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.Created).To(Equal(0))
+			Expect(result.Failed).To(Equal(0))
+			Expect(result.RouteTables).To(HaveKeyWithValue(*routeNode3.DestinationCidrBlock, []string{*rt1}))
+		})
+
+		It("should fail if the existing route has another target", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(table(route1, routeNode1), nil)
+			expectCreate()
+			otherTarget := &ec2.Route{
+				DestinationCidrBlock: routeNode3.DestinationCidrBlock,
+				InstanceId:           aws.String("i-other"),
+				Origin:               aws.String(ec2.RouteOriginCreateRoute),
+			}
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{RouteTableIds: []*string{rt1}}).Return(table(route1, routeNode1, otherTarget), nil)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("route with other target already exists")))
+			Expect(err).To(MatchError(alreadyExists))
+			Expect(result.Failed).To(Equal(1))
+		})
+	})
+
+	Context("route scope", func() {
+		var (
+			rtA         = aws.String("rtb-a")
+			rtB         = aws.String("rtb-b")
+			rtC         = aws.String("rtb-c")
+			scopeTables = []*ec2.RouteTable{
+				{RouteTableId: rtB, VpcId: aws.String("vpc-1"), Tags: []*ec2.Tag{clusterTag}, Routes: []*ec2.Route{route1, routeNode1}},
+				{RouteTableId: rtA, VpcId: aws.String("vpc-1"), Tags: []*ec2.Tag{clusterTag}, Routes: []*ec2.Route{route1, routeNode1}},
+				{RouteTableId: rtC, VpcId: aws.String("vpc-2"), Tags: []*ec2.Tag{clusterTag}, Routes: []*ec2.Route{route1}},
+			}
+		)
+		expectCreate := func(table *string, route *ec2.Route) {
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: route.DestinationCidrBlock,
+				InstanceId:           route.InstanceId,
+				RouteTableId:         table,
+			})
+		}
+
+		It("should program duplicate routes into all route tables of a VPC by default", func() {
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: scopeTables}, nil)
+			expectCreate(rtA, routeNode3)
+			expectCreate(rtB, routeNode3)
+			expectCreate(rtC, routeNode1)
+			expectCreate(rtC, routeNode3)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables[*routeNode1.DestinationCidrBlock]).To(ConsistOf(*rtA, *rtB, *rtC))
+		})
+
+		It("should program a single route per VPC with the VPC scope", func() {
+			var err error
+			customRoutes, err = updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				RouteScope: updater.RouteScopeVPC,
+			})
+			Expect(err).To(BeNil())
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: scopeTables}, nil)
+			// the existing route in the first table is kept, the duplicate is removed
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{DestinationCidrBlock: routeNode1.DestinationCidrBlock, RouteTableId: rtB})
+			expectCreate(rtA, routeNode3)
+			expectCreate(rtC, routeNode1)
+			expectCreate(rtC, routeNode3)
+
+			result, err := customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables[*routeNode1.DestinationCidrBlock]).To(ConsistOf(*rtA, *rtC))
+			Expect(result.RouteTables[*routeNode3.DestinationCidrBlock]).To(ConsistOf(*rtA, *rtC))
+
+			// a route existing only in a later table of the VPC is kept there
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{RouteTableId: rtA, VpcId: aws.String("vpc-1"), Tags: []*ec2.Tag{clusterTag}, Routes: []*ec2.Route{route1, routeNode1}},
+				{RouteTableId: rtB, VpcId: aws.String("vpc-1"), Tags: []*ec2.Tag{clusterTag}, Routes: []*ec2.Route{route1, routeNode3}},
+			}}, nil)
+			result, err = customRoutes.Update(nodeRoutes, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Expect(result.RouteTables[*routeNode1.DestinationCidrBlock]).To(ConsistOf(*rtA))
+			Expect(result.RouteTables[*routeNode3.DestinationCidrBlock]).To(ConsistOf(*rtB))
+		})
+
+		It("should reject an invalid route scope", func() {
+			_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				RouteScope: "subnet",
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid route scope")))
+		})
+	})
 })
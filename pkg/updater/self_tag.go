@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+const (
+	// SelfTagKey is the key of the tag written by the controller on the route tables it manages
+	SelfTagKey = "aws-custom-route-controller/managed"
+	// selfTagValue is the value of the self-tag
+	selfTagValue = "true"
+
+	// SelfTagRouteTablesOff neither writes nor evaluates the self-tag
+	SelfTagRouteTablesOff = "off"
+	// SelfTagRouteTablesWrite writes the self-tag on all cluster route tables
+	SelfTagRouteTablesWrite = "write"
+	// SelfTagRouteTablesRestrict writes the self-tag on the cluster route tables on the first run, i.e. if no cluster route table has it yet,
+	// and restricts all operations to the cluster route tables with the self-tag afterwards
+	SelfTagRouteTablesRestrict = "restrict"
+)
+
+// hasSelfTag returns true if the route table is tagged as managed by the controller
+func hasSelfTag(table *ec2.RouteTable) bool {
+	for _, tag := range table.Tags {
+		if aws.StringValue(tag.Key) == SelfTagKey && aws.StringValue(tag.Value) == selfTagValue {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSelfTagged restricts the cluster route tables to the ones with the self-tag if any of them has it
+func (r *CustomRoutes) filterSelfTagged(tables []*ec2.RouteTable) []*ec2.RouteTable {
+	if r.options.SelfTagRouteTables != SelfTagRouteTablesRestrict {
+		return tables
+	}
+	var tagged []*ec2.RouteTable
+	for _, table := range tables {
+		if hasSelfTag(table) {
+			tagged = append(tagged, table)
+		}
+	}
+	if len(tagged) == 0 {
+		// first run, all cluster route tables are tagged by the update
+		return tables
+	}
+	if len(tagged) < len(tables) {
+		for _, table := range tables {
+			if !hasSelfTag(table) {
+				r.log.V(1).Info("skipping route table without self-tag", "table", aws.StringValue(table.RouteTableId), "tag", SelfTagKey)
+			}
+		}
+	}
+	return tagged
+}
+
+// selfTagRouteTables writes the self-tag on the route tables not having it yet
+func (r *CustomRoutes) selfTagRouteTables(tables []*ec2.RouteTable) error {
+	if r.options.SelfTagRouteTables == SelfTagRouteTablesOff {
+		return nil
+	}
+	var untagged []*ec2.RouteTable
+	for _, table := range tables {
+		if !hasSelfTag(table) {
+			untagged = append(untagged, table)
+		}
+	}
+	if len(untagged) == 0 {
+		return nil
+	}
+	tableIDs := make([]*string, 0, len(untagged))
+	for _, table := range untagged {
+		tableIDs = append(tableIDs, table.RouteTableId)
+	}
+	tag := &ec2.Tag{Key: aws.String(SelfTagKey), Value: aws.String(selfTagValue)}
+	if _, err := r.ec2.CreateTags(&ec2.CreateTagsInput{Resources: tableIDs, Tags: []*ec2.Tag{tag}}); err != nil {
+		return fmt.Errorf("writing self-tag on route tables %s failed: %w", aws.StringValueSlice(tableIDs), err)
+	}
+	for _, table := range untagged {
+		table.Tags = append(table.Tags, tag)
+		r.log.Info("route table self-tagged", "table", aws.StringValue(table.RouteTableId), "tag", SelfTagKey)
+	}
+	return nil
+}
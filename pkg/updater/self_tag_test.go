@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("self-tag route tables", func() {
+	var cloud *fake.EC2
+
+	addTable := func(tableID, clusterName string) {
+		cloud.AddRouteTable(&ec2.RouteTable{
+			RouteTableId: aws.String(tableID),
+			Tags:         []*ec2.Tag{{Key: aws.String(updater.ClusterTagKey(clusterName)), Value: aws.String("1")}},
+		})
+	}
+	selfTag := &ec2.Tag{Key: aws.String(updater.SelfTagKey), Value: aws.String("true")}
+	newCustomRoutes := func(options updater.CustomRoutesOptions) *updater.CustomRoutes {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", options)
+		Expect(err).To(BeNil())
+		return customRoutes
+	}
+	update := func(customRoutes *updater.CustomRoutes, routes ...updater.NodeRoute) {
+		_, err := customRoutes.Update(routes, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+	}
+	destinations := func(tableID string) []string {
+		var destinations []string
+		for _, route := range cloud.RouteTable(tableID).Routes {
+			destinations = append(destinations, aws.StringValue(route.DestinationCidrBlock))
+		}
+		return destinations
+	}
+	node1 := updater.NodeRoute{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24"}
+	node2 := updater.NodeRoute{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24"}
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		addTable("rtb-0001", "test")
+		addTable("rtb-0002", "test")
+		addTable("rtb-other", "other")
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0001")})
+		cloud.AddInstance(&ec2.Instance{InstanceId: aws.String("i-0002")})
+	})
+
+	It("should reject an invalid mode", func() {
+		_, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", updater.CustomRoutesOptions{SelfTagRouteTables: "always"})
+		Expect(err).To(MatchError(`invalid self-tag route tables mode "always"`))
+	})
+
+	It("should not write the self-tag by default", func() {
+		update(newCustomRoutes(updater.CustomRoutesOptions{}), node1)
+		Expect(cloud.Calls("CreateTags")).To(Equal(0))
+		Expect(cloud.RouteTable("rtb-0001").Tags).NotTo(ContainElement(selfTag))
+	})
+
+	It("should write the self-tag once on the cluster route tables", func() {
+		customRoutes := newCustomRoutes(updater.CustomRoutesOptions{SelfTagRouteTables: updater.SelfTagRouteTablesWrite})
+		update(customRoutes, node1)
+		Expect(cloud.Calls("CreateTags")).To(Equal(1))
+		Expect(cloud.RouteTable("rtb-0001").Tags).To(ContainElement(selfTag))
+		Expect(cloud.RouteTable("rtb-0002").Tags).To(ContainElement(selfTag))
+		Expect(cloud.RouteTable("rtb-other").Tags).NotTo(ContainElement(selfTag))
+
+		update(customRoutes, node1, node2)
+		Expect(cloud.Calls("CreateTags")).To(Equal(1))
+		Expect(destinations("rtb-0002")).To(ConsistOf(node1.PodCIDR, node2.PodCIDR))
+	})
+
+	It("should not write the self-tag in observe mode", func() {
+		update(newCustomRoutes(updater.CustomRoutesOptions{SelfTagRouteTables: updater.SelfTagRouteTablesWrite, Mode: updater.ModeObserve}), node1)
+		Expect(cloud.Calls("CreateTags")).To(Equal(0))
+	})
+
+	It("should restrict the updates to the self-tagged route tables after the first run", func() {
+		customRoutes := newCustomRoutes(updater.CustomRoutesOptions{SelfTagRouteTables: updater.SelfTagRouteTablesRestrict})
+		update(customRoutes, node1)
+		Expect(cloud.RouteTable("rtb-0001").Tags).To(ContainElement(selfTag))
+		Expect(cloud.RouteTable("rtb-0002").Tags).To(ContainElement(selfTag))
+
+		addTable("rtb-0003", "test")
+		update(customRoutes, node1, node2)
+		Expect(destinations("rtb-0001")).To(ConsistOf(node1.PodCIDR, node2.PodCIDR))
+		Expect(destinations("rtb-0002")).To(ConsistOf(node1.PodCIDR, node2.PodCIDR))
+		Expect(destinations("rtb-0003")).To(BeEmpty())
+		Expect(cloud.RouteTable("rtb-0003").Tags).NotTo(ContainElement(selfTag))
+
+		// a restarted controller keeps the restriction
+		update(newCustomRoutes(updater.CustomRoutesOptions{SelfTagRouteTables: updater.SelfTagRouteTablesRestrict}), node1, node2)
+		Expect(destinations("rtb-0003")).To(BeEmpty())
+		Expect(cloud.Calls("CreateTags")).To(Equal(1))
+	})
+})
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"go.uber.org/multierr"
+)
+
+// disableSourceDestChecks disables the source/destination check of the instances targeted by the desired routes,
+// as the instances drop the pod traffic otherwise. Each instance is only modified once, unless forced.
+func (r *CustomRoutes) disableSourceDestChecks(desired []internalNodeRoute, force bool, abort <-chan struct{}) error {
+	var (
+		disabled = map[string]bool{}
+		errs     error
+	)
+	for _, nr := range desired {
+		if aborted(abort) {
+			return multierr.Append(errs, fmt.Errorf("%w, remaining source/destination checks skipped", ErrUpdateAborted))
+		}
+		instanceID := nr.target.InstanceID
+		if instanceID == "" || disabled[instanceID] {
+			continue
+		}
+		if r.sourceDestCheckDisabled[instanceID] && !force {
+			disabled[instanceID] = true
+			continue
+		}
+		if _, err := r.ec2.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+			InstanceId:      aws.String(instanceID),
+			SourceDestCheck: &ec2.AttributeBooleanValue{Value: aws.Bool(false)},
+		}); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("disabling source/destination check of instance %s%s failed: %w", instanceID, ofNode(nr.nodeName), err))
+			continue
+		}
+		r.log.V(1).Info("source/destination check disabled", "instanceId", instanceID)
+		disabled[instanceID] = true
+	}
+	// only remember current instances to forget about deleted ones
+	r.sourceDestCheckDisabled = disabled
+	return errs
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// staleRouteTracker remembers since when routes with missing targets exist
+type staleRouteTracker struct {
+	// clusterName is the value of the cluster label of the metrics
+	clusterName string
+	firstSeen   map[string]time.Time
+}
+
+func newStaleRouteTracker(clusterName string) *staleRouteTracker {
+	return &staleRouteTracker{clusterName: clusterName, firstSeen: map[string]time.Time{}}
+}
+
+func staleRouteKey(tableID, destination string) string {
+	return tableID + "/" + destination
+}
+
+func isStaleRoute(route *ec2.Route) bool {
+	return aws.StringValue(route.State) == ec2.RouteStateBlackhole
+}
+
+// update replaces the tracked stale routes and updates the metrics
+func (t *staleRouteTracker) update(stale []string, now time.Time) {
+	firstSeen := map[string]time.Time{}
+	var maxAge time.Duration
+	for _, key := range stale {
+		seen, ok := t.firstSeen[key]
+		if !ok {
+			seen = now
+		}
+		firstSeen[key] = seen
+		if age := now.Sub(seen); age > maxAge {
+			maxAge = age
+		}
+	}
+	t.firstSeen = firstSeen
+	metrics.StaleRoutes.WithLabelValues(t.clusterName).Set(float64(len(stale)))
+	metrics.StaleRoutesMaxAge.WithLabelValues(t.clusterName).Set(maxAge.Seconds())
+}
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// RouteTarget is the target of a route. Exactly one of the fields should be set.
+type RouteTarget struct {
+	InstanceID             string
+	NetworkInterfaceID     string
+	TransitGatewayID       string
+	NatGatewayID           string
+	GatewayID              string
+	VpcPeeringConnectionID string
+}
+
+// TargetResolver determines the target of the route for a node
+type TargetResolver interface {
+	Resolve(route NodeRoute) (*RouteTarget, error)
+}
+
+// InstanceTargetResolver routes the pod CIDR of a node to its instance
+type InstanceTargetResolver struct{}
+
+var _ TargetResolver = InstanceTargetResolver{}
+
+// Resolve returns the instance of the node as target
+func (InstanceTargetResolver) Resolve(route NodeRoute) (*RouteTarget, error) {
+	if route.InstanceID == "" {
+		return nil, fmt.Errorf("missing instance ID for pod CIDR %s", route.PodCIDR)
+	}
+	return &RouteTarget{InstanceID: route.InstanceID}, nil
+}
+
+// FixedInstanceTargetResolver routes the pod CIDRs of all nodes to a fixed instance, e.g. a central firewall appliance
+type FixedInstanceTargetResolver struct {
+	InstanceID string
+}
+
+var _ TargetResolver = FixedInstanceTargetResolver{}
+
+// Resolve returns the fixed instance as target
+func (r FixedInstanceTargetResolver) Resolve(_ NodeRoute) (*RouteTarget, error) {
+	if r.InstanceID == "" {
+		return nil, fmt.Errorf("missing fixed next hop instance ID")
+	}
+	return &RouteTarget{InstanceID: r.InstanceID}, nil
+}
+
+// NetworkInterfaceTargetResolver routes the pod CIDRs of all nodes to a network interface
+type NetworkInterfaceTargetResolver struct {
+	NetworkInterfaceID string
+}
+
+var _ TargetResolver = NetworkInterfaceTargetResolver{}
+
+// Resolve returns the network interface as target
+func (r NetworkInterfaceTargetResolver) Resolve(_ NodeRoute) (*RouteTarget, error) {
+	if r.NetworkInterfaceID == "" {
+		return nil, fmt.Errorf("missing network interface ID")
+	}
+	return &RouteTarget{NetworkInterfaceID: r.NetworkInterfaceID}, nil
+}
+
+// TransitGatewayTargetResolver routes the pod CIDRs of all nodes to a transit gateway
+type TransitGatewayTargetResolver struct {
+	TransitGatewayID string
+}
+
+var _ TargetResolver = TransitGatewayTargetResolver{}
+
+// Resolve returns the transit gateway as target
+func (r TransitGatewayTargetResolver) Resolve(_ NodeRoute) (*RouteTarget, error) {
+	if r.TransitGatewayID == "" {
+		return nil, fmt.Errorf("missing transit gateway ID")
+	}
+	return &RouteTarget{TransitGatewayID: r.TransitGatewayID}, nil
+}
+
+// NatGatewayTargetResolver routes the pod CIDRs of all nodes to a NAT gateway
+type NatGatewayTargetResolver struct {
+	NatGatewayID string
+}
+
+var _ TargetResolver = NatGatewayTargetResolver{}
+
+// Resolve returns the NAT gateway as target
+func (r NatGatewayTargetResolver) Resolve(_ NodeRoute) (*RouteTarget, error) {
+	if r.NatGatewayID == "" {
+		return nil, fmt.Errorf("missing NAT gateway ID")
+	}
+	return &RouteTarget{NatGatewayID: r.NatGatewayID}, nil
+}
+
+// GatewayTargetResolver routes the pod CIDRs of all nodes to an internet or virtual private gateway
+type GatewayTargetResolver struct {
+	GatewayID string
+}
+
+var _ TargetResolver = GatewayTargetResolver{}
+
+// Resolve returns the gateway as target
+func (r GatewayTargetResolver) Resolve(_ NodeRoute) (*RouteTarget, error) {
+	if r.GatewayID == "" {
+		return nil, fmt.Errorf("missing gateway ID")
+	}
+	return &RouteTarget{GatewayID: r.GatewayID}, nil
+}
+
+// VpcPeeringConnectionTargetResolver routes the pod CIDRs of all nodes to a VPC peering connection
+type VpcPeeringConnectionTargetResolver struct {
+	VpcPeeringConnectionID string
+}
+
+var _ TargetResolver = VpcPeeringConnectionTargetResolver{}
+
+// Resolve returns the VPC peering connection as target
+func (r VpcPeeringConnectionTargetResolver) Resolve(_ NodeRoute) (*RouteTarget, error) {
+	if r.VpcPeeringConnectionID == "" {
+		return nil, fmt.Errorf("missing VPC peering connection ID")
+	}
+	return &RouteTarget{VpcPeeringConnectionID: r.VpcPeeringConnectionID}, nil
+}
+
+// targetOf returns the target of an existing route
+func targetOf(route *ec2.Route) *RouteTarget {
+	return &RouteTarget{
+		InstanceID:             aws.StringValue(route.InstanceId),
+		NetworkInterfaceID:     aws.StringValue(route.NetworkInterfaceId),
+		TransitGatewayID:       aws.StringValue(route.TransitGatewayId),
+		NatGatewayID:           aws.StringValue(route.NatGatewayId),
+		GatewayID:              aws.StringValue(route.GatewayId),
+		VpcPeeringConnectionID: aws.StringValue(route.VpcPeeringConnectionId),
+	}
+}
+
+// matches returns true if the route points to this target.
+// Only the IDs set in the target are compared, as AWS reports e.g. both instance and network interface for instance routes.
+func (t *RouteTarget) matches(route *ec2.Route) bool {
+	pairs := []struct {
+		expected string
+		actual   *string
+	}{
+		{t.InstanceID, route.InstanceId},
+		{t.NetworkInterfaceID, route.NetworkInterfaceId},
+		{t.TransitGatewayID, route.TransitGatewayId},
+		{t.NatGatewayID, route.NatGatewayId},
+		{t.GatewayID, route.GatewayId},
+		{t.VpcPeeringConnectionID, route.VpcPeeringConnectionId},
+	}
+	matched := false
+	for _, pair := range pairs {
+		if pair.expected == "" {
+			continue
+		}
+		if pair.expected != aws.StringValue(pair.actual) {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// applyTo sets the target of the create route request
+func (t *RouteTarget) applyTo(request *ec2.CreateRouteInput) {
+	request.InstanceId = optionalString(t.InstanceID)
+	request.NetworkInterfaceId = optionalString(t.NetworkInterfaceID)
+	request.TransitGatewayId = optionalString(t.TransitGatewayID)
+	request.NatGatewayId = optionalString(t.NatGatewayID)
+	request.GatewayId = optionalString(t.GatewayID)
+	request.VpcPeeringConnectionId = optionalString(t.VpcPeeringConnectionID)
+}
+
+// validate checks that exactly one target ID is set
+func (t *RouteTarget) validate() error {
+	count := 0
+	for _, id := range t.ids() {
+		if id != "" {
+			count++
+		}
+	}
+	switch {
+	case count == 0:
+		return fmt.Errorf("route target has no ID")
+	case count > 1:
+		return fmt.Errorf("route target %s is ambiguous, only one target is allowed", t)
+	case t.VpcPeeringConnectionID != "" && !strings.HasPrefix(t.VpcPeeringConnectionID, "pcx-"):
+		return fmt.Errorf("invalid VPC peering connection ID %q", t.VpcPeeringConnectionID)
+	}
+	return nil
+}
+
+func (t *RouteTarget) ids() []string {
+	return []string{t.InstanceID, t.NetworkInterfaceID, t.TransitGatewayID, t.NatGatewayID, t.GatewayID, t.VpcPeeringConnectionID}
+}
+
+// String returns the target IDs
+func (t *RouteTarget) String() string {
+	if t == nil {
+		return ""
+	}
+	var ids []string
+	for _, id := range t.ids() {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ",")
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
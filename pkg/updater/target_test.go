@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("TargetResolver", func() {
+	nodeRoute := updater.NodeRoute{InstanceID: "i-node1", PodCIDR: "10.243.3.0/24"}
+
+	DescribeTable("should resolve targets",
+		func(resolver updater.TargetResolver, expected *updater.RouteTarget) {
+			target, err := resolver.Resolve(nodeRoute)
+			Expect(err).To(BeNil())
+			Expect(target).To(Equal(expected))
+		},
+		Entry("instance", updater.InstanceTargetResolver{}, &updater.RouteTarget{InstanceID: "i-node1"}),
+		Entry("network interface", updater.NetworkInterfaceTargetResolver{NetworkInterfaceID: "eni-1"}, &updater.RouteTarget{NetworkInterfaceID: "eni-1"}),
+		Entry("transit gateway", updater.TransitGatewayTargetResolver{TransitGatewayID: "tgw-1"}, &updater.RouteTarget{TransitGatewayID: "tgw-1"}),
+		Entry("NAT gateway", updater.NatGatewayTargetResolver{NatGatewayID: "nat-1"}, &updater.RouteTarget{NatGatewayID: "nat-1"}),
+		Entry("gateway", updater.GatewayTargetResolver{GatewayID: "igw-1"}, &updater.RouteTarget{GatewayID: "igw-1"}),
+		Entry("VPC peering connection", updater.VpcPeeringConnectionTargetResolver{VpcPeeringConnectionID: "pcx-1"}, &updater.RouteTarget{VpcPeeringConnectionID: "pcx-1"}),
+	)
+
+	DescribeTable("should fail without target ID",
+		func(resolver updater.TargetResolver) {
+			_, err := resolver.Resolve(updater.NodeRoute{PodCIDR: "10.243.3.0/24"})
+			Expect(err).NotTo(BeNil())
+		},
+		Entry("instance", updater.InstanceTargetResolver{}),
+		Entry("network interface", updater.NetworkInterfaceTargetResolver{}),
+		Entry("transit gateway", updater.TransitGatewayTargetResolver{}),
+		Entry("NAT gateway", updater.NatGatewayTargetResolver{}),
+		Entry("gateway", updater.GatewayTargetResolver{}),
+		Entry("VPC peering connection", updater.VpcPeeringConnectionTargetResolver{}),
+	)
+
+	Context("Update", func() {
+		var (
+			ctrl          *gomock.Controller
+			ec2RoutesMock *updater.MockEC2Routes
+			clusterName   = "shoot--foo--bar"
+			rt1           = aws.String("rt1")
+			clusterTag    = &ec2.Tag{
+				Key:   aws.String(updater.ClusterTagKey(clusterName)),
+				Value: aws.String("1"),
+			}
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			ec2RoutesMock = updater.NewMockEC2Routes(ctrl)
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		It("should create routes to the resolved target", func() {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				TargetResolver: updater.TransitGatewayTargetResolver{TransitGatewayID: "tgw-1"},
+			})
+			Expect(err).To(BeNil())
+
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes: []*ec2.Route{
+						{
+							DestinationCidrBlock: aws.String("10.243.9.0/24"),
+							InstanceId:           aws.String("i-node2"),
+							Origin:               aws.String(ec2.RouteOriginCreateRoute),
+						},
+					},
+				},
+			}}, nil)
+			ec2RoutesMock.EXPECT().DeleteRoute(&ec2.DeleteRouteInput{
+				DestinationCidrBlock: aws.String("10.243.9.0/24"),
+				RouteTableId:         rt1,
+			})
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock: aws.String(nodeRoute.PodCIDR),
+				TransitGatewayId:     aws.String("tgw-1"),
+				RouteTableId:         rt1,
+			})
+			_, err = customRoutes.Update([]updater.NodeRoute{nodeRoute}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+
+		It("should route nodes to the VPC peering connection of their annotation", func() {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes: []*ec2.Route{
+						{
+							DestinationCidrBlock:   aws.String("10.243.9.0/24"),
+							VpcPeeringConnectionId: aws.String("pcx-1"),
+							Origin:                 aws.String(ec2.RouteOriginCreateRoute),
+						},
+					},
+				},
+			}}, nil).Times(2)
+			ec2RoutesMock.EXPECT().CreateRoute(&ec2.CreateRouteInput{
+				DestinationCidrBlock:   aws.String(nodeRoute.PodCIDR),
+				VpcPeeringConnectionId: aws.String("pcx-2"),
+				RouteTableId:           rt1,
+			})
+			peered := []updater.NodeRoute{
+				{InstanceID: "i-node1", PodCIDR: nodeRoute.PodCIDR, VpcPeeringConnectionID: "pcx-2"},
+				{InstanceID: "i-node2", PodCIDR: "10.243.9.0/24", VpcPeeringConnectionID: "pcx-1"},
+			}
+			_, err = customRoutes.Update(peered, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+
+			// the existing peering route is kept, the invalid one is skipped
+			peered[0].VpcPeeringConnectionID = "i-node1"
+			_, err = customRoutes.Update(peered, updater.UpdateOptions{CreateOnly: true})
+			Expect(err).To(MatchError(ContainSubstring(`invalid VPC peering connection ID "i-node1"`)))
+		})
+
+		It("should reject ambiguous targets", func() {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{
+				TargetResolver: ambiguousTargetResolver{},
+			})
+			Expect(err).To(BeNil())
+
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{RouteTableId: rt1, Tags: []*ec2.Tag{clusterTag}},
+			}}, nil)
+			_, err = customRoutes.Update([]updater.NodeRoute{nodeRoute}, updater.UpdateOptions{})
+			Expect(err).To(MatchError(ContainSubstring("route target i-node1,pcx-1 is ambiguous")))
+		})
+
+		It("should keep instance routes reported with network interface", func() {
+			customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{})
+			Expect(err).To(BeNil())
+
+			ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: rt1,
+					Tags:         []*ec2.Tag{clusterTag},
+					Routes: []*ec2.Route{
+						{
+							DestinationCidrBlock: aws.String(nodeRoute.PodCIDR),
+							InstanceId:           aws.String(nodeRoute.InstanceID),
+							NetworkInterfaceId:   aws.String("eni-node1"),
+							Origin:               aws.String(ec2.RouteOriginCreateRoute),
+						},
+					},
+				},
+			}}, nil)
+			_, err = customRoutes.Update([]updater.NodeRoute{nodeRoute}, updater.UpdateOptions{})
+			Expect(err).To(BeNil())
+		})
+	})
+})
+
+// ambiguousTargetResolver returns both the instance and a VPC peering connection as target
+type ambiguousTargetResolver struct{}
+
+func (ambiguousTargetResolver) Resolve(route updater.NodeRoute) (*updater.RouteTarget, error) {
+	return &updater.RouteTarget{InstanceID: route.InstanceID, VpcPeeringConnectionID: "pcx-1"}, nil
+}
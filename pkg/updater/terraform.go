@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// ManagedRoute is a route in a cluster route table managed by the controller
+type ManagedRoute struct {
+	RouteTableID         string
+	DestinationCidrBlock string
+	Target               *RouteTarget
+}
+
+var invalidTerraformNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ListManagedRoutes returns all routes in the cluster route tables managed by the controller
+func (r *CustomRoutes) ListManagedRoutes() ([]ManagedRoute, error) {
+	tables, err := r.findRouteTables()
+	if err != nil {
+		return nil, err
+	}
+	var routes []ManagedRoute
+	for _, table := range tables {
+		for _, route := range r.managedRoutes(table) {
+			routes = append(routes, ManagedRoute{
+				RouteTableID:         aws.StringValue(table.RouteTableId),
+				DestinationCidrBlock: aws.StringValue(route.DestinationCidrBlock),
+				Target:               targetOf(route),
+			})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].RouteTableID != routes[j].RouteTableID {
+			return routes[i].RouteTableID < routes[j].RouteTableID
+		}
+		return routes[i].DestinationCidrBlock < routes[j].DestinationCidrBlock
+	})
+	return routes, nil
+}
+
+// TerraformResourceName returns the name of the aws_route resource for the route
+func (m ManagedRoute) TerraformResourceName() string {
+	return invalidTerraformNameChars.ReplaceAllString(m.RouteTableID+"_"+m.DestinationCidrBlock, "_")
+}
+
+// TerraformImportCommand returns the terraform command to import the route as aws_route resource
+func (m ManagedRoute) TerraformImportCommand() string {
+	return fmt.Sprintf("terraform import aws_route.%s %s_%s", m.TerraformResourceName(), m.RouteTableID, m.DestinationCidrBlock)
+}
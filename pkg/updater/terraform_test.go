@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("Terraform export", func() {
+	It("should generate import commands", func() {
+		route := updater.ManagedRoute{RouteTableID: "rtb-0123abcd", DestinationCidrBlock: "10.243.3.0/24"}
+		Expect(route.TerraformResourceName()).To(Equal("rtb_0123abcd_10_243_3_0_24"))
+		Expect(route.TerraformImportCommand()).To(Equal("terraform import aws_route.rtb_0123abcd_10_243_3_0_24 rtb-0123abcd_10.243.3.0/24"))
+	})
+
+	It("should list only managed routes", func() {
+		ctrl := gomock.NewController(GinkgoT())
+		defer ctrl.Finish()
+		ec2RoutesMock := updater.NewMockEC2Routes(ctrl)
+		clusterName := "shoot--foo--bar"
+		clusterTag := &ec2.Tag{Key: aws.String(updater.ClusterTagKey(clusterName)), Value: aws.String("1")}
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), ec2RoutesMock, clusterName, "10.243.0.0/19", updater.CustomRoutesOptions{})
+		Expect(err).To(BeNil())
+
+		ec2RoutesMock.EXPECT().DescribeRouteTables(&ec2.DescribeRouteTablesInput{}).Return(&ec2.DescribeRouteTablesOutput{RouteTables: []*ec2.RouteTable{
+			{
+				RouteTableId: aws.String("rtb-2"),
+				Tags:         []*ec2.Tag{clusterTag},
+				Routes: []*ec2.Route{
+					{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+					{DestinationCidrBlock: aws.String("10.243.3.0/24"), InstanceId: aws.String("i-node1"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				},
+			},
+			{
+				RouteTableId: aws.String("rtb-1"),
+				Tags:         []*ec2.Tag{clusterTag},
+				Routes: []*ec2.Route{
+					{DestinationCidrBlock: aws.String("10.243.3.0/24"), InstanceId: aws.String("i-node1"), Origin: aws.String(ec2.RouteOriginCreateRoute)},
+				},
+			},
+		}}, nil)
+
+		routes, err := customRoutes.ListManagedRoutes()
+		Expect(err).To(BeNil())
+		var commands []string
+		for _, route := range routes {
+			commands = append(commands, route.TerraformImportCommand())
+		}
+		Expect(commands).To(Equal([]string{
+			"terraform import aws_route.rtb_1_10_243_3_0_24 rtb-1_10.243.3.0/24",
+			"terraform import aws_route.rtb_2_10_243_3_0_24 rtb-2_10.243.3.0/24",
+		}))
+	})
+})
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// errCodeRouteAlreadyExists is the AWS error code for creating a route whose destination already exists in the route table
+const errCodeRouteAlreadyExists = "RouteAlreadyExists"
+
+// verifyRoute reads back the route table until it contains the created route with the expected target,
+// unless the update is aborted
+func (r *CustomRoutes) verifyRoute(tableID string, created internalNodeRoute, abort <-chan struct{}) error {
+	for attempt := 1; ; attempt++ {
+		found, err := r.routeExists(tableID, created)
+		if err != nil {
+			return fmt.Errorf("verifying route %s in table %s failed: %w", created, tableID, err)
+		}
+		if found {
+			return nil
+		}
+		if attempt > aws.IntValue(r.options.VerifyRetries) {
+			return fmt.Errorf("route %s not found in table %s after creation", created, tableID)
+		}
+		r.log.Info("created route not visible yet, retrying", "table", tableID, "destination", created.destinationCidrBlock, "attempt", attempt)
+		if !sleep(abort, r.options.VerifyRetryDelay) {
+			return fmt.Errorf("%w, verifying route %s in table %s skipped", ErrUpdateAborted, created, tableID)
+		}
+	}
+}
+
+func (r *CustomRoutes) routeExists(tableID string, expected internalNodeRoute) (bool, error) {
+	var response *ec2.DescribeRouteTablesOutput
+	err := r.describeLimiter.do(func() (err error) {
+		response, err = r.ec2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+			RouteTableIds: []*string{aws.String(tableID)},
+		})
+		return
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, table := range response.RouteTables {
+		if tableHasRoute(table, expected) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// tableHasRoute returns true if the table contains the route with the expected target
+func tableHasRoute(table *ec2.RouteTable, expected internalNodeRoute) bool {
+	for _, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == expected.destinationCidrBlock && expected.target.matches(route) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRouteAlreadyExists(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == errCodeRouteAlreadyExists
+}
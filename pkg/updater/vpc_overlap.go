@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// VPCOverlapError reports VPC CIDRs overlapping with the pod network
+type VPCOverlapError struct {
+	PodNetworkCIDR string
+	VPCCIDRs       []string
+}
+
+func (e *VPCOverlapError) Error() string {
+	return fmt.Sprintf("pod network CIDR %s overlaps with VPC CIDR %s", e.PodNetworkCIDR, strings.Join(e.VPCCIDRs, ","))
+}
+
+// CheckVPCOverlap returns a VPCOverlapError if the pod network overlaps with a CIDR of the VPCs of the cluster route tables,
+// as the routes to the pod CIDRs would conflict with the local routes of the VPC then.
+func (r *CustomRoutes) CheckVPCOverlap() error {
+	tables, err := r.findRouteTables()
+	if err != nil {
+		return err
+	}
+	vpcIDs := map[string]bool{}
+	for _, table := range tables {
+		if table.VpcId != nil {
+			vpcIDs[*table.VpcId] = true
+		}
+	}
+	if len(vpcIDs) == 0 {
+		return nil
+	}
+	var ids []string
+	for id := range vpcIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	response, err := r.ec2.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: aws.StringSlice(ids)})
+	if err != nil {
+		return fmt.Errorf("describing VPCs %s failed: %w", strings.Join(ids, ","), err)
+	}
+	var overlapping []string
+	for _, vpc := range response.Vpcs {
+		for _, cidr := range vpcCIDRs(vpc) {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			if network.Contains(r.podNetwork.IP) || r.podNetwork.Contains(network.IP) {
+				overlapping = append(overlapping, cidr)
+			}
+		}
+	}
+	if len(overlapping) > 0 {
+		return &VPCOverlapError{PodNetworkCIDR: r.podNetwork.String(), VPCCIDRs: overlapping}
+	}
+	return nil
+}
+
+// vpcCIDRs returns the associated IPv4 CIDRs of the VPC
+func vpcCIDRs(vpc *ec2.Vpc) []string {
+	if len(vpc.CidrBlockAssociationSet) == 0 {
+		if vpc.CidrBlock == nil {
+			return nil
+		}
+		return []string{*vpc.CidrBlock}
+	}
+	var cidrs []string
+	for _, assoc := range vpc.CidrBlockAssociationSet {
+		if assoc.CidrBlockState != nil && aws.StringValue(assoc.CidrBlockState.State) != ec2.VpcCidrBlockStateCodeAssociated {
+			continue
+		}
+		cidrs = append(cidrs, aws.StringValue(assoc.CidrBlock))
+	}
+	return cidrs
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+)
+
+// tableZones maps the route table IDs to the availability zones of their associated subnets
+type tableZones map[string]map[string]bool
+
+// getTableZones determines the availability zones of the route tables from their subnet associations
+func (r *CustomRoutes) getTableZones(tables []*ec2.RouteTable) (tableZones, error) {
+	associations := getSubnetAssociations(tables)
+	zones := tableZones{}
+	if len(associations) == 0 {
+		return zones, nil
+	}
+	var subnetIDs []*string
+	for subnetID := range associations {
+		subnetIDs = append(subnetIDs, aws.String(subnetID))
+	}
+	input := &ec2.DescribeSubnetsInput{SubnetIds: subnetIDs}
+	for {
+		var output *ec2.DescribeSubnetsOutput
+		err := r.describeLimiter.do(func() (err error) {
+			output, err = r.ec2.DescribeSubnets(input)
+			return
+		})
+		if err != nil {
+			return nil, fmt.Errorf("describing subnets failed: %w", err)
+		}
+		for _, subnet := range output.Subnets {
+			tableID := associations[aws.StringValue(subnet.SubnetId)]
+			if zones[tableID] == nil {
+				zones[tableID] = map[string]bool{}
+			}
+			zones[tableID][aws.StringValue(subnet.AvailabilityZone)] = true
+		}
+		if aws.StringValue(output.NextToken) == "" {
+			return zones, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// desiredForTable returns the desired routes for a table. With zone scoped routing, only the routes of the nodes
+// in the zones of the table are returned. Routes of nodes without known zone and tables without subnets in a known
+// zone are not restricted.
+func (r *CustomRoutes) desiredForTable(table *ec2.RouteTable, desired []internalNodeRoute, zones tableZones) []internalNodeRoute {
+	if zones == nil {
+		return desired
+	}
+	scope := zones[aws.StringValue(table.RouteTableId)]
+	if len(scope) == 0 {
+		return desired
+	}
+	var result []internalNodeRoute
+	for _, nr := range desired {
+		if nr.zone == "" || scope[nr.zone] {
+			result = append(result, nr)
+		}
+	}
+	return result
+}
+
+// recordManagedRoutesPerZone exports the number of programmed node routes per availability zone of their route tables.
+// Routes in a route table with subnets in several zones are counted for each of them, route tables without known zone are ignored.
+func recordManagedRoutesPerZone(clusterName string, programmed programmedRoutes, zones tableZones) {
+	counts := map[string]int{}
+	for tableID, tableZones := range zones {
+		for zone := range tableZones {
+			counts[zone] += len(programmed[tableID])
+		}
+	}
+	metrics.ResetCluster(metrics.ManagedRoutesPerAZ, clusterName)
+	for zone, count := range counts {
+		metrics.ManagedRoutesPerAZ.WithLabelValues(clusterName, zone).Set(float64(count))
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package updater_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gardener/aws-custom-route-controller/pkg/metrics"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater"
+	"github.com/gardener/aws-custom-route-controller/pkg/updater/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("managed routes per AZ", func() {
+	var cloud *fake.EC2
+
+	BeforeEach(func() {
+		cloud = fake.NewEC2()
+		clusterTag := &ec2.Tag{Key: aws.String(updater.ClusterTagKey("test")), Value: aws.String("1")}
+		for _, zone := range []string{"a", "b"} {
+			cloud.AddSubnet(&ec2.Subnet{SubnetId: aws.String("subnet-" + zone), AvailabilityZone: aws.String("eu-west-1" + zone)})
+			cloud.AddRouteTable(&ec2.RouteTable{
+				RouteTableId: aws.String("rtb-" + zone),
+				Tags:         []*ec2.Tag{clusterTag},
+				Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-" + zone)}},
+			})
+		}
+		cloud.AddRouteTable(&ec2.RouteTable{RouteTableId: aws.String("rtb-shared"), Tags: []*ec2.Tag{clusterTag}})
+		for _, id := range []string{"i-0001", "i-0002", "i-0003"} {
+			cloud.AddInstance(&ec2.Instance{InstanceId: aws.String(id)})
+		}
+		metrics.ManagedRoutesPerAZ.Reset()
+	})
+
+	update := func(options updater.CustomRoutesOptions) {
+		customRoutes, err := updater.NewCustomRoutes(logf.Log.WithName("test"), cloud, "test", "10.243.0.0/16", options)
+		Expect(err).To(BeNil())
+		_, err = customRoutes.Update([]updater.NodeRoute{
+			{NodeName: "node1", InstanceID: "i-0001", PodCIDR: "10.243.1.0/24", Zone: "eu-west-1a"},
+			{NodeName: "node2", InstanceID: "i-0002", PodCIDR: "10.243.2.0/24", Zone: "eu-west-1a"},
+			{NodeName: "node3", InstanceID: "i-0003", PodCIDR: "10.243.3.0/24", Zone: "eu-west-1b"},
+		}, updater.UpdateOptions{})
+		Expect(err).To(BeNil())
+	}
+
+	It("should count the routes of the route tables per zone with AZ scoped routing", func() {
+		update(updater.CustomRoutesOptions{AZScopedRouting: true})
+		Expect(testutil.CollectAndCount(metrics.ManagedRoutesPerAZ)).To(Equal(2))
+		Expect(testutil.ToFloat64(metrics.ManagedRoutesPerAZ.WithLabelValues("test", "eu-west-1a"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(metrics.ManagedRoutesPerAZ.WithLabelValues("test", "eu-west-1b"))).To(Equal(1.0))
+	})
+
+	It("should count the routes per zone without AZ scoped routing if enabled", func() {
+		update(updater.CustomRoutesOptions{PerAZRouteMetrics: true})
+		Expect(testutil.CollectAndCount(metrics.ManagedRoutesPerAZ)).To(Equal(2))
+		Expect(testutil.ToFloat64(metrics.ManagedRoutesPerAZ.WithLabelValues("test", "eu-west-1a"))).To(Equal(3.0))
+		Expect(testutil.ToFloat64(metrics.ManagedRoutesPerAZ.WithLabelValues("test", "eu-west-1b"))).To(Equal(3.0))
+	})
+
+	It("should keep the series of the other clusters", func() {
+		metrics.ManagedRoutesPerAZ.WithLabelValues("other", "eu-west-1a").Set(5)
+		update(updater.CustomRoutesOptions{AZScopedRouting: true})
+		Expect(testutil.CollectAndCount(metrics.ManagedRoutesPerAZ)).To(Equal(3))
+		Expect(testutil.ToFloat64(metrics.ManagedRoutesPerAZ.WithLabelValues("other", "eu-west-1a"))).To(Equal(5.0))
+	})
+
+	It("should not describe the subnets for the metric by default", func() {
+		update(updater.CustomRoutesOptions{})
+		Expect(testutil.CollectAndCount(metrics.ManagedRoutesPerAZ)).To(Equal(0))
+		Expect(cloud.Calls("DescribeSubnets")).To(Equal(0))
+	})
+})
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CompressPayload", func() {
+	It("should round-trip a large payload", func() {
+		var sb strings.Builder
+		for i := 0; i < 20000; i++ {
+			fmt.Fprintf(&sb, `{"nodeName":"node-%d","podCIDR":"10.%d.%d.0/24","routeTables":["rtb-0001"]},`, i, i/256, i%256)
+		}
+		data := []byte(sb.String())
+		Expect(len(data)).To(BeNumerically(">", 1024*1024))
+
+		payload, err := util.CompressPayload(data)
+		Expect(err).To(BeNil())
+		Expect(len(payload)).To(BeNumerically("<", len(data)/4))
+		decompressed, err := util.DecompressPayload(payload)
+		Expect(err).To(BeNil())
+		Expect(decompressed).To(Equal(data))
+	})
+
+	It("should reject an invalid payload", func() {
+		_, err := util.DecompressPayload("not base64!")
+		Expect(err).NotTo(BeNil())
+		_, err = util.DecompressPayload("bm90IGd6aXA=")
+		Expect(err).NotTo(BeNil())
+	})
+})
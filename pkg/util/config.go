@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"sort"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	// sensitiveAnnotation is the flag annotation marking flags with confidential values
+	sensitiveAnnotation = "sensitive"
+	// RedactedValue replaces the values of sensitive flags
+	RedactedValue = "<redacted>"
+)
+
+// MarkFlagSensitive marks a flag as containing confidential data, so that its value is never dumped
+func MarkFlagSensitive(fs *pflag.FlagSet, name string) error {
+	return fs.SetAnnotation(name, sensitiveAnnotation, []string{"true"})
+}
+
+// EffectiveConfig returns the values of all flags with defaults applied. Values of sensitive flags are redacted.
+func EffectiveConfig(fs *pflag.FlagSet) map[string]string {
+	config := map[string]string{}
+	fs.VisitAll(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+		if _, ok := flag.Annotations[sensitiveAnnotation]; ok && value != "" {
+			value = RedactedValue
+		}
+		config[flag.Name] = value
+	})
+	return config
+}
+
+// SortedKeysAndValues returns the configuration as key/value pairs sorted by key, e.g. for logging
+func SortedKeysAndValues(config map[string]string) []interface{} {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keysAndValues := make([]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		keysAndValues = append(keysAndValues, key, config[key])
+	}
+	return keysAndValues
+}
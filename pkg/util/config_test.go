@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+)
+
+var _ = Describe("EffectiveConfig", func() {
+	It("should dump all flags and redact sensitive values", func() {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		fs.String("region", "", "AWS region")
+		fs.Duration("sync-period", time.Hour, "period for syncing routes")
+		fs.String("target-kubeconfig", "", "path of target kubeconfig")
+		fs.String("password", "", "a secret")
+		fs.String("token", "", "an unset secret")
+		Expect(util.MarkFlagSensitive(fs, "password")).To(Succeed())
+		Expect(util.MarkFlagSensitive(fs, "token")).To(Succeed())
+		Expect(fs.Parse([]string{"--region=eu-west-1", "--target-kubeconfig=/path/kubeconfig", "--password=top-secret"})).To(Succeed())
+
+		config := util.EffectiveConfig(fs)
+		Expect(config).To(Equal(map[string]string{
+			"region":            "eu-west-1",
+			"sync-period":       "1h0m0s",
+			"target-kubeconfig": "/path/kubeconfig",
+			"password":          util.RedactedValue,
+			"token":             "",
+		}))
+		data, err := json.Marshal(config)
+		Expect(err).To(BeNil())
+		Expect(string(data)).NotTo(ContainSubstring("top-secret"))
+	})
+
+	It("should return the configuration as key/value pairs sorted by key", func() {
+		Expect(util.SortedKeysAndValues(map[string]string{"sync-period": "1h0m0s", "region": "eu-west-1", "mode": "manage"})).To(Equal([]interface{}{
+			"mode", "manage",
+			"region", "eu-west-1",
+			"sync-period", "1h0m0s",
+		}))
+	})
+
+	It("should fail to mark an unknown flag", func() {
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		Expect(util.MarkFlagSensitive(fs, "unknown")).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RuntimeLevel is the default log level of a logger, which can be changed while it is running.
+// Log level overrides of individual loggers are not affected.
+type RuntimeLevel struct {
+	level zap.AtomicLevel
+}
+
+func newRuntimeLevel(level zapcore.Level) *RuntimeLevel {
+	return &RuntimeLevel{level: zap.NewAtomicLevelAt(level)}
+}
+
+// Level returns the current log level
+func (l *RuntimeLevel) Level() string {
+	switch l.level.Level() {
+	case zap.DebugLevel:
+		return DebugLevel
+	case zap.ErrorLevel:
+		return ErrorLevel
+	default:
+		return InfoLevel
+	}
+}
+
+// SetLevel changes the log level
+func (l *RuntimeLevel) SetLevel(level string) error {
+	zapLevel, err := toZapLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(zapLevel)
+	return nil
+}
+
+// ServeHTTP returns the log level as JSON. PUT or POST requests change it to the value of the "level" parameter.
+func (l *RuntimeLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level := r.FormValue("level")
+		if level == "" {
+			http.Error(w, "missing level parameter", http.StatusBadRequest)
+			return
+		}
+		if err := l.SetLevel(level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{"level": l.Level()}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
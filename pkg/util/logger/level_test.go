@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("RuntimeLevel", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	serve := func(level *logger.RuntimeLevel, method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		level.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+
+	It("should change the log level via the endpoint", func() {
+		log, level, err := logger.NewZapLoggerWithRuntimeLevel(logger.InfoLevel, logger.FormatLogfmt, nil, logzap.WriteTo(buf))
+		Expect(err).To(BeNil())
+
+		log.V(1).Info("debug before")
+		Expect(buf.String()).To(BeEmpty())
+
+		response := serve(level, http.MethodPut, "/debug/loglevel?level=debug")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(MatchJSON(`{"level":"debug"}`))
+		log.V(1).Info("debug after")
+		Expect(buf.String()).To(ContainSubstring(`msg="debug after"`))
+
+		Expect(serve(level, http.MethodPost, "/debug/loglevel?level=error").Code).To(Equal(http.StatusOK))
+		buf.Reset()
+		log.Info("info")
+		Expect(buf.String()).To(BeEmpty())
+
+		response = serve(level, http.MethodGet, "/debug/loglevel")
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Body.String()).To(MatchJSON(`{"level":"error"}`))
+	})
+
+	It("should keep the overrides when changing the default level", func() {
+		log, level, err := logger.NewZapLoggerWithRuntimeLevel(logger.InfoLevel, logger.FormatLogfmt,
+			map[string]string{"controller": logger.ErrorLevel}, logzap.WriteTo(buf))
+		Expect(err).To(BeNil())
+
+		Expect(level.SetLevel(logger.DebugLevel)).To(Succeed())
+		log.WithName("updater").V(1).Info("updater debug")
+		log.WithName("controller").V(1).Info("controller debug")
+		Expect(buf.String()).To(ContainSubstring(`msg="updater debug"`))
+		Expect(buf.String()).NotTo(ContainSubstring("controller debug"))
+	})
+
+	It("should reject invalid requests", func() {
+		_, level, err := logger.NewZapLoggerWithRuntimeLevel(logger.InfoLevel, logger.FormatJSON, nil, logzap.WriteTo(buf))
+		Expect(err).To(BeNil())
+
+		Expect(serve(level, http.MethodPut, "/debug/loglevel?level=trace").Code).To(Equal(http.StatusBadRequest))
+		Expect(serve(level, http.MethodPut, "/debug/loglevel").Code).To(Equal(http.StatusBadRequest))
+		Expect(serve(level, http.MethodDelete, "/debug/loglevel").Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(level.Level()).To(Equal(logger.InfoLevel))
+	})
+})
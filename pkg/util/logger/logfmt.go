@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	logzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// logfmtEncoder encodes the entries as key=value pairs.
+// It relies on the JSON encoder for the fields and converts its output preserving the order of the keys.
+type logfmtEncoder struct {
+	zapcore.Encoder
+}
+
+// logfmtEncoderOption configures the logger to use the logfmt encoder
+func logfmtEncoderOption(opts ...logzap.EncoderConfigOption) logzap.Opts {
+	return func(o *logzap.Options) {
+		o.NewEncoder = newLogfmtEncoder
+		o.EncoderConfigOptions = append(o.EncoderConfigOptions, opts...)
+	}
+}
+
+func newLogfmtEncoder(opts ...logzap.EncoderConfigOption) zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	for _, opt := range opts {
+		opt(&encoderConfig)
+	}
+	return &logfmtEncoder{Encoder: zapcore.NewJSONEncoder(encoderConfig)}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{Encoder: e.Encoder.Clone()}
+}
+
+func (e *logfmtEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	line, err := jsonToLogfmt(buf.Bytes())
+	if err != nil {
+		buf.Free()
+		return nil, err
+	}
+	buf.Reset()
+	buf.AppendString(line)
+	buf.AppendByte('\n')
+	return buf, nil
+}
+
+// jsonToLogfmt converts a JSON object to key=value pairs. Nested values are kept as JSON.
+func jsonToLogfmt(data []byte) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return "", fmt.Errorf("unexpected log entry %q", string(data))
+	}
+	var pairs []string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return "", fmt.Errorf("unexpected key %v in log entry", token)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return "", err
+		}
+		value := string(raw)
+		if strings.HasPrefix(value, `"`) {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return "", err
+			}
+		}
+		pairs = append(pairs, key+"="+logfmtValue(value))
+	}
+	return strings.Join(pairs, " "), nil
+}
+
+// logfmtValue quotes the value if needed
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.ContainsFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logger_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("logfmt", func() {
+	It("should encode log lines as key=value pairs", func() {
+		buf := &bytes.Buffer{}
+		log, err := logger.NewZapLogger(logger.InfoLevel, logger.FormatLogfmt, logzap.WriteTo(buf))
+		Expect(err).To(BeNil())
+
+		log.WithName("updater").Info("route created", "table", "rtb-1", "destination", "10.243.3.0/24", "retries", 2, "target", "")
+		Expect(buf.String()).To(MatchRegexp(`^level=info ts=\S+ logger=updater msg="route created" table=rtb-1 destination=10.243.3.0/24 retries=2 target=""\n$`))
+	})
+
+	It("should honor the log level", func() {
+		buf := &bytes.Buffer{}
+		log, err := logger.NewZapLogger(logger.ErrorLevel, logger.FormatLogfmt, logzap.WriteTo(buf))
+		Expect(err).To(BeNil())
+
+		log.Info("route created")
+		Expect(buf.String()).To(BeEmpty())
+		log.Error(fmt.Errorf("access \"denied\""), "updating routes failed")
+		Expect(buf.String()).To(MatchRegexp(`^level=error ts=\S+ msg="updating routes failed" error="access \\"denied\\""`))
+	})
+})
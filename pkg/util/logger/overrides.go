@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelOverrideCore filters the log entries by the level configured for the name of their logger.
+// The wrapped core must be enabled for the most verbose of these levels.
+type levelOverrideCore struct {
+	zapcore.Core
+	defaultLevel zap.AtomicLevel
+	overrides    map[string]zapcore.Level
+}
+
+func newLevelOverrideCore(defaultLevel zap.AtomicLevel, overrides map[string]string) (*levelOverrideCore, error) {
+	c := &levelOverrideCore{defaultLevel: defaultLevel, overrides: map[string]zapcore.Level{}}
+	for name, level := range overrides {
+		if name == "" {
+			return nil, fmt.Errorf("missing logger name for log level override %q", level)
+		}
+		zapLevel, err := toZapLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level override for logger %q: %w", name, err)
+		}
+		c.overrides[name] = zapLevel
+	}
+	return c, nil
+}
+
+// enabled returns true if the level is enabled for the default level or any override.
+// It is evaluated for each entry, as the default level can change at runtime.
+func (c *levelOverrideCore) enabled(level zapcore.Level) bool {
+	if c.defaultLevel.Enabled(level) {
+		return true
+	}
+	for _, override := range c.overrides {
+		if override.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *levelOverrideCore) wrap(core zapcore.Core) zapcore.Core {
+	return &levelOverrideCore{Core: core, defaultLevel: c.defaultLevel, overrides: c.overrides}
+}
+
+// levelFor returns the level of the logger with the given name. Names of nested loggers are joined with dots,
+// the last element with an override determines the level, e.g. "updater" matches "main.updater.aws".
+func (c *levelOverrideCore) levelFor(loggerName string) zapcore.Level {
+	level := c.defaultLevel.Level()
+	if loggerName == "" {
+		return level
+	}
+	for _, element := range strings.Split(loggerName, ".") {
+		if override, ok := c.overrides[element]; ok {
+			level = override
+		}
+	}
+	return level
+}
+
+func (c *levelOverrideCore) With(fields []zapcore.Field) zapcore.Core {
+	return c.wrap(c.Core.With(fields))
+}
+
+func (c *levelOverrideCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levelFor(entry.LoggerName).Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logger_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gardener/aws-custom-route-controller/pkg/util/logger"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	logzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("log level overrides", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	It("should log the overridden components at their configured level", func() {
+		log, err := logger.NewZapLoggerWithOverrides(logger.InfoLevel, logger.FormatLogfmt,
+			map[string]string{"updater": logger.DebugLevel, "controller": logger.ErrorLevel}, logzap.WriteTo(buf))
+		Expect(err).To(BeNil())
+		root := log.WithName("main")
+
+		root.V(1).Info("root debug")
+		root.Info("root info")
+		root.WithName("updater").V(1).Info("updater debug")
+		root.WithName("updater").WithName("aws").WithValues("table", "rtb-1").V(1).Info("nested updater debug")
+		root.WithName("controller").WithName("node").Info("controller info")
+		root.WithName("controller").Error(fmt.Errorf("failed"), "controller error")
+
+		Expect(buf.String()).NotTo(ContainSubstring("root debug"))
+		Expect(buf.String()).To(ContainSubstring(`msg="root info"`))
+		Expect(buf.String()).To(ContainSubstring(`logger=main.updater msg="updater debug"`))
+		Expect(buf.String()).To(ContainSubstring(`logger=main.updater.aws msg="nested updater debug" table=rtb-1`))
+		Expect(buf.String()).NotTo(ContainSubstring("controller info"))
+		Expect(buf.String()).To(ContainSubstring(`msg="controller error"`))
+	})
+
+	It("should reject invalid levels", func() {
+		_, err := logger.NewZapLoggerWithOverrides(logger.InfoLevel, logger.FormatJSON, map[string]string{"updater": "trace"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid log level override for logger "updater"`)))
+	})
+})
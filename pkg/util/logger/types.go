@@ -16,11 +16,13 @@ const (
 	FormatJSON = "json"
 	// FormatText outputs the log as human-readable text.
 	FormatText = "text"
+	// FormatLogfmt outputs the log as key=value pairs.
+	FormatLogfmt = "logfmt"
 )
 
 var (
 	// AllLogLevels is a slice of all available log levels.
 	AllLogLevels = []string{DebugLevel, InfoLevel, ErrorLevel}
 	// AllLogFormats is a slice of all available log formats.
-	AllLogFormats = []string{FormatJSON, FormatText}
+	AllLogFormats = []string{FormatJSON, FormatText, FormatLogfmt}
 )
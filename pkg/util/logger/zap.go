@@ -30,21 +30,35 @@ func MustNewZapLogger(level string, format string, additionalOpts ...logzap.Opts
 
 // NewZapLogger creates a new logr.Logger backed by Zap.
 func NewZapLogger(level string, format string, additionalOpts ...logzap.Opts) (logr.Logger, error) {
+	return NewZapLoggerWithOverrides(level, format, nil, additionalOpts...)
+}
+
+// NewZapLoggerWithOverrides creates a new logr.Logger backed by Zap. The overrides map names of loggers
+// (created with WithName) to their log levels, replacing the given level for them and their sub-loggers.
+func NewZapLoggerWithOverrides(level string, format string, overrides map[string]string, additionalOpts ...logzap.Opts) (logr.Logger, error) {
+	logger, _, err := NewZapLoggerWithRuntimeLevel(level, format, overrides, additionalOpts...)
+	return logger, err
+}
+
+// NewZapLoggerWithRuntimeLevel is like NewZapLoggerWithOverrides, but additionally returns the default level
+// for changing it at runtime.
+func NewZapLoggerWithRuntimeLevel(level string, format string, overrides map[string]string, additionalOpts ...logzap.Opts) (logr.Logger, *RuntimeLevel, error) {
 	var opts []logzap.Opts
 
-	// map our log levels to zap levels
-	var zapLevel zapcore.LevelEnabler
-	switch level {
-	case DebugLevel:
-		zapLevel = zap.DebugLevel
-	case ErrorLevel:
-		zapLevel = zap.ErrorLevel
-	case "", InfoLevel:
-		zapLevel = zap.InfoLevel
-	default:
-		return logr.Logger{}, fmt.Errorf("invalid log level %q", level)
+	zapLevel, err := toZapLevel(level)
+	if err != nil {
+		return logr.Logger{}, nil, err
+	}
+	defaultLevel := newRuntimeLevel(zapLevel)
+	if len(overrides) == 0 {
+		opts = append(opts, logzap.Level(defaultLevel.level))
+	} else {
+		core, err := newLevelOverrideCore(defaultLevel.level, overrides)
+		if err != nil {
+			return logr.Logger{}, nil, err
+		}
+		opts = append(opts, logzap.Level(zap.LevelEnablerFunc(core.enabled)), logzap.RawZapOpts(zap.WrapCore(core.wrap)))
 	}
-	opts = append(opts, logzap.Level(zapLevel))
 
 	// map our log format to encoder
 	switch format {
@@ -52,9 +66,25 @@ func NewZapLogger(level string, format string, additionalOpts ...logzap.Opts) (l
 		opts = append(opts, logzap.ConsoleEncoder(setCommonEncoderConfigOptions))
 	case "", FormatJSON:
 		opts = append(opts, logzap.JSONEncoder(setCommonEncoderConfigOptions))
+	case FormatLogfmt:
+		opts = append(opts, logfmtEncoderOption(setCommonEncoderConfigOptions))
 	default:
-		return logr.Logger{}, fmt.Errorf("invalid log format %q", format)
+		return logr.Logger{}, nil, fmt.Errorf("invalid log format %q", format)
 	}
 
-	return logzap.New(append(opts, additionalOpts...)...), nil
+	return logzap.New(append(opts, additionalOpts...)...), defaultLevel, nil
+}
+
+// toZapLevel maps our log levels to zap levels
+func toZapLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case DebugLevel:
+		return zap.DebugLevel, nil
+	case ErrorLevel:
+		return zap.ErrorLevel, nil
+	case "", InfoLevel:
+		return zap.InfoLevel, nil
+	default:
+		return zap.InfoLevel, fmt.Errorf("invalid log level %q", level)
+	}
 }
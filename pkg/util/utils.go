@@ -23,3 +23,30 @@ func GetIPv4CIDR(cidrs []string) (string, error) {
 	}
 	return "", nil
 }
+
+// ContainsCIDR returns true if the inner network is completely covered by the outer network
+func ContainsCIDR(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// SupernetCIDR returns the smallest network covering all given networks of the same address family
+func SupernetCIDR(networks []*net.IPNet) *net.IPNet {
+	if len(networks) == 0 {
+		return nil
+	}
+	ones, bits := networks[0].Mask.Size()
+	supernet := &net.IPNet{IP: networks[0].IP, Mask: networks[0].Mask}
+	for _, network := range networks[1:] {
+		for !ContainsCIDR(supernet, network) {
+			if ones == 0 {
+				return nil
+			}
+			ones--
+			mask := net.CIDRMask(ones, bits)
+			supernet = &net.IPNet{IP: supernet.IP.Mask(mask), Mask: mask}
+		}
+	}
+	return supernet
+}